@@ -0,0 +1,243 @@
+// Package clienttest runs modules against an in-process watermelon
+// server for unit tests. The hosts of the test inventory use the fake
+// backend, so no commands are actually run, and the tests can check
+// the files written and the commands run by the module:
+//
+//	func TestInstall(t *testing.T) {
+//	    h, err := clienttest.New("mymodule", nil,
+//	        &server.Host{HostInfo: pb.HostInfo{ID: "web1", Labels: []string{"web"}}})
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    defer h.Close()
+//	    h.Backend.OnOutput("web1", "^systemctl is-active", "active\n")
+//
+//	    rsp, err := h.Call("install", nil)
+//	    ...
+//	    if f := h.Backend.GetFile("web1", "/etc/nginx/nginx.conf"); f == nil {
+//	        t.Errorf("Config not written")
+//	    }
+//	}
+//
+// The harness replaces the localhost backend and registers the fake
+// backend globally until it is closed, so tests using it must not run
+// in parallel.
+package clienttest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	grpc "google.golang.org/grpc"
+
+	"github.com/bserdar/watermelon/client"
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/backends/fake"
+	"github.com/bserdar/watermelon/server/inventory"
+	"github.com/bserdar/watermelon/server/logging"
+	"github.com/bserdar/watermelon/server/module"
+	"github.com/bserdar/watermelon/server/pb"
+	"github.com/bserdar/watermelon/server/remote"
+	"github.com/bserdar/watermelon/server/schema"
+	"github.com/bserdar/watermelon/server/session"
+)
+
+// Harness contains an in-process watermelon server with the
+// Lifecycle, Inventory, and Remote services, and a client runtime
+// connected to it
+type Harness struct {
+	// Module is the name of the module under test. Calls to this
+	// module, including the handlers, are dispatched to the functions
+	// of the harness
+	Module string
+	// Backend is the fake backend used by all hosts, including
+	// localhost
+	Backend *fake.Backend
+	// Session is the server session
+	Session server.Session
+	// Runtime is the client runtime connected to the server
+	Runtime *client.Runtime
+	// Logdir is the temporary directory containing the host logs
+	Logdir string
+	// Schema is the module schema the configuration and the function
+	// arguments are validated against. It is initialized from the
+	// declarations of client.DeclareConfig and client.DeclareArgs
+	Schema *schema.ModuleSchema
+
+	lmgr             *module.LifecycleManager
+	grpcServer       *grpc.Server
+	listener         net.Listener
+	localhostBackend server.HostBackend
+	fakeBackend      func(*server.Host) server.HostBackend
+	sessionFactory   func() server.Session
+}
+
+// New starts a server with the given hosts in its inventory, and
+// returns a harness connected to it. If funcs is nil, the functions
+// registered using client.Export are used. Hosts are initialized
+// with defaults, and their backends are replaced with the fake
+// backend. The harness must be closed after use.
+func New(moduleName string, funcs client.Functions, hosts ...*server.Host) (*Harness, error) {
+	if funcs == nil {
+		funcs = client.RegisteredFunctions()
+	}
+	logdir, err := ioutil.TempDir("", "wmtest")
+	if err != nil {
+		return nil, err
+	}
+	h := &Harness{Module: moduleName,
+		Backend:        fake.New(),
+		Logdir:         logdir,
+		sessionFactory: server.SessionFactory}
+	if server.SessionFactory == nil {
+		server.SessionFactory = session.Factory
+	}
+	if data := client.DeclaredSchema(); data != nil {
+		if h.Schema, err = schema.Parse(data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, x := range hosts {
+		x.Defaults()
+		x.Backend = h.Backend
+		x.BackendName = "fake"
+	}
+	// Hosts added during the run use the fake backend
+	h.fakeBackend = server.RegisterBackend("fake", func(*server.Host) server.HostBackend { return h.Backend })
+	h.localhostBackend = server.Localhost.Backend
+	server.Localhost.Backend = h.Backend
+
+	h.Session = server.NewSession()
+	h.Session.SetLog(logging.Logging{Logdir: logdir})
+	h.Session.SetInv(inventory.NewInvServer(hosts, server.InventoryConfiguration{}, h.Session))
+
+	h.lmgr = module.NewLifecycleManager()
+	h.lmgr.LocalModuleFunc = h.dispatch
+	h.Session.SetModules(h.lmgr)
+
+	h.listener, err = net.Listen("tcp", "localhost:0")
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.grpcServer = grpc.NewServer()
+	pb.RegisterLifecycleServer(h.grpcServer, h.lmgr)
+	pb.RegisterInventoryServer(h.grpcServer, inventory.NewServer())
+	pb.RegisterRemoteServer(h.grpcServer, remote.New())
+	go h.grpcServer.Serve(h.listener)
+
+	// The worker listener is required by the runtime, but calls are
+	// dispatched to the functions directly
+	workerListener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.Runtime, err = client.NewRuntime(h.listener.Addr().String(), workerListener, funcs, nil)
+	if err != nil {
+		workerListener.Close()
+		h.Close()
+		return nil, err
+	}
+	h.lmgr.SetModulePort(h.Module, h.Runtime.Port)
+	return h, nil
+}
+
+// ClientSession returns a client session that can be passed to the
+// module functions directly
+func (h *Harness) ClientSession() *client.Session {
+	return h.Runtime.Session(h.Session.GetID())
+}
+
+// Call calls a function of the module under test the way "watermelon
+// run" does. The handlers queued until the end of the run are flushed
+// after the call, and their results are added to the response. data
+// is passed as is if it is []byte or string, and marshaled to JSON
+// otherwise.
+func (h *Harness) Call(funcName string, data interface{}) (server.Response, error) {
+	var fdata []byte
+	switch x := data.(type) {
+	case nil:
+	case []byte:
+		fdata = x
+	case string:
+		fdata = []byte(x)
+	default:
+		var err error
+		fdata, err = json.Marshal(data)
+		if err != nil {
+			return server.Response{}, err
+		}
+	}
+	rsp, err := h.lmgr.SendRequest(h.Session.GetID(), h.Module, funcName, fdata)
+	if err != nil {
+		return rsp, err
+	}
+	hrsp, err := h.Session.GetHandlers().Flush(h.lmgr, h.Session.GetID(), nil)
+	if err != nil {
+		return rsp, err
+	}
+	rsp.Append(hrsp)
+	return rsp, nil
+}
+
+// dispatch calls the functions of the module under test in-process,
+// and the local modules for the rest
+func (h *Harness) dispatch(sessionID, moduleName, funcName string, data []byte) (server.Response, bool, error) {
+	if moduleName != h.Module {
+		return server.CallLocalModule(sessionID, moduleName, funcName, data)
+	}
+	if err := h.lmgr.ValidateCall(h.Module, h.Schema, sessionID, funcName, data); err != nil {
+		return server.Response{}, true, err
+	}
+	callID := uuid.New().String()
+	ws, err := h.Runtime.Worker.Process(context.Background(), &pb.Request{Session: sessionID, FuncName: funcName, Data: data, CallId: callID})
+	if err != nil {
+		return server.Response{}, true, err
+	}
+	ret := server.Response{Success: ws.Success,
+		FuncName: ws.FuncName,
+		ErrorMsg: ws.ErrorMsg,
+		Modified: ws.Modified,
+		Data:     ws.Data}
+	hrsp, err := h.Session.GetHandlers().FlushCall(h.lmgr, sessionID, callID)
+	if err != nil {
+		return ret, true, err
+	}
+	ret.Append(hrsp)
+	return ret, true, nil
+}
+
+// Log returns the contents of the log file of the host
+func (h *Harness) Log(hostID string) string {
+	data, _ := ioutil.ReadFile(filepath.Join(h.Logdir, hostID))
+	return string(data)
+}
+
+// Close stops the server, and restores the localhost backend, the
+// backend registry, and the session factory
+func (h *Harness) Close() {
+	if h.Runtime != nil {
+		h.Runtime.Worker.Stop()
+		h.Runtime.ClientConn.Close()
+	}
+	if h.grpcServer != nil {
+		h.grpcServer.Stop()
+	}
+	if h.listener != nil {
+		h.listener.Close()
+	}
+	if h.Session != nil {
+		h.Session.Close()
+	}
+	server.Localhost.Backend = h.localhostBackend
+	server.RegisterBackend("fake", h.fakeBackend)
+	server.SessionFactory = h.sessionFactory
+	os.RemoveAll(h.Logdir)
+}
@@ -0,0 +1,402 @@
+package clienttest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bserdar/watermelon/client"
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/backends/fake"
+	"github.com/bserdar/watermelon/server/pb"
+	"github.com/bserdar/watermelon/server/schema"
+)
+
+func install(s *client.Session) error {
+	s.DeclareHandler(client.Handler{Name: "restart", Module: "web", Func: "restart"})
+	if !s.ForAllSelected(client.Has("web"), func(host client.Host) error {
+		if string(host.Command("systemctl is-active nginx").Stdout) != "inactive\n" {
+			return nil
+		}
+		changed, err := host.WriteFileIfDifferent("/etc/nginx/nginx.conf", 0644, []byte("config "+host.ID))
+		if err != nil {
+			return err
+		}
+		host.NotifyIf(changed, "restart")
+		return nil
+	}) {
+		return fmt.Errorf("Install failed")
+	}
+	return nil
+}
+
+func restart(s *client.Session, args client.HandlerArgs) {
+	s.Command(args.HostID, "systemctl restart nginx")
+}
+
+func TestHarness(t *testing.T) {
+	funcs := client.Functions{}
+	funcs.Add("install", install)
+	funcs.Add("restart", restart)
+	h, err := New("web", funcs,
+		&server.Host{HostInfo: pb.HostInfo{ID: "web1", Labels: []string{"web"}}},
+		&server.Host{HostInfo: pb.HostInfo{ID: "web2", Labels: []string{"web"}}},
+		&server.Host{HostInfo: pb.HostInfo{ID: "db1", Labels: []string{"db"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Backend.OnOutput("", "^systemctl is-active", "inactive\n")
+	h.Backend.OnOutput("web2", "^systemctl is-active", "active\n")
+
+	rsp, err := h.Call("install", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsp.Success {
+		t.Fatalf("Failed: %s", rsp.ErrorMsg)
+	}
+	if f := h.Backend.GetFile("web1", "/etc/nginx/nginx.conf"); f == nil || string(f.Content) != "config web1" || f.Mode != 0644 {
+		t.Errorf("Wrong file: %+v", f)
+	}
+	if f := h.Backend.GetFile("web1", "/etc/nginx"); f == nil || !f.Dir {
+		t.Errorf("Expecting parent dir")
+	}
+	if w := h.Backend.Written("web2"); len(w) != 0 {
+		t.Errorf("Unexpected writes: %v", w)
+	}
+	if ops := h.Backend.Ops("db1"); len(ops) != 0 {
+		t.Errorf("Unexpected ops: %v", ops)
+	}
+	cmds := h.Backend.Commands("web1")
+	if len(cmds) != 2 || cmds[1] != "systemctl restart nginx" {
+		t.Errorf("Wrong commands: %v", cmds)
+	}
+	if !strings.Contains(h.Log("web1"), "systemctl restart nginx") {
+		t.Errorf("Command not logged: %s", h.Log("web1"))
+	}
+
+	// Second run does not change anything
+	rsp, _ = h.Call("install", nil)
+	if rsp.Modified {
+		t.Errorf("Expecting no modifications")
+	}
+	if cmds := h.Backend.Commands("web1"); len(cmds) != 3 {
+		t.Errorf("Wrong commands: %v", cmds)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	h, err := New("caps", client.Functions{},
+		&server.Host{HostInfo: pb.HostInfo{ID: "h1"}},
+		&server.Host{HostInfo: pb.HostInfo{ID: "h2"}, Become: "doas"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	s := h.ClientSession()
+
+	caps, err := s.Capabilities("h1")
+	if err != nil || caps.Backend != "fake" || !caps.Checksum || caps.FileTransfer != "memory" {
+		t.Errorf("Wrong capabilities: %+v %v", caps, err)
+	}
+	if _, err := s.Capabilities("h2"); err == nil || !strings.Contains(err.Error(), "become doas is unsupported on backend fake") {
+		t.Errorf("Expecting unsupported error, got %v", err)
+	}
+
+	h.Backend.SetFile("h1", "/f", 0644, []byte("abc"))
+	sum, err := s.Checksum("h1", "/f")
+	if err != nil || sum != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("Wrong checksum: %s %v", sum, err)
+	}
+	if sum, err := s.Checksum("h1", "/missing"); sum != "" || err != nil {
+		t.Errorf("Expecting missing file: %s %v", sum, err)
+	}
+
+	// Unchanged file is compared using checksums, and not read
+	n := len(h.Backend.Ops("h1"))
+	changed, err := s.WriteFileIfDifferent("h1", "/f", 0644, []byte("abc"))
+	if changed || err != nil {
+		t.Errorf("Expecting no change: %v", err)
+	}
+	for _, op := range h.Backend.Ops("h1")[n:] {
+		if op.Op == fake.OpRead || op.Op == fake.OpWrite {
+			t.Errorf("Unexpected op: %+v", op)
+		}
+	}
+
+	// The destination checksum is computed once
+	h.Backend.SetFile("h1", "/g", 0644, []byte("xyz"))
+	n = len(h.Backend.Ops("h1"))
+	changed, err = s.CopyIfDifferent("h1", "/f", "h1", "/g")
+	if !changed || err != nil {
+		t.Errorf("Expecting change: %v", err)
+	}
+	sums := 0
+	for _, op := range h.Backend.Ops("h1")[n:] {
+		if op.Op == fake.OpChecksum && op.Path == "/g" {
+			sums++
+		}
+	}
+	if sums != 1 {
+		t.Errorf("Expecting one checksum, got %d", sums)
+	}
+}
+
+func TestAddHost(t *testing.T) {
+	h, err := New("provision", client.Functions{},
+		&server.Host{HostInfo: pb.HostInfo{ID: "h1", Labels: []string{"web"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	s := h.ClientSession()
+
+	if _, err := s.AddHost(client.NewHost{ID: "vm1"}); err == nil {
+		t.Errorf("Expecting error for linux host without ssh")
+	}
+	if _, err := s.AddHost(client.NewHost{ID: "vm1", Backend: "fake", SSH: &client.SSHConfig{Hostname: "vm1", Port: 70000}}); err == nil {
+		t.Errorf("Expecting error for invalid port")
+	}
+	if _, err := s.AddHost(client.NewHost{ID: "h1", Backend: "fake"}); err == nil {
+		t.Errorf("Expecting error for duplicate host")
+	}
+	info, err := s.AddHost(client.NewHost{ID: "vm1",
+		Backend:       "fake",
+		Addresses:     map[string]string{"primary": "10.0.0.5"},
+		Labels:        []string{"web"},
+		Properties:    map[string]string{"dc": "east"},
+		Configuration: map[string]interface{}{"port": 8080}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "vm1" || len(info.Addresses) != 1 || info.Addresses[0].Address != "10.0.0.5" {
+		t.Errorf("Wrong host info: %+v", info)
+	}
+	var port int
+	s.GetHostCfg("vm1", "/port", &port)
+	if port != 8080 {
+		t.Errorf("Wrong configuration: %d", port)
+	}
+
+	if !s.ForAllSelected(client.Has("web"), func(host client.Host) error {
+		host.Command("uptime")
+		return nil
+	}) {
+		t.Errorf("ForAll failed")
+	}
+	if cmds := h.Backend.Commands("vm1"); len(cmds) != 1 || cmds[0] != "uptime" {
+		t.Errorf("Wrong commands: %v", cmds)
+	}
+
+	if err := s.SetLabels("vm1", "db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetProperties("vm1", map[string]string{"rack": "a1"}); err != nil {
+		t.Fatal(err)
+	}
+	hosts := s.GetHostInfo([]string{"vm1"})
+	if len(hosts) != 1 || len(hosts[0].Labels) != 1 || hosts[0].Labels[0] != "db" ||
+		hosts[0].Properties["dc"] != "east" || hosts[0].Properties["rack"] != "a1" {
+		t.Errorf("Wrong host: %+v", hosts)
+	}
+	if err := s.ReplaceProperties("vm1", map[string]string{"rack": "b2"}); err != nil {
+		t.Fatal(err)
+	}
+	if hosts := s.GetHostInfo([]string{"vm1"}); len(hosts[0].Properties) != 1 {
+		t.Errorf("Properties not replaced: %v", hosts[0].Properties)
+	}
+
+	if _, err := s.AddHost(client.NewHost{ID: "vm2", Backend: "fake", SSH: &client.SSHConfig{Hostname: "10.0.0.6", Bastion: "bastion"}}); err == nil {
+		t.Errorf("Expecting error for undefined bastion")
+	}
+	if _, err := s.AddHost(client.NewHost{ID: "vm2", Backend: "fake", SSH: &client.SSHConfig{Hostname: "10.0.0.6", Bastion: "vm1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveHost("vm1"); err == nil {
+		t.Errorf("Expecting error for removing a bastion")
+	}
+	if err := s.RemoveHost("vm2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveHost("vm1"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := s.GetHostIDs(client.AllHosts); len(ids) != 1 || ids[0] != "h1" {
+		t.Errorf("Host not removed: %v", ids)
+	}
+	if err := s.RemoveHost("vm1"); err == nil {
+		t.Errorf("Expecting error for unknown host")
+	}
+}
+
+func TestForAllRolling(t *testing.T) {
+	hosts := make([]*server.Host, 0)
+	for i := 1; i <= 10; i++ {
+		hosts = append(hosts, &server.Host{HostInfo: pb.HostInfo{ID: fmt.Sprintf("h%02d", i)}})
+	}
+	h, err := New("rolling", client.Functions{}, hosts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	s := h.ClientSession()
+
+	checked := make([][]string, 0)
+	failOn := map[string]bool{"h02": true, "h07": true}
+	f := func(host client.Host) error {
+		if failOn[host.ID] {
+			return fmt.Errorf("Failed")
+		}
+		return nil
+	}
+	check := client.RollingOptions{Check: func(batch int, hosts []string) error {
+		checked = append(checked, hosts)
+		return nil
+	}}
+
+	// 2 of 10 hosts fail, threshold is 20%
+	r := s.ForAllRolling(client.AllHosts, client.BatchSize(3), 20, f, check)
+	if r.Aborted || r.OK() || len(r.Failed()) != 2 || len(checked) != 3 || len(checked[0]) != 3 {
+		t.Errorf("Wrong result: %s checks: %v", r, checked)
+	}
+
+	// Threshold crossed after the third batch
+	r = s.ForAllRolling(client.AllHosts, client.BatchPercent(30), 10, f, client.RollingOptions{})
+	if !r.Aborted || r.Hosts[6].Skipped || !r.Hosts[9].Skipped || r.Hosts[9].Batch != 3 {
+		t.Errorf("Wrong result: %s", r)
+	}
+
+	// Health check aborts the run
+	r = s.ForAllRolling(client.AllHosts, client.BatchSize(4), 100, f, client.RollingOptions{Check: func(int, []string) error {
+		return fmt.Errorf("unhealthy")
+	}})
+	if !r.Aborted || !strings.Contains(r.Reason, "unhealthy") || r.Hosts[3].Skipped || !r.Hosts[4].Skipped {
+		t.Errorf("Wrong result: %s", r)
+	}
+}
+
+type dbConfig struct {
+	Host string `json:"host" jsonschema:"required"`
+	Port int    `json:"port" jsonschema:"default=5432,minimum=1"`
+	Mode string `json:"mode" jsonschema:"enum=primary|replica"`
+}
+
+type connectArgs struct {
+	User string `json:"user" jsonschema:"required"`
+}
+
+func TestSchema(t *testing.T) {
+	funcs := client.Functions{}
+	funcs.Add("connect", func(s *client.Session, args connectArgs) dbConfig {
+		var cfg dbConfig
+		s.GetHostCfg("db1", "/db", &cfg)
+		return cfg
+	})
+	h, err := New("db", funcs,
+		&server.Host{HostInfo: pb.HostInfo{ID: "db1"}, Configuration: map[string]interface{}{"db": map[string]interface{}{"host": "h1"}}},
+		&server.Host{HostInfo: pb.HostInfo{ID: "db2"}, Configuration: map[string]interface{}{"db": map[string]interface{}{"port": "x", "mode": "none"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Session.SetConfig(map[string]interface{}{"db": map[string]interface{}{"port": 0}})
+	h.Schema = &schema.ModuleSchema{
+		Config:    &schema.Schema{Type: "object", Properties: map[string]*schema.Schema{"db": client.SchemaOf(dbConfig{})}},
+		Functions: map[string]*schema.Schema{"connect": client.SchemaOf(connectArgs{})}}
+
+	if _, err := h.Call("connect", nil); err == nil || !strings.Contains(err.Error(), "/user: required") {
+		t.Errorf("Expecting argument error: %v", err)
+	}
+	_, err = h.Call("connect", connectArgs{User: "u"})
+	if err == nil {
+		t.Fatal("Expecting configuration errors")
+	}
+	for _, msg := range []string{"/db/host: required (hosts db2)", "/db/port: 0 is less than 1 (hosts db1)", "/db/port: expecting integer, got string (hosts db2)", "/db/mode: none is not one of"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("Missing %s in %s", msg, err)
+		}
+	}
+
+	h2, err := New("db", funcs,
+		&server.Host{HostInfo: pb.HostInfo{ID: "db1"}, Configuration: map[string]interface{}{"db": map[string]interface{}{"host": "h1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+	h2.Schema = h.Schema
+	rsp, err := h2.Call("connect", connectArgs{User: "u"})
+	if err != nil || !rsp.Success {
+		t.Fatalf("Call failed: %v %+v", err, rsp)
+	}
+	if !strings.Contains(string(rsp.Data), `"port":5432`) {
+		t.Errorf("Default not applied: %s", rsp.Data)
+	}
+	// The defaults are only seen by the module, and added once
+	if v, err := h2.Session.GetCfg("db1", "/db/port"); err != nil || v != nil {
+		t.Errorf("Default seen by the session: %v %v", v, err)
+	}
+	if _, err := h2.Call("connect", connectArgs{User: "u"}); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, l := range h2.Session.GetConfigLayers() {
+		if l.Module == "db" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("Expecting one schema layer, got %d", n)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	funcs := client.Functions{}
+	funcs.Add("connect", func(s *client.Session, args connectArgs) (dbConfig, error) {
+		return dbConfig{}, nil
+	})
+	funcs.Add("noargs", func(s *client.Session) {})
+	client.Doc("connect", "Connects to the database")
+	h, err := New("db", funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	desc := h.Runtime.Worker.Describe()
+	if len(desc.Functions) != 2 || desc.Functions[0].Name != "connect" || desc.Functions[1].Name != "noargs" {
+		t.Fatalf("Unexpected functions: %v", desc.Functions)
+	}
+	f := desc.Functions[0]
+	if f.Doc != "Connects to the database" ||
+		!strings.Contains(string(f.Args), `"user"`) ||
+		!strings.Contains(string(f.Returns), `"port"`) {
+		t.Errorf("Unexpected description: %v", f)
+	}
+	if f := desc.Functions[1]; len(f.Args) != 0 || len(f.Returns) != 0 {
+		t.Errorf("Unexpected schemas: %v", f)
+	}
+}
+
+func TestWrapOutputs(t *testing.T) {
+	funcs := client.Functions{}
+	funcs.Add("out", func(s *client.Session, args connectArgs) (*connectArgs, error) {
+		return &args, nil
+	})
+	funcs.Add("fail", func(s *client.Session, args connectArgs) error {
+		return fmt.Errorf("failed for %s", args.User)
+	})
+	h, err := New("db", funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	rsp, err := h.Call("out", `{"user":"u"}`)
+	if err != nil || !rsp.Success || string(rsp.Data) != `{"user":"u"}` {
+		t.Errorf("Unexpected response: %+v %v", rsp, err)
+	}
+	rsp, err = h.Call("fail", `{"user":"u"}`)
+	if err != nil || rsp.Success || rsp.ErrorMsg != "failed for u" {
+		t.Errorf("Unexpected response: %+v %v", rsp, err)
+	}
+}
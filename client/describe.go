@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/bserdar/watermelon/server/pb"
+	"github.com/bserdar/watermelon/server/schema"
+)
+
+var moduleDoc string
+
+var functionDocs = map[string]string{}
+
+// functionSchemas are the argument and return value schemas of the
+// functions added using Functions.Add
+var functionSchemas = map[string][2]*schema.Schema{}
+
+// ModuleDoc sets the module description shown by describe
+//
+//	var _ = client.ModuleDoc("Installs and configures PostgreSQL")
+func ModuleDoc(doc string) int {
+	moduleDoc = doc
+	return 0
+}
+
+// Doc sets the description of an exported function shown by describe
+//
+//	var _ = client.Export("db.Bootstrap", Bootstrap)
+//	var _ = client.Doc("db.Bootstrap", "Creates the database and the users")
+func Doc(function, doc string) int {
+	functionDocs[function] = doc
+	return 0
+}
+
+func marshalSchema(s *schema.Schema) []byte {
+	if s == nil {
+		return nil
+	}
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// Describe returns the description of the module, containing the
+// exported functions and the methods of the registered GRPC servers.
+// Argument schemas declared using DeclareArgs have precedence over the
+// schemas derived from the function signatures
+func (w *WorkServer) Describe() *pb.ModuleDescription {
+	ret := &pb.ModuleDescription{Doc: moduleDoc}
+	add := func(name string, args, returns *schema.Schema) {
+		if declared, ok := declaredSchema.Functions[name]; ok {
+			args = declared
+		}
+		ret.Functions = append(ret.Functions, &pb.FunctionDescription{Name: name,
+			Doc:     functionDocs[name],
+			Args:    marshalSchema(args),
+			Returns: marshalSchema(returns)})
+	}
+	for name := range w.Functions {
+		s := functionSchemas[name]
+		add(name, s[0], s[1])
+	}
+	ctxType := reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	for prefix, srv := range w.grpcServers {
+		v := reflect.ValueOf(srv)
+		for i := 0; i < v.NumMethod(); i++ {
+			// Methods that can be called by Process
+			typ := v.Method(i).Type()
+			if typ.NumIn() == 2 && typ.NumOut() == 2 && typ.In(0) == ctxType &&
+				typ.In(1).Kind() == reflect.Ptr && typ.Out(1) == errorType {
+				add(prefix+"."+v.Type().Method(i).Name, schemaOf(typ.In(1)), schemaOf(typ.Out(0)))
+			}
+		}
+	}
+	sort.Slice(ret.Functions, func(i, j int) bool { return ret.Functions[i].Name < ret.Functions[j].Name })
+	return ret
+}
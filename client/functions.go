@@ -1,13 +1,30 @@
 package client
 
+import (
+	"github.com/bserdar/watermelon/server/schema"
+)
+
 var registeredFunctions = Functions{}
 
 // Functions are used to keep track of the registered functions
 type Functions map[string]func(*Session, []byte) ([]byte, error)
 
-// Add adds a new function to the functions list
+// Add adds a new function to the functions list. The schemas of the
+// function argument and return value are reported to describe
 func (f Functions) Add(name string, function interface{}) {
 	f[name] = Wrap(function)
+	args, returns := FunctionSchemas(function)
+	functionSchemas[name] = [2]*schema.Schema{args, returns}
+}
+
+// RegisteredFunctions returns a copy of the functions registered
+// using Export
+func RegisteredFunctions() Functions {
+	ret := make(Functions, len(registeredFunctions))
+	for k, v := range registeredFunctions {
+		ret[k] = v
+	}
+	return ret
 }
 
 // Export can be used to register a function as an anonymous variable
@@ -3,6 +3,8 @@ package client
 import (
 	"encoding/json"
 	"reflect"
+
+	"github.com/bserdar/watermelon/server/schema"
 )
 
 // Wrap wraps a function and translates json input/output for the wrapped function.
@@ -32,12 +34,32 @@ func Wrap(in interface{}) func(*Session, []byte) ([]byte, error) {
 	}
 }
 
+// FunctionSchemas returns the JSON schemas of the InStruct and the
+// OutStruct of a function accepted by Wrap. A schema is nil if the
+// function does not have it, or if it is a byte array
+func FunctionSchemas(in interface{}) (args, returns *schema.Schema) {
+	fType := reflect.TypeOf(in)
+	if fType == nil || fType.Kind() != reflect.Func {
+		return nil, nil
+	}
+	bytesType := reflect.TypeOf([]byte{})
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	if fType.NumIn() == 2 && fType.In(1) != bytesType {
+		args = schemaOf(fType.In(1))
+	}
+	if fType.NumOut() > 0 && fType.Out(0) != bytesType && fType.Out(0) != errorType {
+		returns = schemaOf(fType.Out(0))
+	}
+	return args, returns
+}
+
 func wrap(in interface{}, preserveOut bool) func(*Session, []byte) (interface{}, error) {
 	f := reflect.ValueOf(in)
 	fType := f.Type()
 	if fType.Kind() != reflect.Func {
 		panic("Input to Wrap is not a function")
 	}
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
 	numInArgs := fType.NumIn()
 	if numInArgs != 1 && numInArgs != 2 {
 		panic("Input function must get one or two args")
@@ -97,9 +119,9 @@ func wrap(in interface{}, preserveOut bool) func(*Session, []byte) (interface{},
 			return nil, nil
 		}
 		if len(out) == 1 {
-			var e error
-			if reflect.TypeOf(out[0]) == reflect.TypeOf(e) {
-				return nil, out[0].Interface().(error)
+			if fType.Out(0) == errorType {
+				err, _ := out[0].Interface().(error)
+				return nil, err
 			}
 			if out[0].Interface() == nil {
 				return nil, nil
@@ -111,13 +133,14 @@ func wrap(in interface{}, preserveOut bool) func(*Session, []byte) (interface{},
 			return b, nil
 		}
 
+		err, _ := out[1].Interface().(error)
 		if out[0].Interface() == nil {
-			return nil, out[1].Interface().(error)
+			return nil, err
 		}
 		if preserveOut {
-			return out[0].Interface(), out[1].Interface().(error)
+			return out[0].Interface(), err
 		}
 		b, _ := json.Marshal(out[0].Interface())
-		return b, out[1].Interface().(error)
+		return b, err
 	}
 }
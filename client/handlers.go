@@ -0,0 +1,27 @@
+package client
+
+// Handler declares a module function to run for the hosts it is
+// notified for. A handler is usually notified when an operation
+// modifies a host, and runs once per host no matter how many times it
+// is notified:
+//
+//	session.DeclareHandler(client.Handler{Name: "restart nginx", Module: "nginx", Func: "nginx.Restart"})
+//	...
+//	host.NotifyIf(changed, "restart nginx")
+//
+// The handler function receives HandlerArgs as its input.
+type Handler struct {
+	Name   string
+	Module string
+	Func   string
+	// If true, the handler runs at the end of the module call that
+	// notified it. Otherwise, it runs at the end of the run, or when
+	// the handlers are flushed
+	AfterCall bool
+}
+
+// HandlerArgs is the input of a handler function
+type HandlerArgs struct {
+	HostID  string `json:"hostId"`
+	Handler string `json:"handler"`
+}
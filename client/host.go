@@ -104,6 +104,17 @@ func (h Host) WaitHost(timeout time.Duration) error {
 	return h.S.WaitHost(h.ID, timeout)
 }
 
+// WaitHostWith waits until host becomes available using the given
+// poll intervals
+func (h Host) WaitHostWith(timeout time.Duration, opts WaitOptions) error {
+	return h.S.WaitHostWith(h.ID, timeout, opts)
+}
+
+// Reboot reboots the host and waits until it comes back
+func (h Host) Reboot(timeout time.Duration) error {
+	return h.S.Reboot(h.ID, timeout)
+}
+
 // GetFileInfo retrieves file information
 func (h Host) GetFileInfo(path string) (os.FileInfo, FileOwner) { return h.S.GetFileInfo(h.ID, path) }
 
@@ -122,6 +133,25 @@ func (h Host) Chown(path string, user, group string) error { return h.S.Chown(h.
 // Ensure a file has certain attributes. Returns true if things changed
 func (h Host) Ensure(path string, req Ensure) bool { return h.S.Ensure(h.ID, path, req) }
 
+// Capabilities returns the capabilities of the host backend
+func (h Host) Capabilities() (Capabilities, error) { return h.S.Capabilities(h.ID) }
+
+// Checksum returns the hex SHA256 checksum of a file. Returns empty
+// string if the file does not exist
+func (h Host) Checksum(path string) (string, error) { return h.S.Checksum(h.ID, path) }
+
+// Notify queues the handler for this host
+func (h Host) Notify(handler string) { h.S.Notify(h.ID, handler) }
+
+// NotifyIf queues the handler for this host if changed is true, and
+// returns changed
+func (h Host) NotifyIf(changed bool, handler string) bool {
+	if changed {
+		h.Notify(handler)
+	}
+	return changed
+}
+
 // GetInfo returns host info. Panics on invalid host
 func (h Host) GetInfo() pb.HostInfo {
 	info := h.S.GetHostInfo([]string{h.ID})
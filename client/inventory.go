@@ -2,6 +2,9 @@ package client
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 
 	"github.com/bserdar/watermelon/server/pb"
 )
@@ -115,6 +118,95 @@ func (inv Inventory) Release(session string, id string) {
 	inv.impl.Release(context.Background(), &pb.InvIdRequest{Session: session, ID: string(id)})
 }
 
+// SSHConfig contains the ssh settings of a new host
+type SSHConfig struct {
+	Hostname string
+	Network  string
+	Port     int
+	User     string
+	Password string
+	Become   string
+	// Bastion is the ID of the host used to reach this host
+	Bastion string
+}
+
+// NewHost describes a host to add to the inventory during a run
+type NewHost struct {
+	ID string
+	// Addresses of the host keyed by name. The primary address is
+	// named "primary"
+	Addresses  map[string]string
+	Labels     []string
+	Properties map[string]string
+	// Backend is the backend used to reach the host. Default is linux
+	Backend string
+	// Wrapper is the command template for the exec backend
+	Wrapper string
+	SSH     *SSHConfig
+	// Configuration is the host configuration. It is marshaled as JSON
+	Configuration interface{}
+}
+
+// AddHost adds a new host to the inventory of all hosts
+func (inv Inventory) AddHost(session string, host NewHost) (*pb.HostInfo, error) {
+	req := &pb.NewHost{ID: host.ID,
+		Labels:     host.Labels,
+		Properties: host.Properties,
+		Backend:    host.Backend,
+		Wrapper:    host.Wrapper}
+	for _, name := range sortedKeys(host.Addresses) {
+		req.Addresses = append(req.Addresses, &pb.Address{Name: name, Address: host.Addresses[name]})
+	}
+	if host.SSH != nil {
+		req.SSH = &pb.SSHConfig{Hostname: host.SSH.Hostname,
+			Network:  host.SSH.Network,
+			Port:     int32(host.SSH.Port),
+			User:     host.SSH.User,
+			Password: host.SSH.Password,
+			Become:   host.SSH.Become,
+			Bastion:  host.SSH.Bastion}
+	}
+	if host.Configuration != nil {
+		data, err := json.Marshal(host.Configuration)
+		if err != nil {
+			return nil, err
+		}
+		req.Configuration = data
+	}
+	return inv.impl.AddHost(context.Background(), &pb.InvAddHostRequest{Session: session, Host: req})
+}
+
+// RemoveHost removes a host from all inventories
+func (inv Inventory) RemoveHost(session string, hostID string) error {
+	_, err := inv.impl.RemoveHost(context.Background(), &pb.InvRemoveHostRequest{Session: session, HostId: hostID})
+	return err
+}
+
+// SetLabels replaces the labels of a host
+func (inv Inventory) SetLabels(session string, hostID string, labels []string) (*pb.HostInfo, error) {
+	return inv.impl.SetLabels(context.Background(), &pb.InvSetLabelsRequest{Session: session,
+		HostId: hostID,
+		Labels: labels})
+}
+
+// SetProperties sets the properties of a host. If replace is true,
+// the existing properties are removed
+func (inv Inventory) SetProperties(session string, hostID string, properties map[string]string, replace bool) (*pb.HostInfo, error) {
+	return inv.impl.SetProperties(context.Background(), &pb.InvSetPropertiesRequest{Session: session,
+		HostId:     hostID,
+		Properties: properties,
+		Replace:    replace})
+}
+
+func sortedKeys(m map[string]string) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 func makeKeyValues(in []KeyAndValues) *pb.PropertySet {
 	p := make([]*pb.PropertySet_KVS, len(in))
 	for i, x := range in {
@@ -133,10 +225,38 @@ func selectorToPb(sel Selector) *pb.Selector {
 		return &pb.Selector{Select: &pb.Selector_HasNoneLabels{HasNoneLabels: &pb.LabelSet{Labels: k.Labels}}}
 	case SelectByID:
 		return &pb.Selector{Select: &pb.Selector_ByID{ByID: &pb.HostIdSet{IDs: k.IDs}}}
+	case SelectByName:
+		return &pb.Selector{Select: &pb.Selector_IDGlob{IDGlob: &pb.PatternSet{Patterns: k.Names}}}
+	case SelectByIDGlob:
+		return &pb.Selector{Select: &pb.Selector_IDGlob{IDGlob: &pb.PatternSet{Patterns: k.Patterns}}}
+	case SelectByIDRegex:
+		return &pb.Selector{Select: &pb.Selector_IDRegex{IDRegex: &pb.PatternSet{Patterns: k.Patterns}}}
+	case SelectByCIDR:
+		return &pb.Selector{Select: &pb.Selector_InCIDR{InCIDR: &pb.CIDRSet{CIDRs: k.CIDRs}}}
+	case SelectByPropertyRegex:
+		return &pb.Selector{Select: &pb.Selector_HasPropertyRegex{HasPropertyRegex: &pb.PropertyRegex{Key: k.Key, Regex: k.Regex}}}
+	case SelectByPropertyExists:
+		return &pb.Selector{Select: &pb.Selector_HasProperties{HasProperties: &pb.PropertyKeys{Keys: k.Keys}}}
 	case SelectByAnyProperty:
 		return &pb.Selector{Select: &pb.Selector_HasAnyProperty{HasAnyProperty: makeKeyValues(k.Any)}}
 	case SelectByAllProperty:
 		return &pb.Selector{Select: &pb.Selector_HasAllProperty{HasAllProperty: makeKeyValues(k.All)}}
+	case SelectAnd:
+		return &pb.Selector{Select: &pb.Selector_And{And: selectorListToPb(k.Selectors)}}
+	case SelectOr:
+		return &pb.Selector{Select: &pb.Selector_Or{Or: selectorListToPb(k.Selectors)}}
+	case SelectNot:
+		return &pb.Selector{Select: &pb.Selector_Not{Not: selectorToPb(k.Selector)}}
+	case SelectExpr:
+		return &pb.Selector{Select: &pb.Selector_Expr{Expr: k.Expr}}
+	}
+	panic(fmt.Sprintf("Unknown selector: %T", sel))
+}
+
+func selectorListToPb(sel []Selector) *pb.SelectorList {
+	ret := &pb.SelectorList{Selectors: make([]*pb.Selector, 0, len(sel))}
+	for _, x := range sel {
+		ret.Selectors = append(ret.Selectors, selectorToPb(x))
 	}
-	return nil
+	return ret
 }
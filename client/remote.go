@@ -77,6 +77,29 @@ func (e Ensure) EnsureMode(mode int) Ensure {
 	return e
 }
 
+// Capabilities describes the operations supported by the backend of
+// a host
+type Capabilities struct {
+	// Backend is the name of the host backend
+	Backend string
+	// Streaming is true if command output is logged while the command
+	// runs
+	Streaming bool
+	// Become lists the supported privilege escalation methods
+	Become []string
+	// Checksum is true if file checksums can be computed on the host
+	Checksum bool
+	// Symlinks is true if the host filesystem supports symlinks
+	Symlinks bool
+	// AtomicRename is true if files are replaced atomically
+	AtomicRename bool
+	// Ownership is true if file modes and owners can be changed
+	Ownership bool
+	// FileTransfer is the file transfer method: sftp, scp, stream,
+	// local, or memory
+	FileTransfer string
+}
+
 // Name returns the file name
 func (c CommonFileInfo) Name() string { return c.FileName }
 
@@ -186,14 +209,34 @@ func (r Remote) CopyFile(session string, from string, fromPath string, to string
 	return rsp.Changed, nil
 }
 
+// WaitOptions control how a host is polled while waiting for it. The
+// poll interval starts at PollInterval and doubles after every failed
+// attempt until it reaches MaxInterval. Zero values select the
+// server defaults.
+type WaitOptions struct {
+	PollInterval time.Duration
+	MaxInterval  time.Duration
+}
+
 // WaitHost waits until host becomes available
-func (r Remote) WaitHost(session string, hostID string, timeout time.Duration) error {
+func (r Remote) WaitHost(session string, hostID string, timeout time.Duration, opts WaitOptions) error {
 	_, err := r.impl.WaitHost(context.Background(), &pb.WaitHostRequest{Session: session,
-		HostId:  hostID,
-		Timeout: int64(timeout)})
+		HostId:       hostID,
+		Timeout:      int64(timeout),
+		PollInterval: int64(opts.PollInterval),
+		MaxInterval:  int64(opts.MaxInterval)})
 	return err
 }
 
+// Reboot reboots the host and waits until it comes back
+func (r Remote) Reboot(session string, hostID string, timeout time.Duration, opts WaitOptions) (*pb.RebootResponse, error) {
+	return r.impl.Reboot(context.Background(), &pb.RebootRequest{Session: session,
+		HostId:       hostID,
+		Timeout:      int64(timeout),
+		PollInterval: int64(opts.PollInterval),
+		MaxInterval:  int64(opts.MaxInterval)})
+}
+
 // GetFileInfo retrieves file information
 func (r Remote) GetFileInfo(session string, hostID string, path string) (os.FileInfo, FileOwner, error) {
 	fi, err := r.impl.GetFileInfo(context.Background(), &pb.PathRequest{Session: session,
@@ -289,3 +332,32 @@ func (r Remote) Ensure(session string, hostID string, path string, req Ensure) (
 	}
 	return rsp.Changed, nil
 }
+
+// GetCapabilities returns the capabilities of the host backend
+func (r Remote) GetCapabilities(session string, hostID string) (Capabilities, *pb.CommandError, error) {
+	rsp, err := r.impl.GetCapabilities(context.Background(), &pb.HostRequest{Session: session,
+		HostId: hostID})
+	if err != nil {
+		return Capabilities{}, nil, err
+	}
+	return Capabilities{Backend: rsp.Backend,
+		Streaming:    rsp.Streaming,
+		Become:       rsp.Become,
+		Checksum:     rsp.Checksum,
+		Symlinks:     rsp.Symlinks,
+		AtomicRename: rsp.AtomicRename,
+		Ownership:    rsp.Ownership,
+		FileTransfer: rsp.FileTransfer}, rsp.Error, nil
+}
+
+// Checksum returns the SHA256 checksum of a file. Returns empty
+// string if the file does not exist
+func (r Remote) Checksum(session string, hostID string, path string) (string, *pb.CommandError, error) {
+	rsp, err := r.impl.Checksum(context.Background(), &pb.PathRequest{Session: session,
+		HostId: hostID,
+		Path:   path})
+	if err != nil {
+		return "", nil, err
+	}
+	return rsp.Sha256, rsp.Error, nil
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batch is the size of each batch of a rolling run. Use BatchSize or
+// BatchPercent to build one
+type Batch struct {
+	Size    int
+	Percent int
+}
+
+// BatchSize returns a batch of n hosts
+func BatchSize(n int) Batch { return Batch{Size: n} }
+
+// BatchPercent returns a batch containing the given percentage of the
+// hosts
+func BatchPercent(p int) Batch { return Batch{Percent: p} }
+
+// hosts returns the number of hosts in a batch for a run of total
+// hosts. A batch contains at least one host
+func (b Batch) hosts(total int) int {
+	n := b.Size
+	if n <= 0 {
+		n = (total*b.Percent + 99) / 100
+	}
+	if n <= 0 {
+		n = 1
+	}
+	return n
+}
+
+// RollingOptions are the optional settings of a rolling run
+type RollingOptions struct {
+	// Pause is the time to wait between batches
+	Pause time.Duration
+	// Check is called after the pause following each batch except
+	// the last one with the batch number and the hosts of the batch,
+	// e.g. to check the health of the service before continuing. If
+	// it returns an error, the run is aborted
+	Check func(batch int, hosts []string) error
+}
+
+// HostResult is the result of a rolling run for a host
+type HostResult struct {
+	HostID string
+	// Batch is the batch number of the host, starting from 0
+	Batch int
+	Err   error
+	// Skipped is true if the run was aborted before the host
+	Skipped bool
+}
+
+// RollingResult contains the results of a rolling run
+type RollingResult struct {
+	Hosts []HostResult
+	// Aborted is true if the run stopped before all hosts were run
+	Aborted bool
+	// Reason is the reason the run is aborted
+	Reason string
+}
+
+// OK returns true if all hosts ran without errors
+func (r RollingResult) OK() bool {
+	if r.Aborted {
+		return false
+	}
+	for _, h := range r.Hosts {
+		if h.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the IDs of the hosts that failed
+func (r RollingResult) Failed() []string {
+	ret := make([]string, 0)
+	for _, h := range r.Hosts {
+		if h.Err != nil {
+			ret = append(ret, h.HostID)
+		}
+	}
+	return ret
+}
+
+// String returns a summary of the results, one line for each host
+func (r RollingResult) String() string {
+	b := strings.Builder{}
+	for _, h := range r.Hosts {
+		switch {
+		case h.Skipped:
+			fmt.Fprintf(&b, "%s\tbatch %d\tskipped\n", h.HostID, h.Batch)
+		case h.Err != nil:
+			fmt.Fprintf(&b, "%s\tbatch %d\tfailed: %s\n", h.HostID, h.Batch, h.Err)
+		default:
+			fmt.Fprintf(&b, "%s\tbatch %d\tok\n", h.HostID, h.Batch)
+		}
+	}
+	if r.Aborted {
+		fmt.Fprintf(&b, "Aborted: %s\n", r.Reason)
+	}
+	return b.String()
+}
+
+// ForAllRolling runs f for the hosts in inv in batches. The hosts of
+// a batch run in parallel, and the next batch starts when all the
+// hosts of the batch are done. If the percentage of failed hosts
+// exceeds maxFailPercent, the remaining batches are skipped. Use 0 to
+// stop after the first batch with a failure.
+func (s *Session) ForAllRolling(inv string, batch Batch, maxFailPercent int, f func(Host) error, opts RollingOptions) RollingResult {
+	ids := s.GetHostIDs(inv)
+	result := RollingResult{Hosts: make([]HostResult, len(ids))}
+	size := batch.hosts(len(ids))
+	for i, id := range ids {
+		result.Hosts[i] = HostResult{HostID: id, Batch: i / size, Skipped: true}
+	}
+	failed := 0
+	for start := 0; start < len(ids); start += size {
+		end := start + size
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(r *HostResult) {
+				defer wg.Done()
+				host := s.Host(r.HostID)
+				r.Skipped = false
+				r.Err = f(host)
+				if r.Err != nil {
+					host.Logf("Error: %s", r.Err.Error())
+				}
+			}(&result.Hosts[i])
+		}
+		wg.Wait()
+		for i := start; i < end; i++ {
+			if result.Hosts[i].Err != nil {
+				failed++
+			}
+		}
+		if end == len(ids) {
+			break
+		}
+		if failed*100 > maxFailPercent*len(ids) {
+			result.Aborted = true
+			result.Reason = fmt.Sprintf("%d of %d hosts failed, more than %d%%", failed, len(ids), maxFailPercent)
+			break
+		}
+		time.Sleep(opts.Pause)
+		if opts.Check != nil {
+			if err := opts.Check(start/size, ids[start:end]); err != nil {
+				result.Aborted = true
+				result.Reason = fmt.Sprintf("Check after batch %d failed: %s", start/size, err)
+				break
+			}
+		}
+	}
+	if result.Aborted {
+		s.Printf("Rolling run aborted: %s", result.Reason)
+	}
+	return result
+}
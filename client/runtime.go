@@ -50,10 +50,7 @@ func Run(args []string, funcs Functions, registerGRPCServers func(*grpc.Server,
 		panic(err)
 	}
 
-	allFuncs := make(Functions)
-	for k, v := range registeredFunctions {
-		allFuncs[k] = v
-	}
+	allFuncs := RegisteredFunctions()
 	for k, v := range funcs {
 		allFuncs[k] = v
 	}
@@ -168,7 +165,7 @@ func (rt *Runtime) Call(session, module, function string, data interface{}) (*pb
 // GetCfgJSON retrieves a configuration item pointed to by path, a
 // JSON pointer, and returns the JSON for it.
 func (rt *Runtime) GetCfgJSON(session, path string) ([]byte, error) {
-	ret, err := rt.LCClient.GetCfg(context.Background(), &pb.CfgRequest{Session: session, Path: path})
+	ret, err := rt.LCClient.GetCfg(context.Background(), &pb.CfgRequest{Session: session, Path: path, Port: int32(rt.Port)})
 	if err != nil {
 		return nil, err
 	}
@@ -193,7 +190,7 @@ func (rt *Runtime) GetCfg(session, path string, out interface{}) error {
 // host does not have the configuration item, this looks at the global
 // configuration
 func (rt *Runtime) GetHostCfgJSON(session, host, path string) ([]byte, error) {
-	ret, err := rt.LCClient.GetCfg(context.Background(), &pb.CfgRequest{Session: session, HostId: host, Path: path})
+	ret, err := rt.LCClient.GetCfg(context.Background(), &pb.CfgRequest{Session: session, HostId: host, Path: path, Port: int32(rt.Port)})
 	if err != nil {
 		return nil, err
 	}
@@ -214,6 +211,40 @@ func (rt *Runtime) GetHostCfg(session, host, path string, out interface{}) error
 	return json.Unmarshal(x, out)
 }
 
+// DeclareHandler declares a handler for the session
+func (rt *Runtime) DeclareHandler(session string, h Handler) error {
+	_, err := rt.LCClient.DeclareHandler(context.Background(), &pb.HandlerRequest{Session: session,
+		Name:      h.Name,
+		Module:    h.Module,
+		FuncName:  h.Func,
+		AfterCall: h.AfterCall})
+	return err
+}
+
+// Notify queues a handler for a host
+func (rt *Runtime) Notify(session, callID, hostID, handler string) error {
+	_, err := rt.LCClient.Notify(context.Background(), &pb.NotifyRequest{Session: session,
+		CallId:  callID,
+		HostId:  hostID,
+		Handler: handler})
+	return err
+}
+
+// FlushHandlers runs the queued handlers for the hosts. If no hosts
+// are given, runs all queued handlers
+func (rt *Runtime) FlushHandlers(session string, hosts []string) (*pb.Response, error) {
+	return rt.LCClient.FlushHandlers(context.Background(), &pb.FlushHandlersRequest{Session: session,
+		HostIds: hosts})
+}
+
+// AddSecrets marks the values as secrets, so they are masked in logs
+// and outputs
+func (rt *Runtime) AddSecrets(session string, values []string) error {
+	_, err := rt.LCClient.AddSecrets(context.Background(), &pb.SecretsRequest{Session: session,
+		Values: values})
+	return err
+}
+
 // runLifecycle connects to the server, responds to pings, and waits
 // for the term signal. Once terminated, it returns from this function
 // with nil. Any communication error will immediately return
@@ -223,7 +254,7 @@ func (rt *Runtime) runLifecycle(serverPort int) error {
 		return err
 	}
 	err = lifecycleCli.Send(&pb.LifecycleRequest{RequestType: pb.LifecycleRequest_CONNECT,
-		Msg: &pb.LifecycleRequest_ConnectMsg{ConnectMsg: &pb.Connect{Port: int32(serverPort)}}})
+		Msg: &pb.LifecycleRequest_ConnectMsg{ConnectMsg: &pb.Connect{Port: int32(serverPort), Schema: DeclaredSchema()}}})
 	if err != nil {
 		return err
 	}
@@ -238,6 +269,12 @@ func (rt *Runtime) runLifecycle(serverPort int) error {
 			if err != nil {
 				return err
 			}
+		case pb.LifecycleRequest_DESCRIBE:
+			err := lifecycleCli.Send(&pb.LifecycleRequest{RequestType: pb.LifecycleRequest_DESCRIBE,
+				Msg: &pb.LifecycleRequest_Description{Description: rt.Worker.Describe()}})
+			if err != nil {
+				return err
+			}
 		case pb.LifecycleRequest_TERM:
 			return nil
 		}
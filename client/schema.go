@@ -0,0 +1,203 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bserdar/watermelon/server/schema"
+)
+
+var declaredSchema = schema.ModuleSchema{}
+
+// DeclareConfig declares the configuration the module expects at
+// path, a JSON pointer, using the fields of v. The configuration is
+// validated before the first call to the module, and the defaults are
+// applied to the configuration returned by the GetCfg calls of the
+// module. Other modules do not see the defaults.
+//
+//	type DBConfig struct {
+//	  Host string `json:"host" jsonschema:"required"`
+//	  Port int    `json:"port" jsonschema:"default=5432,minimum=1"`
+//	  Mode string `json:"mode" jsonschema:"enum=primary|replica"`
+//	}
+//
+//	var _ = client.DeclareConfig("/db", DBConfig{})
+//
+// If the struct has required fields, path is required as well
+func DeclareConfig(path string, v interface{}) int {
+	s := SchemaOf(v)
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if declaredSchema.Config == nil {
+		declaredSchema.Config = &schema.Schema{Type: "object"}
+	}
+	parent := declaredSchema.Config
+	for i, seg := range segments {
+		key := strings.Replace(strings.Replace(seg, "~1", "/", -1), "~0", "~", -1)
+		if parent.Properties == nil {
+			parent.Properties = map[string]*schema.Schema{}
+		}
+		if len(s.Required) > 0 && !contains(parent.Required, key) {
+			parent.Required = append(parent.Required, key)
+		}
+		if i == len(segments)-1 {
+			parent.Properties[key] = s
+			break
+		}
+		next, ok := parent.Properties[key]
+		if !ok {
+			next = &schema.Schema{Type: "object"}
+			parent.Properties[key] = next
+		}
+		parent = next
+	}
+	return 0
+}
+
+func contains(values []string, s string) bool {
+	for _, x := range values {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DeclareArgs declares the arguments of the function using the fields
+// of v. The arguments are validated before each call.
+//
+//	var _ = client.DeclareArgs("db.Bootstrap", BootstrapArgs{})
+func DeclareArgs(function string, v interface{}) int {
+	if declaredSchema.Functions == nil {
+		declaredSchema.Functions = map[string]*schema.Schema{}
+	}
+	declaredSchema.Functions[function] = SchemaOf(v)
+	return 0
+}
+
+// DeclaredSchema returns the JSON schema declared using DeclareConfig
+// and DeclareArgs, or nil if there are no declarations
+func DeclaredSchema() []byte {
+	if declaredSchema.Config == nil && declaredSchema.Functions == nil {
+		return nil
+	}
+	data, _ := json.Marshal(declaredSchema)
+	return data
+}
+
+// SchemaOf returns the schema for the Go value v. Struct field names
+// are taken from json tags. The jsonschema tag contains comma
+// separated options:
+//
+//	required
+//	default=value
+//	enum=value1|value2|...
+//	minimum=n
+//	maximum=n
+//
+// and the description tag contains the field description
+func SchemaOf(v interface{}) *schema.Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *schema.Schema {
+	if t == nil {
+		return &schema.Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &schema.Schema{Type: "string"}
+	case reflect.Bool:
+		return &schema.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema.Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schema.Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &schema.Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema.Schema{Type: "object"}
+	case reflect.Struct:
+		s := &schema.Schema{Type: "object", Properties: map[string]*schema.Schema{}}
+		addFields(s, t)
+		return s
+	}
+	return &schema.Schema{}
+}
+
+// addFields adds the exported fields of struct t to s. Fields of
+// embedded structs are added as if they are fields of t
+func addFields(s *schema.Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			n := strings.Split(tag, ",")[0]
+			if n == "-" {
+				continue
+			}
+			if len(n) > 0 {
+				name = n
+			} else if f.Anonymous {
+				name = ""
+			}
+		} else if f.Anonymous {
+			name = ""
+		}
+		if len(name) == 0 && f.Type.Kind() == reflect.Struct {
+			addFields(s, f.Type)
+			continue
+		}
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		fs := schemaOf(f.Type)
+		fs.Description = f.Tag.Get("description")
+		for _, opt := range strings.Split(f.Tag.Get("jsonschema"), ",") {
+			kv := strings.SplitN(opt, "=", 2)
+			switch kv[0] {
+			case "required":
+				s.Required = append(s.Required, name)
+			case "default":
+				if len(kv) == 2 {
+					fs.Default = tagValue(fs, kv[1])
+				}
+			case "enum":
+				if len(kv) == 2 {
+					for _, e := range strings.Split(kv[1], "|") {
+						fs.Enum = append(fs.Enum, tagValue(fs, e))
+					}
+				}
+			case "minimum", "maximum":
+				if len(kv) == 2 {
+					if n, err := strconv.ParseFloat(kv[1], 64); err == nil {
+						if kv[0] == "minimum" {
+							fs.Minimum = &n
+						} else {
+							fs.Maximum = &n
+						}
+					}
+				}
+			}
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// tagValue converts a value given in a struct tag to the type of the
+// schema
+func tagValue(s *schema.Schema, value string) interface{} {
+	if s.Type == "string" {
+		return value
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+	return v
+}
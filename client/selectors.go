@@ -26,11 +26,43 @@ type SelectByID struct {
 	IDs []string
 }
 
-// SelectByName is a selector that selects hosts by name
+// SelectByName is a selector that selects hosts by name. Names can
+// be glob patterns matching host IDs
 type SelectByName struct {
 	Names []string
 }
 
+// SelectByIDGlob is a selector that selects hosts whose ID matches any
+// of the glob patterns
+type SelectByIDGlob struct {
+	Patterns []string
+}
+
+// SelectByIDRegex is a selector that selects hosts whose ID matches
+// any of the regular expressions
+type SelectByIDRegex struct {
+	Patterns []string
+}
+
+// SelectByCIDR is a selector that selects hosts having an address in
+// any of the networks
+type SelectByCIDR struct {
+	CIDRs []string
+}
+
+// SelectByPropertyRegex is a selector that selects hosts whose
+// property value matches the regular expression
+type SelectByPropertyRegex struct {
+	Key   string
+	Regex string
+}
+
+// SelectByPropertyExists is a selector that selects hosts having all
+// the properties, regardless of their values
+type SelectByPropertyExists struct {
+	Keys []string
+}
+
 // KeyAndValues contains a property key, and a set of values one of which should match
 type KeyAndValues struct {
 	Key    string
@@ -47,6 +79,26 @@ type SelectByAllProperty struct {
 	All []KeyAndValues
 }
 
+// SelectAnd is a selector that selects hosts matching all the selectors
+type SelectAnd struct {
+	Selectors []Selector
+}
+
+// SelectOr is a selector that selects hosts matching any of the selectors
+type SelectOr struct {
+	Selectors []Selector
+}
+
+// SelectNot is a selector that selects hosts not matching the selector
+type SelectNot struct {
+	Selector Selector
+}
+
+// SelectExpr is a selector that selects hosts matching an expression
+type SelectExpr struct {
+	Expr string
+}
+
 // Has returns a selector that selects hosts containing label
 func Has(label string) HasAllLabels {
 	return HasAllOf(label)
@@ -77,6 +129,36 @@ func WithNames(name ...string) SelectByName {
 	return SelectByName{Names: name}
 }
 
+// WithIDGlob returns a selector that selects hosts whose ID matches
+// any of the glob patterns, e.g. "web-*"
+func WithIDGlob(pattern ...string) SelectByIDGlob {
+	return SelectByIDGlob{Patterns: pattern}
+}
+
+// WithIDRegex returns a selector that selects hosts whose ID matches
+// any of the regular expressions
+func WithIDRegex(regex ...string) SelectByIDRegex {
+	return SelectByIDRegex{Patterns: regex}
+}
+
+// InCIDR returns a selector that selects hosts having an address in
+// any of the networks, e.g. "10.20.0.0/16"
+func InCIDR(cidr ...string) SelectByCIDR {
+	return SelectByCIDR{CIDRs: cidr}
+}
+
+// WithPropertyRegex returns a selector that selects hosts whose
+// property value matches the regular expression
+func WithPropertyRegex(key, regex string) SelectByPropertyRegex {
+	return SelectByPropertyRegex{Key: key, Regex: regex}
+}
+
+// WithProperty returns a selector that selects hosts having all the
+// property keys
+func WithProperty(key ...string) SelectByPropertyExists {
+	return SelectByPropertyExists{Keys: key}
+}
+
 // WithAnyProperty returns a selector that selects hosts containing any of the given properties
 func WithAnyProperty(kv ...KeyAndValues) SelectByAnyProperty {
 	return SelectByAnyProperty{Any: kv}
@@ -87,10 +169,44 @@ func WithAllProperty(kv ...KeyAndValues) SelectByAllProperty {
 	return SelectByAllProperty{All: kv}
 }
 
-func (h HasAllLabels) isSelector()        {}
-func (h HasAnyLabel) isSelector()         {}
-func (h HasNoneLabels) isSelector()       {}
-func (h SelectByID) isSelector()          {}
-func (h SelectByName) isSelector()        {}
-func (h SelectByAnyProperty) isSelector() {}
-func (h SelectByAllProperty) isSelector() {}
+// And returns a selector that selects hosts matching all the selectors
+func And(sel ...Selector) SelectAnd {
+	return SelectAnd{Selectors: sel}
+}
+
+// Or returns a selector that selects hosts matching any of the selectors
+func Or(sel ...Selector) SelectOr {
+	return SelectOr{Selectors: sel}
+}
+
+// Not returns a selector that selects hosts not matching sel
+func Not(sel Selector) SelectNot {
+	return SelectNot{Selector: sel}
+}
+
+// Where returns a selector that selects hosts matching the
+// expression. A word selects hosts with that label, key=value and
+// key!=value compare host properties, and &&, ||, !, and parentheses
+// combine them:
+//
+//	client.Where("db && (prod || staging) && !maintenance")
+func Where(expr string) SelectExpr {
+	return SelectExpr{Expr: expr}
+}
+
+func (h HasAllLabels) isSelector()           {}
+func (h HasAnyLabel) isSelector()            {}
+func (h HasNoneLabels) isSelector()          {}
+func (h SelectByID) isSelector()             {}
+func (h SelectByName) isSelector()           {}
+func (h SelectByAnyProperty) isSelector()    {}
+func (h SelectByAllProperty) isSelector()    {}
+func (h SelectAnd) isSelector()              {}
+func (h SelectOr) isSelector()               {}
+func (h SelectNot) isSelector()              {}
+func (h SelectExpr) isSelector()             {}
+func (h SelectByIDGlob) isSelector()         {}
+func (h SelectByIDRegex) isSelector()        {}
+func (h SelectByCIDR) isSelector()           {}
+func (h SelectByPropertyRegex) isSelector()  {}
+func (h SelectByPropertyExists) isSelector() {}
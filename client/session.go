@@ -20,6 +20,9 @@ type Session struct {
 	Rt       *Runtime
 	ID       string
 	Modified bool
+	// CallID identifies the module call this session is running
+	// for. Handlers notified using this session are tagged with it
+	CallID string
 }
 
 // CommandError is an error message from a host
@@ -191,6 +194,37 @@ func (s Session) Release(id string) {
 	s.Rt.Inv.Release(s.ID, id)
 }
 
+// AddHost adds a new host to the inventory. The new host is included
+// in AllHosts, and can be used immediately. Returns an error if the
+// host description is not valid
+func (s Session) AddHost(host NewHost) (*pb.HostInfo, error) {
+	return s.Rt.Inv.AddHost(s.ID, host)
+}
+
+// RemoveHost removes a host from all inventories
+func (s Session) RemoveHost(hostID string) error {
+	return s.Rt.Inv.RemoveHost(s.ID, hostID)
+}
+
+// SetLabels replaces the labels of a host
+func (s Session) SetLabels(hostID string, labels ...string) error {
+	_, err := s.Rt.Inv.SetLabels(s.ID, hostID, labels)
+	return err
+}
+
+// SetProperties adds the given properties to the host properties,
+// overwriting existing values
+func (s Session) SetProperties(hostID string, properties map[string]string) error {
+	_, err := s.Rt.Inv.SetProperties(s.ID, hostID, properties, false)
+	return err
+}
+
+// ReplaceProperties replaces all the properties of a host
+func (s Session) ReplaceProperties(hostID string, properties map[string]string) error {
+	_, err := s.Rt.Inv.SetProperties(s.ID, hostID, properties, true)
+	return err
+}
+
 // Command executes a command on a host
 func (s Session) Command(hostID string, cmd string) CmdResponse {
 	s.Logf(hostID, "Command  %s", cmd)
@@ -347,8 +381,36 @@ func (s *Session) CopyFromLocalIfDifferent(fromPath string, to string, toPath st
 
 // WaitHost waits until host becomes available
 func (s *Session) WaitHost(hostID string, timeout time.Duration) error {
+	return s.WaitHostWith(hostID, timeout, WaitOptions{})
+}
+
+// WaitHostWith waits until host becomes available using the given
+// poll intervals
+func (s *Session) WaitHostWith(hostID string, timeout time.Duration, opts WaitOptions) error {
 	s.Logf(hostID, "wait")
-	return s.Rt.Rmt.WaitHost(s.ID, hostID, timeout)
+	return s.Rt.Rmt.WaitHost(s.ID, hostID, timeout, opts)
+}
+
+// Reboot reboots the host, and waits until it comes back up. Returns
+// error if the host does not come back in time, or if its boot ID did
+// not change
+func (s *Session) Reboot(hostID string, timeout time.Duration) error {
+	return s.RebootWith(hostID, timeout, WaitOptions{})
+}
+
+// RebootWith reboots the host using the given poll intervals to wait
+// for it
+func (s *Session) RebootWith(hostID string, timeout time.Duration, opts WaitOptions) error {
+	s.Logf(hostID, "reboot")
+	r, e := s.Rt.Rmt.Reboot(s.ID, hostID, timeout, opts)
+	if e != nil {
+		return e
+	}
+	if r.Error != nil {
+		return CommandError{Host: r.Error.Host, Msg: r.Error.Msg}
+	}
+	s.Modified = true
+	return nil
 }
 
 // GetFileInfo retrieves file information
@@ -419,6 +481,74 @@ func (s *Session) Ensure(hostID string, path string, req Ensure) bool {
 	return r
 }
 
+// Capabilities returns the capabilities of the host backend. Returns
+// an error if the host cannot be used with its backend
+func (s *Session) Capabilities(hostID string) (Capabilities, error) {
+	c, r, e := s.Rt.Rmt.GetCapabilities(s.ID, hostID)
+	if e != nil {
+		panic(e)
+	}
+	if r != nil {
+		return c, CommandError{Host: r.Host, Msg: r.Msg}
+	}
+	return c, nil
+}
+
+// Checksum returns the hex SHA256 checksum of a file on the
+// host. Returns empty string if the file does not exist, and an
+// error if the backend cannot compute checksums
+func (s *Session) Checksum(hostID string, path string) (string, error) {
+	s.Logf(hostID, "checksum %s", path)
+	sum, r, e := s.Rt.Rmt.Checksum(s.ID, hostID, path)
+	if e != nil {
+		panic(e)
+	}
+	if r != nil {
+		return "", CommandError{Host: r.Host, Msg: r.Msg}
+	}
+	return sum, nil
+}
+
+// DeclareHandler declares a handler. Handlers are called once for
+// each host they are notified for, in declaration order.
+func (s *Session) DeclareHandler(h Handler) {
+	e := s.Rt.DeclareHandler(s.ID, h)
+	if e != nil {
+		panic(e)
+	}
+}
+
+// Notify queues the handler for the host
+func (s *Session) Notify(hostID string, handler string) {
+	s.Logf(hostID, "notify %s", handler)
+	e := s.Rt.Notify(s.ID, s.CallID, hostID, handler)
+	if e != nil {
+		panic(e)
+	}
+}
+
+// FlushHandlers runs the handlers queued for the given hosts now. If
+// no hosts are given, runs all the queued handlers
+func (s *Session) FlushHandlers(hosts ...string) *pb.Response {
+	r, e := s.Rt.FlushHandlers(s.ID, hosts)
+	if e != nil {
+		panic(e)
+	}
+	if r.Modified {
+		s.Modified = true
+	}
+	return r
+}
+
+// AddSecrets marks runtime values, such as generated passwords or
+// tokens, as secrets. watermelon masks them in logs and outputs.
+// Values shorter than 4 characters are not masked
+func (s *Session) AddSecrets(values ...string) {
+	if e := s.Rt.AddSecrets(s.ID, values); e != nil {
+		panic(e)
+	}
+}
+
 // Host returns a host object tied to this session
 func (s *Session) Host(h string) Host {
 	return Host{S: s, ID: h}
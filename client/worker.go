@@ -37,6 +37,7 @@ func (w *WorkServer) Process(ctx context.Context, req *pb.Request) (returnRespon
 
 	w.rt.Printf(req.Session, "Running process for %s", req.FuncName)
 	sess := w.rt.Session(req.Session)
+	sess.CallID = req.CallId
 	// Check if this is one of the declared functions in the module
 	if f, ok := w.Functions[req.FuncName]; ok {
 		rsp, err := f(sess, req.Data)
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// callArgs are the flags that build the input of the function called
+// by run
+type callArgs struct {
+	args     []string
+	jsonArgs []string
+	file     string
+	json   string
+	output string
+}
+
+func (c *callArgs) addFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&c.args, "arg", nil, "Function argument, given as key=value. The key is a field name or a JSON pointer, and the value is a string. You can specify this flag multiple times")
+	flags.StringArrayVar(&c.jsonArgs, "arg-json", nil, "Function argument, given as key=value. The key is as in --arg, and the value is parsed as JSON. You can specify this flag multiple times")
+	flags.StringVar(&c.file, "args-file", "", "JSON or YAML file containing the function arguments")
+	flags.StringVar(&c.json, "args-json", "", "Function arguments as a JSON object")
+	flags.StringVarP(&c.output, "output", "o", "text", "Output format: text, or json to print the output of the function as JSON")
+}
+
+// check returns an error if the output format is unknown
+func (c *callArgs) check() error {
+	if c.output != "text" && c.output != "json" {
+		return fmt.Errorf("Unknown output format: %s", c.output)
+	}
+	return nil
+}
+
+// data returns the JSON input of the function, or nil if there are no
+// arguments. The arguments in the file, the JSON arguments, the
+// key=value JSON arguments, and the key=value string arguments are
+// merged, the latter having precedence
+func (c *callArgs) data() ([]byte, error) {
+	// Layers ordered from the highest precedence to the lowest
+	layers := make([]interface{}, 0)
+	for i := len(c.args) - 1; i >= 0; i-- {
+		key, value, err := splitArg(c.args[i])
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, server.SetCfg(key, value))
+	}
+	for i := len(c.jsonArgs) - 1; i >= 0; i-- {
+		key, str, err := splitArg(c.jsonArgs[i])
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(str), &value); err != nil {
+			return nil, fmt.Errorf("--arg-json %s: %v", c.jsonArgs[i], err)
+		}
+		layers = append(layers, server.SetCfg(key, value))
+	}
+	if len(c.json) > 0 {
+		var value interface{}
+		if err := json.Unmarshal([]byte(c.json), &value); err != nil {
+			return nil, fmt.Errorf("--args-json: %v", err)
+		}
+		layers = append(layers, value)
+	}
+	if len(c.file) > 0 {
+		value, err := readArgsFile(c.file)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, value)
+	}
+	if len(layers) == 0 {
+		return nil, nil
+	}
+	for _, l := range layers {
+		if _, ok := l.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("Function arguments must be an object: %v", l)
+		}
+	}
+	return json.Marshal(server.ResolveCfg("", layers...))
+}
+
+// splitArg returns the JSON pointer and the value of a key=value
+// argument. A key that is not a JSON pointer is a field name
+func splitArg(arg string) (string, string, error) {
+	eq := strings.Index(arg, "=")
+	if eq <= 0 {
+		return "", "", fmt.Errorf("Invalid argument, expecting key=value: %s", arg)
+	}
+	key := arg[:eq]
+	if !strings.HasPrefix(key, "/") {
+		key = "/" + strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+	}
+	return key, arg[eq+1:], nil
+}
+
+func readArgsFile(file string) (interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	switch strings.ToUpper(filepath.Ext(file)) {
+	case ".JSON":
+		err = json.Unmarshal(data, &value)
+	case ".YML", ".YAML":
+		err = yaml.Unmarshal(data, &value)
+		value = server.MapYaml(value)
+	default:
+		return nil, fmt.Errorf("Unrecognized extension: %s", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return value, nil
+}
+
+// printOutput prints the output of the function if the output format
+// is json
+func (c *callArgs) printOutput(data []byte) error {
+	if c.output != "json" {
+		return nil
+	}
+	if len(data) == 0 {
+		fmt.Println("null")
+		return nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return fmt.Errorf("Function output is not JSON: %v", err)
+	}
+	fmt.Println(out.String())
+	return nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// configArgs are the flags that build the configuration layers
+type configArgs struct {
+	files  []string
+	labels []string
+	sets   []string
+}
+
+func (c *configArgs) addFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&c.files, "cfg", nil, "Configuration file. You can specify this flag multiple times, later files have precedence")
+	flags.StringArrayVar(&c.labels, "label-cfg", nil, "Configuration file for the hosts with a label, given as label=file. You can specify this flag multiple times")
+	flags.StringArrayVar(&c.sets, "set", nil, "Override a configuration value, given as /path=value. The value is parsed as YAML. You can specify this flag multiple times")
+}
+
+// labelFiles returns the label and the file of each --label-cfg
+func (c *configArgs) labelFiles() ([][2]string, error) {
+	ret := make([][2]string, 0, len(c.labels))
+	for _, l := range c.labels {
+		eq := strings.Index(l, "=")
+		if eq <= 0 || eq == len(l)-1 {
+			return nil, fmt.Errorf("Invalid label configuration, expecting label=file: %s", l)
+		}
+		ret = append(ret, [2]string{l[:eq], l[eq+1:]})
+	}
+	return ret, nil
+}
+
+// overrides returns the override layers of --set
+func (c *configArgs) overrides() ([]server.ConfigLayer, error) {
+	ret := make([]server.ConfigLayer, 0, len(c.sets))
+	for _, s := range c.sets {
+		eq := strings.Index(s, "=")
+		if eq < 0 || !strings.HasPrefix(s, "/") {
+			return nil, fmt.Errorf("Invalid configuration override, expecting /path=value: %s", s)
+		}
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(s[eq+1:]), &value); err != nil {
+			return nil, fmt.Errorf("%s: %v", s, err)
+		}
+		ret = append(ret, server.ConfigLayer{Name: "--set " + s[:eq],
+			Override: true,
+			Config:   server.SetCfg(s[:eq], server.MapYaml(value))})
+	}
+	return ret, nil
+}
+
+// layers returns the configuration layers ordered from the lowest
+// precedence to the highest. These are the configuration files, the
+// inventory configuration, the label configuration files, and the
+// overrides
+func (c *configArgs) layers(inventoryConfig map[string]interface{}) ([]server.ConfigLayer, error) {
+	ret := make([]server.ConfigLayer, 0)
+	for _, f := range c.files {
+		cfg, err := readConfig(f)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, server.ConfigLayer{Name: "file " + f, Config: cfg})
+	}
+	if inventoryConfig != nil {
+		ret = append(ret, server.ConfigLayer{Name: "inventory", Config: inventoryConfig})
+	}
+	labels, err := c.labelFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		cfg, err := readConfig(l[1])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, server.ConfigLayer{Name: fmt.Sprintf("label %s (%s)", l[0], l[1]), Label: l[0], Config: cfg})
+	}
+	overrides, err := c.overrides()
+	if err != nil {
+		return nil, err
+	}
+	return append(ret, overrides...), nil
+}
+
+var configExplainArgs = struct {
+	configArgs
+	host string
+}{}
+
+func init() {
+	configExplainArgs.addFlags(configCmd.PersistentFlags())
+	configExplainCmd.Flags().StringVar(&configExplainArgs.host, "host", "", "Explain the configuration seen by this host")
+	configCmd.AddCommand(configExplainCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configCmd is the parent of the configuration inspection commands
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long:  `Inspect the configuration built from the configuration files, the inventory, and the overrides.`}
+
+var configExplainCmd = &cobra.Command{
+	Use:   "explain <path>",
+	Short: "Show which configuration layer supplies a value",
+	Long:  `Show the effective value at a JSON pointer path, and the value in each configuration layer that has it, from the highest precedence to the lowest. valueFrom references are not expanded. Secrets are masked.`,
+	Args:  cobra.ExactArgs(1),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		session, _, err := newSession(configExplainArgs.configArgs)
+		if err != nil {
+			return err
+		}
+		value, sources, err := session.ExplainCfg(configExplainArgs.host, args[0])
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		fmt.Printf("%s: %s\n", args[0], cfgString(value))
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, s := range sources {
+			fmt.Fprintf(w, "  %s\t%s", s.Layer, cfgString(s.Value))
+			if s.Overridden {
+				fmt.Fprint(w, "\toverridden")
+			}
+			fmt.Fprintln(w)
+		}
+		return w.Flush()
+	}}
+
+// cfgString returns the JSON representation of v with the secrets
+// masked
+func cfgString(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return server.Redact(fmt.Sprint(v))
+	}
+	return server.Redact(string(data))
+}
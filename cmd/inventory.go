@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/inventory"
+)
+
+var inventoryArgs = struct {
+	configArgs
+	output string
+}{}
+
+func init() {
+	inventoryArgs.addFlags(inventoryCmd.PersistentFlags())
+	inventoryListCmd.Flags().StringVarP(&inventoryArgs.output, "output", "o", "table", "Output format: table or json")
+	inventoryCmd.AddCommand(inventoryListCmd)
+	inventoryCmd.AddCommand(inventoryShowCmd)
+	inventoryCmd.AddCommand(inventoryGraphCmd)
+	rootCmd.AddCommand(inventoryCmd)
+}
+
+// inventoryCmd is the parent of the inventory inspection commands
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Inspect the inventory",
+	Long:  `Inspect the inventory. Use list, show, and graph to see how the hosts are selected, configured, and connected.`}
+
+var inventoryListCmd = &cobra.Command{
+	Use: "list [selector]",
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Short:         "List hosts matching a selector expression",
+	Long:          `List hosts matching a selector expression, e.g. "web && dc=east". If there is no selector, all hosts are listed.`,
+	Args:          cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, inv, err := newSession(inventoryArgs.configArgs)
+		if err != nil {
+			return err
+		}
+		expr := ""
+		if len(args) > 0 {
+			expr = args[0]
+		}
+		hosts, err := selectHosts(inv, expr)
+		if err != nil {
+			return err
+		}
+		switch inventoryArgs.output {
+		case "json":
+			return printHostsJSON(hosts)
+		case "table":
+			printHostsTable(hosts)
+			return nil
+		}
+		return fmt.Errorf("Unknown output format: %s", inventoryArgs.output)
+	}}
+
+var inventoryShowCmd = &cobra.Command{
+	Use: "show <host>",
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	Short:         "Show the resolved information of a host",
+	Long:          `Show the addresses, labels, properties, effective configuration, and connection parameters of a host. Secrets are masked.`,
+	Args:          cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		session, inv, err := newSession(inventoryArgs.configArgs)
+		if err != nil {
+			return err
+		}
+		hosts, err := inv.GetHost(args)
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("Host not found: %s", args[0])
+		}
+		return showHost(session, hosts[0])
+	}}
+
+var inventoryGraphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show group and label membership, and bastion chains",
+	Long:  `Show group and label membership, and bastion chains`,
+	Args:  cobra.NoArgs,
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, inv, err := newSession(inventoryArgs.configArgs)
+		if err != nil {
+			return err
+		}
+		hosts, err := selectHosts(inv, "")
+		if err != nil {
+			return err
+		}
+		printGraph(inv.Cfg.Groups, hosts)
+		return nil
+	}}
+
+// selectHosts returns the hosts matching the selector expression. If
+// expr is empty, returns all hosts
+func selectHosts(inv *inventory.InvServer, expr string) ([]*server.Host, error) {
+	id := server.AllHosts
+	if len(expr) > 0 {
+		sel, err := inventory.ParseSelector(expr)
+		if err != nil {
+			return nil, err
+		}
+		if id, err = inv.Select(server.AllHosts, sel); err != nil {
+			return nil, err
+		}
+	}
+	ids, err := inv.GetHostIDs(id)
+	if err != nil {
+		return nil, err
+	}
+	return inv.GetHost(ids)
+}
+
+func primaryAddress(host *server.Host) string {
+	for _, a := range host.Addresses {
+		if a.Name == server.Primary {
+			return a.Address
+		}
+	}
+	if len(host.Addresses) > 0 {
+		return host.Addresses[0].Address
+	}
+	return ""
+}
+
+func printHostsTable(hosts []*server.Host) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tADDRESS\tBACKEND\tLABELS")
+	for _, h := range hosts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", h.ID, primaryAddress(h), h.BackendName, strings.Join(h.Labels, ","))
+	}
+	w.Flush()
+}
+
+type hostSummary struct {
+	ID         string            `json:"id"`
+	Addresses  map[string]string `json:"addresses,omitempty"`
+	Labels     []string          `json:"labels,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+	Backend    string            `json:"backend"`
+}
+
+func printHostsJSON(hosts []*server.Host) error {
+	out := make([]hostSummary, 0, len(hosts))
+	for _, h := range hosts {
+		s := hostSummary{ID: h.ID, Labels: h.Labels, Properties: h.Properties, Backend: h.BackendName}
+		if len(h.Addresses) > 0 {
+			s.Addresses = make(map[string]string, len(h.Addresses))
+			for _, a := range h.Addresses {
+				s.Addresses[a.Name] = a.Address
+			}
+		}
+		out = append(out, s)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// bastionChain returns the bastion hosts used to reach host, starting
+// from the closest one
+func bastionChain(host *server.Host) []string {
+	ret := make([]string, 0)
+	for b := host.Bastion; b != nil; b = b.Bastion {
+		ret = append(ret, b.ID)
+	}
+	return ret
+}
+
+func mask(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return "********"
+}
+
+func showHost(session server.Session, host *server.Host) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", host.ID)
+	fmt.Fprintf(w, "Backend:\t%s\n", host.BackendName)
+	if len(host.Wrapper) > 0 {
+		fmt.Fprintf(w, "Wrapper:\t%s\n", host.Wrapper)
+	}
+	fmt.Fprintf(w, "Labels:\t%s\n", strings.Join(host.Labels, ", "))
+	if len(host.Groups) > 0 {
+		fmt.Fprintf(w, "Groups:\t%s\n", strings.Join(host.Groups, ", "))
+	}
+	fmt.Fprintln(w, "Addresses:")
+	for _, a := range host.Addresses {
+		fmt.Fprintf(w, "  %s\t%s\n", a.Name, a.Address)
+	}
+	fmt.Fprintln(w, "Properties:")
+	keys := make([]string, 0, len(host.Properties))
+	for k := range host.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s\t%s\n", k, host.Properties[k])
+	}
+	fmt.Fprintln(w, "Connection:")
+	if len(host.Hostname) > 0 {
+		fmt.Fprintf(w, "  address\t%s/%s\n", host.GetNetwork(), host.GetHostAndPort())
+	}
+	fmt.Fprintf(w, "  user\t%s\n", host.LoginUser)
+	fmt.Fprintf(w, "  password\t%s\n", mask(host.LoginPassword))
+	if host.KeyAuth != nil {
+		fmt.Fprintf(w, "  private key\t%s\n", mask("key"))
+	}
+	fmt.Fprintf(w, "  become\t%s\n", host.Become)
+	if chain := bastionChain(host); len(chain) > 0 {
+		fmt.Fprintf(w, "  bastion\t%s\n", strings.Join(chain, " -> "))
+	}
+	w.Flush()
+
+	effective, err := session.GetCfg(host.ID, "")
+	if err != nil {
+		return err
+	}
+	if effective == nil {
+		return nil
+	}
+	cfg, err := yaml.Marshal(effective)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Configuration:")
+	for _, line := range strings.Split(strings.TrimRight(server.Redact(string(cfg)), "\n"), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
+
+// printGraph prints the group hierarchy with the hosts of each group,
+// the labels that are not groups, and the bastion chains
+func printGraph(groups map[string][]string, hosts []*server.Host) {
+	members := map[string][]string{}
+	for _, h := range hosts {
+		for _, l := range h.Labels {
+			members[l] = append(members[l], h.ID)
+		}
+	}
+	if len(groups) > 0 {
+		isChild := map[string]bool{}
+		for _, children := range groups {
+			for _, c := range children {
+				isChild[c] = true
+			}
+		}
+		var printGroup func(name string, indent string)
+		printGroup = func(name string, indent string) {
+			fmt.Printf("%s%s: %s\n", indent, name, strings.Join(members[name], " "))
+			children := append([]string{}, groups[name]...)
+			sort.Strings(children)
+			for _, c := range children {
+				printGroup(c, indent+"  ")
+			}
+		}
+		fmt.Println("Groups:")
+		for _, name := range sortedLabels(groups) {
+			if !isChild[name] {
+				printGroup(name, "  ")
+			}
+		}
+	}
+	labels := make([]string, 0)
+	for l := range members {
+		if _, ok := groups[l]; !ok {
+			labels = append(labels, l)
+		}
+	}
+	if len(labels) > 0 {
+		sort.Strings(labels)
+		fmt.Println("Labels:")
+		for _, l := range labels {
+			fmt.Printf("  %s: %s\n", l, strings.Join(members[l], " "))
+		}
+	}
+	first := true
+	for _, h := range hosts {
+		chain := bastionChain(h)
+		if len(chain) == 0 {
+			continue
+		}
+		if first {
+			fmt.Println("Bastions:")
+			first = false
+		}
+		fmt.Printf("  %s -> %s\n", h.ID, strings.Join(chain, " -> "))
+	}
+}
+
+func sortedLabels(m map[string][]string) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -1,34 +1,215 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
 
 	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/module"
+	"github.com/bserdar/watermelon/server/pb"
 )
 
+// moduleArgs are the flags to find, build, and connect to the modules
+type moduleArgs struct {
+	mdir        []string
+	grpc        string
+	build       bool
+	cache       string
+	maxRestarts int
+}
+
+// addFlags adds --mdir to dirFlags, and the other flags to flags
+func (m *moduleArgs) addFlags(dirFlags, flags *pflag.FlagSet) {
+	dirFlags.StringSliceVar(&m.mdir, "mdir", []string{"."}, "Directory containing modules. You can specify this flag multiple times for each directory. This is combined with WM_MODULES env var.")
+	flags.StringVar(&m.grpc, "listen", "localhost:9876", "GRPC port to listen")
+	flags.BoolVar(&m.build, "go-build", false, "Build Go modules using go build, and run the binaries without module.w")
+	flags.StringVar(&m.cache, "build-cache", module.DefaultBuildCacheDir(), "Directory where Go modules are built and cached with --go-build")
+	flags.IntVar(&m.maxRestarts, "max-restarts", module.DefaultMaxRestarts, "Number of times a module that died is restarted within 10 minutes")
+}
+
+// startModules starts the GRPC server the modules connect to, and
+// returns the module manager. register registers the services other
+// than the lifecycle service
+func startModules(args moduleArgs, logLevel string, register func(*grpc.Server)) (*module.LifecycleManager, net.Listener, error) {
+	moduleDirs := strings.Split(os.Getenv("WM_MODULES"), fmt.Sprint(os.PathListSeparator))
+
+	grpcServer := grpc.NewServer()
+	grpcListener, err := net.Listen("tcp", args.grpc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lmgr := module.NewLifecycleManager()
+	lmgr.RunModuleScript = func(first bool, dir string) error {
+		t := "run"
+		if first {
+			t = "buildrun"
+		}
+		log.Debugf("Calling %s first: %v", dir, first)
+		return lmgr.ExecModule("/bin/sh", "-c", fmt.Sprintf("cd %s;/bin/sh ./module.w %s %s --log %s", dir, t, args.grpc, logLevel))
+	}
+	if args.build && len(args.cache) > 0 {
+		lmgr.BuildCache = module.NewBuildCache(args.cache)
+		lmgr.RunModuleBinary = func(bin, dir string) error {
+			return lmgr.ExecModuleIn(dir, bin, args.grpc, "--log", logLevel)
+		}
+	}
+	lmgr.MaxRestarts = args.maxRestarts
+	lmgr.LocalModuleFunc = server.CallLocalModule
+	lmgr.ModuleLookupDirs = append(args.mdir, moduleDirs...)
+	log.Debugf("Module search dirs: %v", lmgr.ModuleLookupDirs)
+	pb.RegisterLifecycleServer(grpcServer, lmgr)
+	if register != nil {
+		register(grpcServer)
+	}
+
+	go func() {
+		log.Debugf("Listening at %s", args.grpc)
+		grpcServer.Serve(grpcListener)
+		log.Debugf("Closed server at %s", args.grpc)
+	}()
+	// Give the server a chance to start
+	time.Sleep(time.Millisecond * 100)
+	lmgr.Supervise(module.DefaultPingInterval)
+	return lmgr, grpcListener, nil
+}
+
+var describeArgs = struct {
+	moduleArgs
+}{}
+
 func init() {
+	describeArgs.addFlags(modulesCmd.Flags(), modulesCmd.Flags())
 	rootCmd.AddCommand(modulesCmd)
 }
 
 // modulesCmd returns information about modules
 var modulesCmd = &cobra.Command{
-	Use:   "describe",
+	Use:   "describe [module [function]]",
 	Short: "List available modules, or get module description.",
-	Long:  `List available modules, or get module description.`,
-	Args:  cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			for name, mod := range server.LocalModules {
-				fmt.Printf("%s\t%s\n", name, mod.Describe())
-			}
-		} else {
-			mod, ok := server.LocalModules[args[0]]
-			if !ok {
-				fmt.Printf("Cannot find module %s\n", args[0])
-				return
+	Long: `List available modules and their functions, or get module description.
+
+The modules under the module directories are started to get their
+exported functions. The description of a function contains the JSON
+schemas of its argument and its return value.`,
+	Args: cobra.MaximumNArgs(2),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			if mod, ok := server.LocalModules[args[0]]; ok {
+				fmt.Print(mod.Help())
+				return nil
 			}
-			fmt.Printf(mod.Help())
 		}
+		logLevel := "info"
+		if verbose {
+			log.SetLevel(log.DebugLevel)
+			logLevel = "debug"
+		}
+		lmgr, listener, err := startModules(describeArgs.moduleArgs, logLevel, nil)
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+		defer lmgr.Close()
+
+		if len(args) == 0 {
+			listModules(lmgr)
+			return nil
+		}
+		if _, ok := lmgr.SearchModuleDir(args[0]); !ok {
+			return fmt.Errorf("Cannot find module %s", args[0])
+		}
+		desc, err := lmgr.Describe(args[0])
+		if err != nil {
+			return err
+		}
+		function := ""
+		if len(args) > 1 {
+			function = args[1]
+		}
+		return describeModule(args[0], desc, function)
 	}}
+
+// listModules prints the local modules, and the external modules with
+// their functions
+func listModules(lmgr *module.LifecycleManager) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	names := make([]string, 0, len(server.LocalModules))
+	for name := range server.LocalModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, server.LocalModules[name].Describe())
+	}
+	for _, name := range lmgr.Modules() {
+		desc, err := lmgr.Describe(name)
+		if err != nil {
+			fmt.Fprintf(w, "%s\tCannot describe: %s\n", name, firstLine(err.Error()))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, firstLine(desc.Doc))
+		for _, f := range desc.Functions {
+			fmt.Fprintf(w, "  %s\t%s\n", f.Name, firstLine(f.Doc))
+		}
+	}
+	w.Flush()
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
+
+// describeModule prints the description of a module, or of one of
+// its functions if function is nonempty
+func describeModule(name string, desc *pb.ModuleDescription, function string) error {
+	if len(function) == 0 {
+		fmt.Println(name)
+		if len(desc.Doc) > 0 {
+			fmt.Printf("\n%s\n", desc.Doc)
+		}
+	}
+	found := false
+	for _, f := range desc.Functions {
+		if len(function) > 0 && f.Name != function {
+			continue
+		}
+		found = true
+		fmt.Printf("\n%s\n", f.Name)
+		if len(f.Doc) > 0 {
+			fmt.Printf("  %s\n", strings.Replace(f.Doc, "\n", "\n  ", -1))
+		}
+		printSchema("Arguments", f.Args)
+		printSchema("Returns", f.Returns)
+	}
+	if len(function) > 0 && !found {
+		return fmt.Errorf("Cannot find function %s in %s", function, name)
+	}
+	return nil
+}
+
+func printSchema(title string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "    ", "  "); err != nil {
+		return
+	}
+	fmt.Printf("  %s:\n    %s\n", title, out.String())
+}
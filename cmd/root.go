@@ -3,21 +3,44 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/bserdar/watermelon/server"
 	"github.com/bserdar/watermelon/server/inventory"
-	"github.com/bserdar/watermelon/server/inventory/yml"
+	"github.com/bserdar/watermelon/server/inventory/script"
+	_ "github.com/bserdar/watermelon/server/inventory/yml"
 )
 
 var verbose bool
 var inventoryFile string
+var limit string
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose")
-	rootCmd.PersistentFlags().StringVarP(&inventoryFile, "inv", "i", "", "Inventory file (YAML)")
+	rootCmd.PersistentFlags().StringVarP(&inventoryFile, "inv", "i", "", "Inventory file (YAML), or exec:executable printing the inventory as JSON. Use loader:location to select the inventory loader")
+	rootCmd.PersistentFlags().StringVar(&limit, "limit", "", "Limit the inventory to hosts matching the selector expression, e.g. \"web && !maintenance\"")
+	rootCmd.PersistentFlags().DurationVar(&script.CacheTTL, "inv-cache-ttl", script.CacheTTL, "How long the output of an inventory executable is cached. 0 disables caching")
+	// Mask secrets in all log messages
+	log.SetFormatter(server.RedactFormatter{Formatter: log.StandardLogger().Formatter})
+}
+
+// inventoryLoader returns the name of the loader and the location for
+// the inventory argument. The argument is either loader:location, or
+// a YAML file. Executables are run only if they are given as
+// exec:location
+func inventoryLoader(inv string) (string, string) {
+	if i := strings.Index(inv, ":"); i != -1 {
+		if server.GetInventoryLoader(inv[:i]) != nil {
+			return inv[:i], inv[i+1:]
+		}
+	}
+	if script.IsExecutable(inv) {
+		log.Warnf("Loading %s as a YAML inventory. Use exec:%s to run it", inv, inv)
+	}
+	return "yaml", inv
 }
 
 func loadInventory(session server.Session) (*inventory.InvServer, map[string]interface{}) {
@@ -26,11 +49,19 @@ func loadInventory(session server.Session) (*inventory.InvServer, map[string]int
 	}
 	log.Debugf("Loading inventory %s", inventoryFile)
 
-	cfg, hosts, config, err := yml.LoadInventory(inventoryFile)
+	name, location := inventoryLoader(inventoryFile)
+	cfg, hosts, config, err := server.GetInventoryLoader(name)(location)
 	if err != nil {
 		panic(err)
 	}
+	if len(limit) > 0 {
+		hosts, err = inventory.Limit(hosts, limit)
+		if err != nil {
+			panic(err)
+		}
+	}
 	log.Debugf("There are %d hosts", len(hosts))
+	server.Localhost.Become = cfg.LocalhostBecome
 	return inventory.NewInvServer(hosts, cfg, session), config
 }
 
@@ -43,7 +74,7 @@ var rootCmd = &cobra.Command{
 // Execute the root cmd
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Println(server.Redact(err.Error()))
 		os.Exit(1)
 	}
 }
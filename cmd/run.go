@@ -4,40 +4,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"google.golang.org/grpc"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	yaml "gopkg.in/yaml.v2"
+	yaml "gopkg.in/yaml.v3"
 
 	"github.com/bserdar/watermelon/server"
 	"github.com/bserdar/watermelon/server/inventory"
 	"github.com/bserdar/watermelon/server/logging"
-	"github.com/bserdar/watermelon/server/module"
 	"github.com/bserdar/watermelon/server/pb"
 	"github.com/bserdar/watermelon/server/remote"
+	"github.com/bserdar/watermelon/server/session"
+	"github.com/bserdar/watermelon/server/vault"
 )
 
 var runArgs = struct {
-	mdir   []string
-	grpc   string
 	logdir string
 	stdout bool
-	config string
+	forks  int
+	configArgs
+	moduleArgs
+	callArgs
 }{}
 
 func init() {
-	runCmd.PersistentFlags().StringSliceVar(&runArgs.mdir, "mdir", []string{"."}, "Directory containing modules. You can specify this flag multiple times for each directory. This is combined with WM_MODULES env var.")
-	runCmd.Flags().StringVar(&runArgs.grpc, "listen", "localhost:9876", "GRPC port to listen")
+	runArgs.moduleArgs.addFlags(runCmd.PersistentFlags(), runCmd.Flags())
 	runCmd.Flags().StringVar(&runArgs.logdir, "log", "./log", "Log directory")
 	runCmd.Flags().BoolVar(&runArgs.stdout, "stdout", false, "Log to stdout as well")
-	runCmd.Flags().StringVar(&runArgs.config, "cfg", "", "Configuration file.")
+	runArgs.configArgs.addFlags(runCmd.Flags())
+	runArgs.callArgs.addFlags(runCmd.Flags())
+	runCmd.Flags().IntVar(&runArgs.forks, "forks", 0, "Maximum number of hosts operated on at the same time by all modules. 0 means no limit")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -45,11 +46,23 @@ func init() {
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run a function in a package",
-	Long:  `Run a function in a package. Pass the package name, function name, and any additional args`,
-	Args:  cobra.MinimumNArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-
-		moduleDirs := strings.Split(os.Getenv("WM_MODULES"), fmt.Sprint(os.PathListSeparator))
+	Long: `Run a function in a package. Pass the package name, function name, and any additional args.
+
+The input of the function is built from --args-file, --args-json, and
+--arg, and decoded into the input struct of the function. Use --output
+json to print the output of the function as JSON.`,
+	Args: cobra.MinimumNArgs(2),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := runArgs.callArgs.check(); err != nil {
+			return err
+		}
+		data, err := runArgs.callArgs.data()
+		if err != nil {
+			return err
+		}
 
 		logLevel := "info"
 		if verbose {
@@ -60,80 +73,90 @@ var runCmd = &cobra.Command{
 		}
 		log.Debug("Initializing...")
 
-		var cfg interface{}
-		var err error
-		if len(runArgs.config) > 0 {
-			cfg, err = readConfig(runArgs.config)
-			if err != nil {
-				panic(err)
-			}
+		server.SetForks(runArgs.forks)
+		session, _, err := newSession(runArgs.configArgs)
+		if err != nil {
+			return err
 		}
-
-		session := server.NewSession()
 		session.SetLogStdout(runArgs.stdout)
 		defer session.Close()
-		session.SetConfig(cfg)
-
-		inv, config := loadInventory(session)
-		session.SetInv(inv)
-		if config != nil {
-			session.SetConfig(mergeConfig(session.GetConfig(), config))
-		}
 		logdir := logging.GetLogDir(runArgs.logdir, args[0])
 		session.SetLog(logging.Logging{Logdir: logdir})
-		fmt.Printf("Logs are under %s\n", logdir)
+		// With JSON output, stdout contains only the function output
+		messages := os.Stdout
+		if runArgs.output == "json" {
+			messages = os.Stderr
+		}
+		fmt.Fprintf(messages, "Logs are under %s\n", logdir)
 		os.MkdirAll(logdir, 0775)
 
-		grpcServer := grpc.NewServer()
-		grpcListener, err := net.Listen("tcp", runArgs.grpc)
+		lmgr, listener, err := startModules(runArgs.moduleArgs, logLevel, func(grpcServer *grpc.Server) {
+			pb.RegisterInventoryServer(grpcServer, inventory.NewServer())
+			pb.RegisterRemoteServer(grpcServer, remote.New())
+		})
 		if err != nil {
-			panic(err)
+			return err
 		}
-		defer grpcListener.Close()
-
-		// Setup the session
-		lmgr := module.NewLifecycleManager()
-		lmgr.RunModuleScript = func(first bool, dir string) error {
-			t := "run"
-			if first {
-				t = "buildrun"
-			}
-			log.Debugf("Calling %s first: %v", dir, first)
-			return lmgr.ExecModule("/bin/sh", "-c", fmt.Sprintf("cd %s;/bin/sh ./module.w %s %s --log %s", dir, t, runArgs.grpc, logLevel))
-		}
-		lmgr.LocalModuleFunc = server.CallLocalModule
+		defer listener.Close()
+		lmgr.Output = messages
 		session.SetModules(lmgr)
-		lmgr.ModuleLookupDirs = append(runArgs.mdir, moduleDirs...)
-		log.Debugf("Module search dirs: %v", lmgr.ModuleLookupDirs)
-		pb.RegisterLifecycleServer(grpcServer, lmgr)
-		pb.RegisterInventoryServer(grpcServer, inventory.NewServer())
-		pb.RegisterRemoteServer(grpcServer, remote.New())
-
-		go func() {
-			log.Debugf("Listening at %s", runArgs.grpc)
-			grpcServer.Serve(grpcListener)
-			log.Debugf("Closed server at %s", runArgs.grpc)
-		}()
-		// Give the server a chance to start
-		time.Sleep(time.Millisecond * 100)
 
 		// Run the module, with optional args
 		session.SetArgs(args[2:])
 		log.Debugf("Calling %s.%s", args[0], args[1])
-		_, err = session.GetModules().SendRequest(session.GetID(), args[0], args[1], nil)
+		result, err := session.GetModules().SendRequest(session.GetID(), args[0], args[1], data)
 		log.Debugf("Result of main: %v", err)
+		// The first error is returned, so the command fails. The
+		// handlers run even if the call fails
+		var runErr error
 		if err != nil {
-			log.Errorf(err.Error())
+			runErr = err
+		} else if !result.Success {
+			runErr = fmt.Errorf("%s.%s: %s", args[0], args[1], result.ErrorMsg)
+		} else {
+			runErr = runArgs.callArgs.printOutput(result.Data)
+		}
+		// Run the handlers queued until the end of the run
+		rsp, err := session.GetHandlers().Flush(session.GetModules(), session.GetID(), nil)
+		if err == nil && !rsp.Success {
+			err = fmt.Errorf("%s", rsp.ErrorMsg)
+		}
+		if err != nil {
+			err = fmt.Errorf("Handlers: %s", err.Error())
+			if runErr == nil {
+				runErr = err
+			} else {
+				log.Errorf(err.Error())
+			}
 		}
 		log.Debugf("Closing session")
 		session.Close()
+		return runErr
 	}}
 
+// newSession returns a new session with the configuration layers and
+// the inventory loaded
+func newSession(config configArgs) (server.Session, *inventory.InvServer, error) {
+	session := server.NewSession()
+	inv, invConfig := loadInventory(session)
+	session.SetInv(inv)
+	layers, err := config.layers(invConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	session.SetConfigLayers(layers)
+	return session, inv, nil
+}
+
 func readConfig(config string) (interface{}, error) {
 	data, err := ioutil.ReadFile(config)
 	if err != nil {
 		return nil, err
 	}
+	encrypted := vault.IsEncrypted(data)
+	if data, err = vault.Decrypt(data); err != nil {
+		return nil, err
+	}
 	var v interface{}
 	ext := strings.ToUpper(filepath.Ext(config))
 	if ext == ".YML" || ext == ".YAML" {
@@ -141,43 +164,18 @@ func readConfig(config string) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
-		return server.MapYaml(v), nil
-	}
-	if ext == ".JSON" {
+		v = server.MapYaml(v)
+	} else if ext == ".JSON" {
 		err := json.Unmarshal(data, &v)
 		if err != nil {
 			return nil, err
 		}
-		return v, nil
+	} else {
+		return nil, fmt.Errorf("Unrecognized extension: %s", ext)
 	}
-	return nil, fmt.Errorf("Unrecognized extension: %s", ext)
-}
-
-// Merge src into dest
-func mergeConfig(dest, src interface{}) interface{} {
-	if dest == nil {
-		return src
-	}
-	if src == nil {
-		return dest
-	}
-	if md, ok := dest.(map[string]interface{}); ok {
-		if ms, ok := src.(map[string]interface{}); ok {
-			for k, v := range ms {
-				if ov, ok := md[k]; ok {
-					md[k] = mergeConfig(ov, v)
-				} else {
-					md[k] = v
-				}
-			}
-		} else {
-			panic(fmt.Sprintf("Incompatible configs: %v - %v", dest, src))
-		}
-		return server.MapYaml(md)
-	}
-
-	if as, ok := src.([]interface{}); ok {
-		return server.MapYaml(as)
+	// All the values of an encrypted file are secrets
+	if encrypted {
+		server.AddSecretValues(v)
 	}
-	return server.MapYaml(src)
+	return vault.DecryptValues(session.ResolvePaths(v, filepath.Dir(config)))
 }
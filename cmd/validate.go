@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/inventory/yml"
+)
+
+var validateArgs = struct {
+	configArgs
+}{}
+
+func init() {
+	validateArgs.addFlags(validateCmd.Flags())
+	rootCmd.AddCommand(validateCmd)
+}
+
+// validateCmd loads the inventory and the configuration, and reports
+// all the problems
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the inventory and the configuration",
+	Long:  `Load the inventory and the configuration files, and report all the problems found with file and line.`,
+	Args:  cobra.NoArgs,
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems := make([]yml.Problem, 0)
+		if len(inventoryFile) > 0 {
+			name, location := inventoryLoader(inventoryFile)
+			if name == "yaml" {
+				data, err := ioutil.ReadFile(location)
+				if err != nil {
+					return err
+				}
+				problems = append(problems, yml.Validate(location, data)...)
+			} else if _, _, _, err := server.GetInventoryLoader(name)(location); err != nil {
+				problems = append(problems, yml.Problem{File: location, Msg: err.Error()})
+			}
+		}
+		files := append([]string{}, validateArgs.files...)
+		labels, err := validateArgs.labelFiles()
+		if err != nil {
+			return err
+		}
+		for _, l := range labels {
+			files = append(files, l[1])
+		}
+		for _, f := range files {
+			data, err := ioutil.ReadFile(f)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, yml.ValidateConfig(f, data)...)
+		}
+		if _, err := validateArgs.overrides(); err != nil {
+			problems = append(problems, yml.Problem{File: "--set", Msg: err.Error()})
+		}
+		for _, p := range problems {
+			fmt.Println(p.Error())
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%d problems found", len(problems))
+		}
+		return nil
+	}}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/spf13/cobra"
+
+	sshdial "github.com/bserdar/watermelon/server/ssh"
+	"github.com/bserdar/watermelon/server/vault"
+)
+
+var vaultArgs = struct {
+	str     bool
+	stdout  bool
+	newFile string
+}{}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&vault.PassphraseFile, "vault-password-file", "", "File containing the vault passphrase. Default is WM_VAULT_PASSWORD_FILE, or ask if that is not set")
+	vaultEncryptCmd.Flags().BoolVar(&vaultArgs.str, "string", false, "Encrypt the argument instead of a file, and print the encrypted value")
+	vaultDecryptCmd.Flags().BoolVar(&vaultArgs.stdout, "stdout", false, "Print the decrypted file instead of replacing it")
+	vaultRekeyCmd.Flags().StringVar(&vaultArgs.newFile, "new-vault-password-file", "", "File containing the new vault passphrase. Asked if not given")
+	vaultCmd.AddCommand(vaultEncryptCmd)
+	vaultCmd.AddCommand(vaultDecryptCmd)
+	vaultCmd.AddCommand(vaultEditCmd)
+	vaultCmd.AddCommand(vaultRekeyCmd)
+	rootCmd.AddCommand(vaultCmd)
+}
+
+// vaultCmd is the parent of the vault commands
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Encrypt and decrypt secrets",
+	Long:  `Encrypt and decrypt files and values used in the inventory and the configuration. Encrypted data is decrypted when it is loaded.`}
+
+var vaultEncryptCmd = &cobra.Command{
+	Use:   "encrypt <file>",
+	Short: "Encrypt a file, or a value with --string",
+	Long:  `Encrypt a file in place. With --string, encrypt the argument and print the encrypted value that can be used in YAML files.`,
+	Args:  cobra.ExactArgs(1),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		v, err := encryptionVault(passphraseFile())
+		if err != nil {
+			return err
+		}
+		if vaultArgs.str {
+			s, err := v.Encrypt([]byte(args[0]))
+			if err != nil {
+				return err
+			}
+			fmt.Println(s)
+			return nil
+		}
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		if vault.IsEncrypted(data) {
+			return fmt.Errorf("%s is already encrypted", args[0])
+		}
+		return writeEncrypted(v, args[0], data)
+	}}
+
+var vaultDecryptCmd = &cobra.Command{
+	Use:   "decrypt <file>",
+	Short: "Decrypt a file",
+	Long:  `Decrypt a file in place, or print it with --stdout`,
+	Args:  cobra.ExactArgs(1),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := readEncrypted(args[0])
+		if err != nil {
+			return err
+		}
+		if vaultArgs.stdout {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return writeFile(args[0], data)
+	}}
+
+var vaultEditCmd = &cobra.Command{
+	Use:   "edit <file>",
+	Short: "Edit an encrypted file",
+	Long:  `Decrypt a file, open it with $EDITOR, and encrypt it again. If the file does not exist, it is created.`,
+	Args:  cobra.ExactArgs(1),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var data []byte
+		var v *vault.Vault
+		var err error
+		if _, err = os.Stat(args[0]); os.IsNotExist(err) {
+			if v, err = encryptionVault(passphraseFile()); err != nil {
+				return err
+			}
+		} else {
+			if data, err = readEncrypted(args[0]); err != nil {
+				return err
+			}
+			if v, err = vault.Default(); err != nil {
+				return err
+			}
+		}
+		tmp, err := ioutil.TempFile("", "wmvault")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(data)
+		tmp.Close()
+		if err != nil {
+			return err
+		}
+		editor := os.Getenv("EDITOR")
+		if len(editor) == 0 {
+			editor = "vi"
+		}
+		// EDITOR may have arguments, as in "code -w"
+		edit := exec.Command("/bin/sh", "-c", editor+` "$1"`, "sh", tmp.Name())
+		edit.Stdin, edit.Stdout, edit.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := edit.Run(); err != nil {
+			return err
+		}
+		edited, err := ioutil.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		if data != nil && bytes.Equal(edited, data) {
+			return nil
+		}
+		return writeEncrypted(v, args[0], edited)
+	}}
+
+var vaultSecret = regexp.MustCompile(regexp.QuoteMeta(vault.Header) + `[A-Za-z0-9+/=]+`)
+
+var vaultRekeyCmd = &cobra.Command{
+	Use:   "rekey <file>...",
+	Short: "Encrypt files using a new passphrase",
+	Long:  `Decrypt encrypted files, and the encrypted values in YAML files, and encrypt them again using a new passphrase`,
+	Args:  cobra.MinimumNArgs(1),
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		old, err := vault.Default()
+		if err != nil {
+			return err
+		}
+		v, err := encryptionVault(vaultArgs.newFile)
+		if err != nil {
+			return err
+		}
+		for _, file := range args {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			encrypted := vault.IsEncrypted(data)
+			if encrypted {
+				if data, err = old.Decrypt(data); err != nil {
+					return fmt.Errorf("%s: %v", file, err)
+				}
+			}
+			if data, err = rekeyValues(old, v, data); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+			if encrypted {
+				err = writeEncrypted(v, file, data)
+			} else {
+				err = writeFile(file, data)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+
+// rekeyValues encrypts the encrypted values in data using the new
+// vault
+func rekeyValues(old, v *vault.Vault, data []byte) ([]byte, error) {
+	var rerr error
+	out := vaultSecret.ReplaceAllFunc(data, func(secret []byte) []byte {
+		plain, err := old.Decrypt(secret)
+		if err == nil {
+			var s string
+			s, err = v.Encrypt(plain)
+			secret = []byte(s)
+		}
+		if err != nil && rerr == nil {
+			rerr = err
+		}
+		return secret
+	})
+	return out, rerr
+}
+
+func passphraseFile() string {
+	if len(vault.PassphraseFile) > 0 {
+		return vault.PassphraseFile
+	}
+	return os.Getenv("WM_VAULT_PASSWORD_FILE")
+}
+
+// encryptionVault returns a vault to encrypt data. The passphrase is
+// read from file, or asked twice if file is empty
+func encryptionVault(file string) (*vault.Vault, error) {
+	if len(file) > 0 {
+		p, err := vault.ReadPassphrase(file)
+		if err != nil {
+			return nil, err
+		}
+		if len(p) == 0 {
+			return nil, vault.ErrNoPassphrase
+		}
+		return vault.New(p), nil
+	}
+	p := sshdial.AskPassword("New vault passphrase: ")
+	if len(p) == 0 {
+		return nil, vault.ErrNoPassphrase
+	}
+	if sshdial.AskPassword("Confirm vault passphrase: ") != p {
+		return nil, errors.New("Passphrases do not match")
+	}
+	return vault.New([]byte(p)), nil
+}
+
+func readEncrypted(file string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if !vault.IsEncrypted(data) {
+		return nil, fmt.Errorf("%s is not encrypted", file)
+	}
+	return vault.Decrypt(data)
+}
+
+func writeEncrypted(v *vault.Vault, file string, data []byte) error {
+	out, err := v.EncryptFile(data)
+	if err != nil {
+		return err
+	}
+	return writeFile(file, out)
+}
+
+// writeFile replaces the contents of file keeping its permissions.
+// New files are only readable by the user
+func writeFile(file string, data []byte) error {
+	mode := os.FileMode(0600)
+	if fi, err := os.Stat(file); err == nil {
+		mode = fi.Mode()
+	}
+	return ioutil.WriteFile(file, data, mode)
+}
@@ -8,6 +8,7 @@ import (
 
 	_ "github.com/bserdar/watermelon/server/backends/localhost"
 	_ "github.com/bserdar/watermelon/server/backends/remotelinux"
+	_ "github.com/bserdar/watermelon/server/backends/wrapper"
 )
 
 func main() {
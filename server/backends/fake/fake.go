@@ -0,0 +1,401 @@
+// Package fake implements an in-memory host backend to test modules
+// without real hosts. Every host using the backend has its own
+// virtual filesystem. Commands are not run, their responses are
+// scripted using regular expressions. All operations are recorded, so
+// tests can check what files a module wrote and what commands it ran.
+//
+// Directories are implicit: writing a file creates its parent
+// directories.
+//
+// The backend is not registered by name. Assign it to the hosts
+// directly:
+//
+//	b := fake.New()
+//	host.Backend = b
+package fake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// Operation names recorded in the op log
+const (
+	OpWrite    = "write"
+	OpRead     = "read"
+	OpRun      = "run"
+	OpStat     = "stat"
+	OpMkdir    = "mkdir"
+	OpChmod    = "chmod"
+	OpChown    = "chown"
+	OpChecksum = "checksum"
+)
+
+// Op is a recorded operation
+type Op struct {
+	Host string
+	Op   string
+	// Path of the file for file operations
+	Path string
+	// Command for run
+	Cmd string
+	Env map[string]string
+	// Content written for write
+	Content []byte
+	// Mode for write and chmod
+	Mode os.FileMode
+	// User and group for chown
+	User  string
+	Group string
+}
+
+// File is a file or directory in the virtual filesystem
+type File struct {
+	Mode    os.FileMode
+	Content []byte
+	Dir     bool
+	User    string
+	UID     string
+	Group   string
+	GID     string
+	ModTime time.Time
+}
+
+type response struct {
+	host string
+	rx   *regexp.Regexp
+	rsp  server.HostCommandResponse
+}
+
+// Backend is the in-memory backend. A single backend instance can be
+// shared by many hosts, keeping a separate filesystem for each.
+type Backend struct {
+	sync.Mutex
+
+	files     map[string]map[string]*File
+	responses []response
+	ops       []Op
+}
+
+// New returns a new empty backend
+func New() *Backend {
+	return &Backend{files: make(map[string]map[string]*File)}
+}
+
+// NewSession returns a session for the host
+func (b *Backend) NewSession(s server.Session, h *server.Host) (server.HostSession, error) {
+	return &Session{Backend: b, Host: h}, nil
+}
+
+// BecomeMethods returns the privilege escalation methods accepted by
+// the backend
+func (b *Backend) BecomeMethods() []string {
+	return server.DefaultBecomeMethods
+}
+
+// On scripts the response of the commands matching the regular
+// expression rx for the host. If host is empty, the response is used
+// for all hosts. Responses registered later take precedence. Commands
+// that do not match any response succeed with empty output.
+func (b *Backend) On(host, rx string, rsp server.HostCommandResponse) {
+	b.Lock()
+	defer b.Unlock()
+	b.responses = append(b.responses, response{host: host, rx: regexp.MustCompile(rx), rsp: rsp})
+}
+
+// OnOutput scripts a successful command with the given output
+func (b *Backend) OnOutput(host, rx, out string) {
+	b.On(host, rx, server.HostCommandResponse{Out: []byte(out)})
+}
+
+// OnFail scripts a failed command with the given exit code and
+// error output
+func (b *Backend) OnFail(host, rx string, exitCode int, errout string) {
+	b.On(host, rx, server.HostCommandResponse{Err: []byte(errout), ExitCode: exitCode})
+}
+
+// hostFiles returns the filesystem of the host. Must be called with
+// lock held
+func (b *Backend) hostFiles(host string) map[string]*File {
+	fs, ok := b.files[host]
+	if !ok {
+		fs = map[string]*File{"/": {Dir: true, Mode: os.ModeDir | 0755, User: "root", UID: "0", Group: "root", GID: "0"}}
+		b.files[host] = fs
+	}
+	return fs
+}
+
+// mkdirs creates the directory and its parents. Must be called with
+// lock held
+func (b *Backend) mkdirs(host, dir string) {
+	fs := b.hostFiles(host)
+	for dir = path.Clean(dir); ; dir = path.Dir(dir) {
+		if f, ok := fs[dir]; ok && f.Dir {
+			return
+		}
+		fs[dir] = &File{Dir: true, Mode: os.ModeDir | 0755, User: "root", UID: "0", Group: "root", GID: "0", ModTime: time.Now()}
+		if dir == "/" || dir == "." {
+			return
+		}
+	}
+}
+
+// SetFile creates or replaces a file on the host. Parent directories
+// are created
+func (b *Backend) SetFile(host, name string, mode os.FileMode, content []byte) {
+	b.Lock()
+	defer b.Unlock()
+	name = path.Clean(name)
+	b.mkdirs(host, path.Dir(name))
+	b.hostFiles(host)[name] = &File{Mode: mode.Perm(),
+		Content: append([]byte{}, content...),
+		User:    "root",
+		UID:     "0",
+		Group:   "root",
+		GID:     "0",
+		ModTime: time.Now()}
+}
+
+// SetDir creates a directory on the host
+func (b *Backend) SetDir(host, name string) {
+	b.Lock()
+	defer b.Unlock()
+	b.mkdirs(host, name)
+}
+
+// GetFile returns a copy of a file or directory of the host, or nil
+// if it does not exist
+func (b *Backend) GetFile(host, name string) *File {
+	b.Lock()
+	defer b.Unlock()
+	f, ok := b.hostFiles(host)[path.Clean(name)]
+	if !ok {
+		return nil
+	}
+	ret := *f
+	ret.Content = append([]byte{}, f.Content...)
+	return &ret
+}
+
+// Files returns the sorted names of all files and directories of the
+// host
+func (b *Backend) Files(host string) []string {
+	b.Lock()
+	defer b.Unlock()
+	ret := make([]string, 0)
+	for k := range b.hostFiles(host) {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Ops returns the operations recorded for the host. If host is empty,
+// returns all recorded operations
+func (b *Backend) Ops(host string) []Op {
+	b.Lock()
+	defer b.Unlock()
+	ret := make([]Op, 0, len(b.ops))
+	for _, x := range b.ops {
+		if len(host) == 0 || x.Host == host {
+			ret = append(ret, x)
+		}
+	}
+	return ret
+}
+
+// Commands returns the commands run on the host in order
+func (b *Backend) Commands(host string) []string {
+	ret := make([]string, 0)
+	for _, x := range b.Ops(host) {
+		if x.Op == OpRun {
+			ret = append(ret, x.Cmd)
+		}
+	}
+	return ret
+}
+
+// Written returns the names of the files written to the host in
+// order
+func (b *Backend) Written(host string) []string {
+	ret := make([]string, 0)
+	for _, x := range b.Ops(host) {
+		if x.Op == OpWrite {
+			ret = append(ret, x.Path)
+		}
+	}
+	return ret
+}
+
+// Reset removes all files, scripted responses, and recorded
+// operations
+func (b *Backend) Reset() {
+	b.Lock()
+	defer b.Unlock()
+	b.files = make(map[string]map[string]*File)
+	b.responses = nil
+	b.ops = nil
+}
+
+func (b *Backend) record(op Op) {
+	b.Lock()
+	defer b.Unlock()
+	b.ops = append(b.ops, op)
+}
+
+// Session is a host session on the fake backend
+type Session struct {
+	Backend *Backend
+	Host    *server.Host
+}
+
+// Close closes a session
+func (s *Session) Close() {}
+
+// WriteFile writes a file to the virtual filesystem
+func (s *Session) WriteFile(name string, perms os.FileMode, content []byte) (server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpWrite, Path: name, Mode: perms, Content: append([]byte{}, content...)})
+	if f := s.Backend.GetFile(s.Host.ID, name); f != nil && f.Dir {
+		return server.NewCmdErr(s.Host, "Is a directory: %s", name), nil
+	}
+	s.Backend.SetFile(s.Host.ID, name, perms, content)
+	return nil, nil
+}
+
+// ReadFile reads a file from the virtual filesystem
+func (s *Session) ReadFile(name string) (os.FileInfo, []byte, server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpRead, Path: name})
+	f := s.Backend.GetFile(s.Host.ID, name)
+	if f == nil {
+		return nil, nil, server.NewCmdErr(s.Host, "File not found: %s", name), nil
+	}
+	if f.Dir {
+		return nil, nil, server.NewCmdErr(s.Host, "Is a directory: %s", name), nil
+	}
+	return f.info(name), f.Content, nil, nil
+}
+
+// Run returns the scripted response for the command
+func (s *Session) Run(cmd string, env map[string]string) (server.HostCommandResponse, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpRun, Cmd: cmd, Env: env})
+	s.Backend.Lock()
+	defer s.Backend.Unlock()
+	for i := len(s.Backend.responses) - 1; i >= 0; i-- {
+		r := s.Backend.responses[i]
+		if (len(r.host) == 0 || r.host == s.Host.ID) && r.rx.MatchString(cmd) {
+			return r.rsp, nil
+		}
+	}
+	return server.HostCommandResponse{}, nil
+}
+
+// GetFileInfo returns the file info from the virtual filesystem. If
+// the file does not exist, returns nil file info
+func (s *Session) GetFileInfo(name string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpStat, Path: name})
+	f := s.Backend.GetFile(s.Host.ID, name)
+	if f == nil {
+		return server.FileOwner{}, nil, nil, nil
+	}
+	return server.FileOwner{OwnerName: f.User, OwnerID: f.UID, GroupName: f.Group, GroupID: f.GID}, f.info(name), nil, nil
+}
+
+// MkDir creates a directory with its parents
+func (s *Session) MkDir(name string) (server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpMkdir, Path: name})
+	if f := s.Backend.GetFile(s.Host.ID, name); f != nil && !f.Dir {
+		return server.NewCmdErr(s.Host, "File exists: %s", name), nil
+	}
+	s.Backend.SetDir(s.Host.ID, name)
+	return nil, nil
+}
+
+// Chmod changes the file mode
+func (s *Session) Chmod(name string, mode int) (server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpChmod, Path: name, Mode: os.FileMode(mode)})
+	return s.update(name, func(f *File) {
+		f.Mode = (f.Mode &^ os.ModePerm) | os.FileMode(mode).Perm()
+	}), nil
+}
+
+// Chown changes the file owner and/or group. Numeric values set the
+// IDs, others set the names
+func (s *Session) Chown(name, user, group string) (server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpChown, Path: name, User: user, Group: group})
+	return s.update(name, func(f *File) {
+		if len(user) > 0 {
+			if isNumeric(user) {
+				f.UID, f.User = user, ""
+			} else {
+				f.User, f.UID = user, ""
+			}
+		}
+		if len(group) > 0 {
+			if isNumeric(group) {
+				f.GID, f.Group = group, ""
+			} else {
+				f.Group, f.GID = group, ""
+			}
+		}
+	}), nil
+}
+
+func (s *Session) update(name string, f func(*File)) server.CmdErr {
+	s.Backend.Lock()
+	defer s.Backend.Unlock()
+	file, ok := s.Backend.hostFiles(s.Host.ID)[path.Clean(name)]
+	if !ok {
+		return server.NewCmdErr(s.Host, "File not found: %s", name)
+	}
+	f(file)
+	return nil
+}
+
+func (f *File) info(name string) os.FileInfo {
+	mode := f.Mode
+	if f.Dir {
+		mode |= os.ModeDir
+	}
+	return server.CommonFileInfo{FileName: path.Base(name),
+		FileSize:    int64(len(f.Content)),
+		FileMode:    mode,
+		FileModTime: f.ModTime,
+		FileIsDir:   f.Dir}
+}
+
+func isNumeric(s string) bool {
+	return len(s) > 0 && strings.Trim(s, "0123456789") == ""
+}
+
+// Capabilities returns the capabilities of the fake backend. Become
+// is accepted, but has no effect
+func (s *Session) Capabilities() server.Capabilities {
+	return server.Capabilities{Become: server.DefaultBecomeMethods,
+		Checksum:     true,
+		AtomicRename: true,
+		Ownership:    true,
+		FileTransfer: server.TransferMemory}
+}
+
+// Checksum returns the SHA256 checksum of the file
+func (s *Session) Checksum(name string) (string, server.CmdErr, error) {
+	s.Backend.record(Op{Host: s.Host.ID, Op: OpChecksum, Path: name})
+	f := s.Backend.GetFile(s.Host.ID, name)
+	if f == nil {
+		return "", nil, nil
+	}
+	if f.Dir {
+		return "", server.NewCmdErr(s.Host, "Is a directory: %s", name), nil
+	}
+	sum := sha256.Sum256(f.Content)
+	return hex.EncodeToString(sum[:]), nil, nil
+}
@@ -2,14 +2,19 @@ package localhost
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"os/user"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/bserdar/watermelon/server"
 )
@@ -30,7 +35,12 @@ func (b Backend) NewSession(s server.Session, h *server.Host) (server.HostSessio
 	return &Session{Host: b.Host, Session: s}, nil
 }
 
-// Session has a pointer to host
+// Session has a pointer to host. Commands run the same way they run
+// on a remote host using ssh: using the shell of the user, in the home
+// directory, with the given environment added to the environment of
+// the process. If the host becomes root using sudo, commands and
+// file operations run with "sudo -n", so they fail if sudo requires a
+// password.
 type Session struct {
 	Host    *server.Host
 	Session server.Session
@@ -40,72 +50,224 @@ type Session struct {
 func (s *Session) Close() {
 }
 
+func (s *Session) sudo() bool {
+	return s.Host.Become == "sudo"
+}
+
+// shell returns the shell of the user
+func shell() string {
+	if sh := os.Getenv("SHELL"); len(sh) > 0 {
+		return sh
+	}
+	return "/bin/sh"
+}
+
 // WriteFile writes a file on the host
 func (s *Session) WriteFile(name string, perms os.FileMode, content []byte) (server.CmdErr, error) {
+	if s.sudo() {
+		return s.sudoCmd(content, "sh", "-c", fmt.Sprintf(`cat > "$1" && chmod 0%o "$1"`, perms&os.ModePerm), "sh", name)
+	}
 	f, err := os.Create(name)
 	if err != nil {
-		return server.CmdErrFromErr(server.Localhost, err), nil
+		return server.CmdErrFromErr(s.Host, err), nil
 	}
 	defer f.Close()
 	err = f.Chmod(perms)
 	if err != nil {
-		return server.CmdErrFromErr(server.Localhost, err), nil
+		return server.CmdErrFromErr(s.Host, err), nil
 	}
 	_, err = f.Write(content)
 	if err != nil {
-		return server.CmdErrFromErr(server.Localhost, err), nil
+		return server.CmdErrFromErr(s.Host, err), nil
 	}
 	return nil, nil
 }
 
 // ReadFile reads a local file
 func (s *Session) ReadFile(name string) (os.FileInfo, []byte, server.CmdErr, error) {
+	if s.sudo() {
+		_, fi, cerr, err := s.GetFileInfo(name)
+		if err != nil || cerr != nil {
+			return nil, nil, cerr, err
+		}
+		if fi == nil {
+			return nil, nil, server.NewCmdErr(s.Host, "File not found: %s", name), nil
+		}
+		out := bytes.Buffer{}
+		cerr, err = s.sudoOut(&out, nil, "cat", name)
+		if err != nil || cerr != nil {
+			return nil, nil, cerr, err
+		}
+		return fi, out.Bytes(), nil, nil
+	}
 	fi, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return nil, nil, server.NewCmdErr(s.Host, "File not found: %s", name), nil
+	}
 	if err != nil {
-		return fi, nil, server.CmdErrFromErr(server.Localhost, err), nil
+		return nil, nil, server.CmdErrFromErr(s.Host, err), nil
 	}
 	data, err := ioutil.ReadFile(name)
-	return fi, data, server.CmdErrFromErr(server.Localhost, err), nil
+	if err != nil {
+		return nil, nil, server.CmdErrFromErr(s.Host, err), nil
+	}
+	return fi, data, nil, nil
 }
 
-// Run runs cmd
+// Run runs cmd using the shell
 func (s *Session) Run(cmd string, env map[string]string) (server.HostCommandResponse, error) {
-	shell := os.Getenv("SHELL")
-	if len(shell) == 0 {
-		shell = "/bin/sh"
-	}
-	args := strings.Split(cmd, " ")
-	cmd = args[0]
-	args = args[1:]
-	s.Session.GetLogger(s.Host).Printf("%s %s", cmd, strings.Join(args, " "))
-	command := exec.Command(shell, "-s")
-	command.Stdin = strings.NewReader(fmt.Sprintf("%s %s", cmd, strings.Join(args, " ")))
+	logger := s.Session.GetLogger(s.Host)
+	logger.Printf("%s", cmd)
+	command := exec.Command(shell(), "-c", cmd)
+	if s.sudo() {
+		command = exec.Command("sudo", sudoArgs(shell(), "-c", cmd)...)
+	}
+	command.Env = os.Environ()
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		command.Env = append(command.Env, k+"="+env[k])
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		command.Dir = home
+	}
 	out := bytes.Buffer{}
-	err := bytes.Buffer{}
-	command.Stdout = &out
-	command.Stderr = &err
+	errout := bytes.Buffer{}
+	stdoutLog := &server.LineLogger{Logger: logger, Prefix: "stdout: "}
+	stderrLog := &server.LineLogger{Logger: logger, Prefix: "stderr: "}
+	command.Stdout = io.MultiWriter(&out, stdoutLog)
+	command.Stderr = io.MultiWriter(&errout, stderrLog)
 	e := command.Run()
-	s.Session.GetLogger(s.Host).Printf("out: %s err: %s", out.String(), err.String())
+	stdoutLog.Flush()
+	stderrLog.Flush()
 	statusCode := 0
 	if e != nil {
+		logger.Printf("exec error: %s", e.Error())
 		if c, ok := e.(*exec.ExitError); ok {
 			statusCode = c.ProcessState.ExitCode()
 		} else {
 			return server.HostCommandResponse{}, e
 		}
 	}
-	return server.HostCommandResponse{Out: out.Bytes(), Err: err.Bytes(), ExitCode: statusCode}, nil
+	return server.HostCommandResponse{Out: out.Bytes(), Err: errout.Bytes(), ExitCode: statusCode}, nil
 }
 
-// GetFileInfo retrieves file info from a host
-func (s *Session) GetFileInfo(file string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
-	fi, err := os.Stat(file)
+// sudoArgs returns the sudo arguments to run args. sudo is run
+// non-interactively, so it fails instead of waiting for a password
+// if it needs one
+func sudoArgs(args ...string) []string {
+	return append([]string{"-n", "--"}, args...)
+}
+
+// sudoOut runs the command with sudo, writing its output to out
+func (s *Session) sudoOut(out io.Writer, stdin []byte, args ...string) (server.CmdErr, error) {
+	command := exec.Command("sudo", sudoArgs(args...)...)
+	if stdin != nil {
+		command.Stdin = bytes.NewReader(stdin)
+	}
+	errout := bytes.Buffer{}
+	command.Stdout = out
+	command.Stderr = &errout
+	err := command.Run()
 	if err != nil {
-		return server.FileOwner{}, fi, nil, nil
+		if _, ok := err.(*exec.ExitError); ok {
+			if errout.Len() > 0 {
+				return server.NewCmdErr(s.Host, "%s", strings.TrimSpace(errout.String())), nil
+			}
+			return server.NewCmdErr(s.Host, "%s", err.Error()), nil
+		}
+		return nil, err
+	}
+	return nil, nil
+}
+
+// sudoCmd runs the command with sudo, discarding its output
+func (s *Session) sudoCmd(stdin []byte, args ...string) (server.CmdErr, error) {
+	return s.sudoOut(ioutil.Discard, stdin, args...)
+}
+
+// Exit code used to signal a missing file
+const notFound = 3
+
+// sudoRun runs the command with sudo, and returns its output and
+// exit code
+func (s *Session) sudoRun(args ...string) (server.HostCommandResponse, error) {
+	out := bytes.Buffer{}
+	errout := bytes.Buffer{}
+	command := exec.Command("sudo", sudoArgs(args...)...)
+	command.Stdout = &out
+	command.Stderr = &errout
+	rsp := server.HostCommandResponse{}
+	if err := command.Run(); err != nil {
+		c, ok := err.(*exec.ExitError)
+		if !ok {
+			return rsp, err
+		}
+		rsp.ExitCode = c.ProcessState.ExitCode()
+	}
+	rsp.Out = out.Bytes()
+	rsp.Err = errout.Bytes()
+	return rsp, nil
+}
+
+// sudoStat returns file info using stat with sudo
+func (s *Session) sudoStat(file string) (uint32, uint32, os.FileInfo, server.CmdErr, error) {
+	rsp, err := s.sudoRun("sh", "-c", fmt.Sprintf(`[ -e "$1" ] || [ -L "$1" ] || exit %d; stat -c '%%s %%f %%u %%g %%Y' "$1"`, notFound), "sh", file)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	if rsp.ExitCode == notFound {
+		return 0, 0, nil, nil, nil
+	}
+	if rsp.ExitCode != 0 {
+		return 0, 0, nil, server.NewCmdErr(s.Host, "%s", strings.TrimSpace(string(rsp.Err))), nil
+	}
+	w := server.Words(string(rsp.Out))
+	if len(w) != 5 {
+		return 0, 0, nil, server.NewCmdErr(s.Host, "Cannot get file info: %s", string(rsp.Out)), nil
+	}
+	fi := server.CommonFileInfo{FileName: file}
+	fi.FileSize, _ = strconv.ParseInt(w[0], 10, 64)
+	rawMode, _ := strconv.ParseUint(w[1], 16, 32)
+	fi.FileMode = server.UnixFileMode(uint32(rawMode))
+	fi.FileIsDir = fi.FileMode.IsDir()
+	mtime, _ := strconv.ParseInt(w[4], 10, 64)
+	fi.FileModTime = time.Unix(mtime, 0)
+	uid, _ := strconv.ParseUint(w[2], 10, 32)
+	gid, _ := strconv.ParseUint(w[3], 10, 32)
+	return uint32(uid), uint32(gid), fi, nil, nil
+}
+
+// GetFileInfo retrieves file info from a host. If the file does not
+// exist, returns nil file info
+func (s *Session) GetFileInfo(file string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
+	var uid, gid uint32
+	var fi os.FileInfo
+	if s.sudo() {
+		var cerr server.CmdErr
+		var err error
+		uid, gid, fi, cerr, err = s.sudoStat(file)
+		if err != nil || cerr != nil || fi == nil {
+			return server.FileOwner{}, nil, cerr, err
+		}
+	} else {
+		var err error
+		fi, err = os.Lstat(file)
+		if os.IsNotExist(err) {
+			return server.FileOwner{}, nil, nil, nil
+		}
+		if err != nil {
+			return server.FileOwner{}, nil, server.CmdErrFromErr(s.Host, err), nil
+		}
+		uid = fi.Sys().(*syscall.Stat_t).Uid
+		gid = fi.Sys().(*syscall.Stat_t).Gid
 	}
 	fo := server.FileOwner{}
-	fo.OwnerID = fmt.Sprint(fi.Sys().(*syscall.Stat_t).Uid)
-	fo.GroupID = fmt.Sprint(fi.Sys().(*syscall.Stat_t).Gid)
+	fo.OwnerID = fmt.Sprint(uid)
+	fo.GroupID = fmt.Sprint(gid)
 	u, _ := user.LookupId(fo.OwnerID)
 	if u != nil {
 		fo.OwnerName = u.Username
@@ -119,17 +281,32 @@ func (s *Session) GetFileInfo(file string) (server.FileOwner, os.FileInfo, serve
 
 // MkDir creates dir
 func (s *Session) MkDir(path string) (server.CmdErr, error) {
-	return server.CmdErrFromErr(server.Localhost, os.MkdirAll(path, 0775)), nil
+	if s.sudo() {
+		return s.sudoCmd(nil, "mkdir", "-p", path)
+	}
+	return server.CmdErrFromErr(s.Host, os.MkdirAll(path, 0755)), nil
 }
 
 // Chmod changes file mode
 func (s *Session) Chmod(path string, mode int) (server.CmdErr, error) {
-	return server.CmdErrFromErr(server.Localhost, os.Chmod(path, os.FileMode(mode))), nil
-
+	if s.sudo() {
+		return s.sudoCmd(nil, "chmod", fmt.Sprintf("0%o", mode), path)
+	}
+	return server.CmdErrFromErr(s.Host, os.Chmod(path, os.FileMode(mode))), nil
 }
 
 // Chown changes file owner
 func (s *Session) Chown(path, u, g string) (server.CmdErr, error) {
+	if s.sudo() {
+		owner := u
+		if len(g) > 0 {
+			owner += ":" + g
+		}
+		if len(owner) == 0 {
+			return nil, nil
+		}
+		return s.sudoCmd(nil, "chown", owner, path)
+	}
 	if len(u) > 0 {
 		us, _ := user.LookupId(u)
 		if us == nil {
@@ -140,13 +317,13 @@ func (s *Session) Chown(path, u, g string) (server.CmdErr, error) {
 			if err == nil {
 				err := os.Chown(path, uid, -1)
 				if err != nil {
-					return server.CmdErrFromErr(server.Localhost, err), nil
+					return server.CmdErrFromErr(s.Host, err), nil
 				}
 			} else {
-				return server.CmdErrFromErr(server.Localhost, err), nil
+				return server.CmdErrFromErr(s.Host, err), nil
 			}
 		} else {
-			return server.NewCmdErr(server.Localhost, "User not found: %s", u), nil
+			return server.NewCmdErr(s.Host, "User not found: %s", u), nil
 		}
 	}
 	if len(g) > 0 {
@@ -159,14 +336,49 @@ func (s *Session) Chown(path, u, g string) (server.CmdErr, error) {
 			if err == nil {
 				err := os.Chown(path, -1, gid)
 				if err != nil {
-					return server.CmdErrFromErr(server.Localhost, err), nil
+					return server.CmdErrFromErr(s.Host, err), nil
 				}
 			} else {
-				return server.CmdErrFromErr(server.Localhost, err), nil
+				return server.CmdErrFromErr(s.Host, err), nil
 			}
 		} else {
-			return server.NewCmdErr(server.Localhost, "Group not found: %s", g), nil
+			return server.NewCmdErr(s.Host, "Group not found: %s", g), nil
 		}
 	}
 	return nil, nil
 }
+
+// Capabilities returns the capabilities of localhost
+func (s *Session) Capabilities() server.Capabilities {
+	return server.Capabilities{Streaming: true,
+		Become:       s.Host.BecomeMethods(),
+		Checksum:     true,
+		Symlinks:     true,
+		Ownership:    true,
+		FileTransfer: server.TransferLocal}
+}
+
+// Checksum returns the SHA256 checksum of the file
+func (s *Session) Checksum(file string) (string, server.CmdErr, error) {
+	if s.sudo() {
+		rsp, err := s.sudoRun("sh", "-c", server.ChecksumCmd(file))
+		if err != nil {
+			return "", nil, err
+		}
+		sum, cerr := server.ParseChecksum(s.Host, rsp)
+		return sum, cerr, nil
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", server.CmdErrFromErr(s.Host, err), nil
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", server.CmdErrFromErr(s.Host, err), nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil, nil
+}
@@ -0,0 +1,90 @@
+package localhost
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+type testLogger struct {
+	sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) Print(v ...interface{}) { l.Printf("%s", fmt.Sprint(v...)) }
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.Lock()
+	defer l.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+type testSession struct {
+	server.Session
+	logger *testLogger
+}
+
+func (s testSession) GetLogger(*server.Host) server.Logger { return s.logger }
+
+func TestRun(t *testing.T) {
+	host := &server.Host{HostInfo: pb.HostInfo{ID: server.LocalhostID}}
+	logger := &testLogger{}
+	s := &Session{Host: host, Session: testSession{logger: logger}}
+
+	rsp, err := s.Run(`echo "$X" | tr a-z A-Z; echo 'second  line'; echo err >&2; pwd`, map[string]string{"X": "a  b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, _ := os.UserHomeDir()
+	if string(rsp.Out) != "A  B\nsecond  line\n"+home+"\n" || string(rsp.Err) != "err\n" || rsp.ExitCode != 0 {
+		t.Errorf("Wrong response: %q %q %d", rsp.Out, rsp.Err, rsp.ExitCode)
+	}
+	// stdout and stderr lines may interleave
+	stdout := make([]string, 0)
+	for _, x := range logger.lines {
+		if strings.HasPrefix(x, "stdout: ") {
+			stdout = append(stdout, x)
+		}
+	}
+	if len(stdout) != 3 || stdout[0] != "stdout: A  B" || stdout[1] != "stdout: second  line" ||
+		!strings.Contains(strings.Join(logger.lines, "\n"), "stderr: err") {
+		t.Errorf("Wrong log: %v", logger.lines)
+	}
+
+	rsp, _ = s.Run("exit 4", nil)
+	if rsp.ExitCode != 4 {
+		t.Errorf("Wrong exit code: %d", rsp.ExitCode)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	host := &server.Host{HostInfo: pb.HostInfo{ID: server.LocalhostID}}
+	s := &Session{Host: host, Session: testSession{logger: &testLogger{}}}
+
+	name := filepath.Join(dir, "a", "file")
+	if _, fi, cerr, err := s.GetFileInfo(name); fi != nil || cerr != nil || err != nil {
+		t.Errorf("Expecting missing file: %v %v %v", fi, cerr, err)
+	}
+	if _, _, cerr, _ := s.ReadFile(name); cerr == nil || !strings.Contains(cerr.Msg(), "not found") {
+		t.Errorf("Expecting not found, got %v", cerr)
+	}
+	s.MkDir(filepath.Dir(name))
+	if cerr, err := s.WriteFile(name, 0600, []byte("data")); cerr != nil || err != nil {
+		t.Fatalf("WriteFile: %v %v", cerr, err)
+	}
+	fi, data, cerr, err := s.ReadFile(name)
+	if cerr != nil || err != nil || string(data) != "data" || fi.Mode() != 0600 {
+		t.Errorf("Wrong read: %v %v %s %v", cerr, err, data, fi)
+	}
+}
@@ -1,23 +1,10 @@
 package remotelinux
 
 import (
-	"fmt"
-
-	"github.com/google/uuid"
-
 	"github.com/bserdar/watermelon/server"
 	scp "github.com/hnakamur/go-scp"
 )
 
-// becomeSudo runs the command in sudo
-func becomeSudo(in string) string {
-	delim := uuid.New().String()
-	return fmt.Sprintf(`sudo -s --<<%s
-%s
-%s
-`, delim, in, delim)
-}
-
 func scpBecomeSudo(in *scp.SCP) *scp.SCP {
 	in.SCPCommand = "sudo scp "
 	return in
@@ -25,10 +12,7 @@ func scpBecomeSudo(in *scp.SCP) *scp.SCP {
 
 // Become rewrites the command to become another user
 func Become(host *server.Host, in string) string {
-	if host.Become == "sudo" {
-		return becomeSudo(in)
-	}
-	return in
+	return server.Become(host, in)
 }
 
 // BecomeSCP configures the scp to run with sudo
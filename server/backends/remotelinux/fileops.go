@@ -0,0 +1,319 @@
+package remotelinux
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// File operations use the SFTP subsystem of the host. If the host
+// becomes another user, the sftp server is run using sudo instead of
+// the subsystem. If SFTP is not available, scp and shell commands are
+// used.
+
+// sudoSFTPServer runs the sftp server as root. The sftp server is
+// installed at different locations on different distributions.
+const sudoSFTPServer = `sudo -n -u root sh -c 'for p in /usr/lib/openssh/sftp-server /usr/libexec/openssh/sftp-server /usr/lib/ssh/sftp-server /usr/libexec/sftp-server; do [ -x "$p" ] && exec "$p"; done; echo "sftp-server not found" >&2; exit 127'`
+
+// getSFTP returns the SFTP client of the session, starting one if
+// necessary. Returns nil if SFTP is not available on the host
+func (b *RemoteSession) getSFTP() *sftp.Client {
+	b.sftpLock.Lock()
+	defer b.sftpLock.Unlock()
+	if b.sftp != nil || b.noSFTP {
+		return b.sftp
+	}
+	if err := b.startSFTP(); err != nil {
+		log.WithField("host", b.Host.ID).Debugf("SFTP is not available, using scp: %v", err)
+		b.noSFTP = true
+		return nil
+	}
+	return b.sftp
+}
+
+func (b *RemoteSession) startSFTP() error {
+	ss, err := b.Client.SSH.NewSession()
+	if err != nil {
+		return err
+	}
+	stdin, err := ss.StdinPipe()
+	if err != nil {
+		ss.Close()
+		return err
+	}
+	stdout, err := ss.StdoutPipe()
+	if err != nil {
+		ss.Close()
+		return err
+	}
+	if b.Host.Become == "sudo" {
+		err = ss.Start(sudoSFTPServer)
+	} else {
+		err = ss.RequestSubsystem("sftp")
+	}
+	if err != nil {
+		ss.Close()
+		return err
+	}
+	c, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		ss.Close()
+		return err
+	}
+	b.sftp = c
+	b.sftpSession = ss
+	return nil
+}
+
+// sftpResult returns the error statuses returned from the SFTP server
+// as command errors. Other errors are communication errors
+func (b *RemoteSession) sftpResult(file string, err error) (server.CmdErr, error) {
+	if err == nil {
+		return nil, nil
+	}
+	switch err.(type) {
+	case *sftp.StatusError, *os.PathError:
+		return server.NewCmdErr(b.Host, "%s: %s", file, err.Error()), nil
+	}
+	if os.IsNotExist(err) {
+		return server.NewCmdErr(b.Host, "%s: %s", file, err.Error()), nil
+	}
+	return nil, err
+}
+
+// WriteFile writes a remote file. The file is written in place, so
+// symlinks, the owner, and the other links of an existing file are
+// preserved.
+func (b *RemoteSession) WriteFile(name string, perms os.FileMode, content []byte) (server.CmdErr, error) {
+	c := b.getSFTP()
+	if c == nil {
+		return b.scpWriteFile(name, perms, content)
+	}
+	log.WithField("host", b.Host.ID).Debugf("Writing remote file %s", name)
+	f, err := c.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return b.sftpResult(name, err)
+	}
+	// The permissions of a new file are subject to umask, so set them
+	// before writing the content
+	err = c.Chmod(name, perms.Perm())
+	if err == nil {
+		_, err = f.Write(content)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return b.sftpResult(name, err)
+}
+
+// ReadFile reads a remote file. Returns a command error if the file
+// does not exist
+func (b *RemoteSession) ReadFile(name string) (os.FileInfo, []byte, server.CmdErr, error) {
+	c := b.getSFTP()
+	if c == nil {
+		return b.scpReadFile(name)
+	}
+	log.WithField("host", b.Host.ID).Debugf("ReadFile %s", name)
+	fi, err := c.Stat(name)
+	if os.IsNotExist(err) {
+		return nil, nil, server.NewCmdErr(b.Host, "File not found: %s", name), nil
+	}
+	if err != nil {
+		cerr, err := b.sftpResult(name, err)
+		return nil, nil, cerr, err
+	}
+	data, err := readFile(c, name)
+	if err != nil {
+		cerr, err := b.sftpResult(name, err)
+		return nil, nil, cerr, err
+	}
+	return fi, data, nil, nil
+}
+
+func readFile(c *sftp.Client, name string) ([]byte, error) {
+	f, err := c.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// GetFileInfo retrieves file info from a host. If the file does not
+// exist, returns nil file info
+func (b *RemoteSession) GetFileInfo(file string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
+	c := b.getSFTP()
+	if c == nil {
+		return b.shellGetFileInfo(file)
+	}
+	fi, err := c.Lstat(file)
+	if os.IsNotExist(err) {
+		return server.FileOwner{}, nil, nil, nil
+	}
+	if err != nil {
+		cerr, err := b.sftpResult(file, err)
+		return server.FileOwner{}, nil, cerr, err
+	}
+	b.loadIDNames(c)
+	var fo server.FileOwner
+	if st, ok := fi.Sys().(*sftp.FileStat); ok {
+		fo = server.FileOwner{OwnerID: strconv.FormatUint(uint64(st.UID), 10),
+			OwnerName: b.users[st.UID],
+			GroupID:   strconv.FormatUint(uint64(st.GID), 10),
+			GroupName: b.groups[st.GID]}
+	}
+	return fo, fi, nil, nil
+}
+
+// ReadDir returns the contents of a directory. Requires SFTP
+func (b *RemoteSession) ReadDir(dir string) ([]os.FileInfo, server.CmdErr, error) {
+	c := b.getSFTP()
+	if c == nil {
+		return nil, server.NewCmdErr(b.Host, "Cannot list %s: SFTP is not available", dir), nil
+	}
+	ret, err := c.ReadDir(dir)
+	if err != nil {
+		cerr, err := b.sftpResult(dir, err)
+		return nil, cerr, err
+	}
+	return ret, nil, nil
+}
+
+// MkDir creates dir and its parents
+func (b *RemoteSession) MkDir(dir string) (server.CmdErr, error) {
+	c := b.getSFTP()
+	if c == nil {
+		return b.shellMkDir(dir)
+	}
+	return b.sftpResult(dir, c.MkdirAll(dir))
+}
+
+// Chmod changes file mode
+func (b *RemoteSession) Chmod(file string, mode int) (server.CmdErr, error) {
+	c := b.getSFTP()
+	if c == nil {
+		return b.shellChmod(file, mode)
+	}
+	// The mode bits are sent as is, so setuid etc. are not translated
+	return b.sftpResult(file, c.Chmod(file, os.FileMode(mode&07777)))
+}
+
+// Chown changes file owner and/or group, whichever is nonempty. The
+// user and group can be names or numeric IDs
+func (b *RemoteSession) Chown(file, u, g string) (server.CmdErr, error) {
+	if len(u) == 0 && len(g) == 0 {
+		return nil, nil
+	}
+	c := b.getSFTP()
+	if c == nil {
+		return b.shellChown(file, u, g)
+	}
+	fi, err := c.Stat(file)
+	if err != nil {
+		return b.sftpResult(file, err)
+	}
+	st, ok := fi.Sys().(*sftp.FileStat)
+	if !ok {
+		return b.shellChown(file, u, g)
+	}
+	uid, gid := st.UID, st.GID
+	b.loadIDNames(c)
+	if len(u) > 0 {
+		if uid, ok = b.users.id(u); !ok {
+			// The user may not be in /etc/passwd
+			return b.shellChown(file, u, g)
+		}
+	}
+	if len(g) > 0 {
+		if gid, ok = b.groups.id(g); !ok {
+			return b.shellChown(file, u, g)
+		}
+	}
+	return b.sftpResult(file, c.Chown(file, int(uid), int(gid)))
+}
+
+// idNames maps user or group IDs to names
+type idNames map[uint32]string
+
+// parseIDNames parses /etc/passwd or /etc/group contents
+func parseIDNames(data []byte) idNames {
+	ret := idNames{}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, ok := ret[uint32(id)]; !ok {
+			ret[uint32(id)] = fields[0]
+		}
+	}
+	return ret
+}
+
+// id returns the numeric ID for a name or a numeric ID
+func (m idNames) id(name string) (uint32, bool) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), true
+	}
+	for k, v := range m {
+		if v == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// loadIDNames reads the user and group names of the host if they are
+// not already loaded
+func (b *RemoteSession) loadIDNames(c *sftp.Client) {
+	b.sftpLock.Lock()
+	defer b.sftpLock.Unlock()
+	if b.users != nil {
+		return
+	}
+	load := func(file string) idNames {
+		data, err := readFile(c, file)
+		if err != nil {
+			log.WithField("host", b.Host.ID).Debugf("Cannot read %s: %v", file, err)
+			return idNames{}
+		}
+		return parseIDNames(data)
+	}
+	b.users = load("/etc/passwd")
+	b.groups = load("/etc/group")
+}
+
+// Capabilities returns the capabilities of the session. Files are
+// written in place, so they are not replaced atomically
+func (b *RemoteSession) Capabilities() server.Capabilities {
+	ret := server.Capabilities{Streaming: true,
+		Become:       b.Host.BecomeMethods(),
+		Checksum:     true,
+		Symlinks:     true,
+		Ownership:    true,
+		FileTransfer: server.TransferSCP}
+	if b.getSFTP() != nil {
+		ret.FileTransfer = server.TransferSFTP
+	}
+	return ret
+}
+
+// Checksum returns the SHA256 checksum of the file using sha256sum
+func (b *RemoteSession) Checksum(file string) (string, server.CmdErr, error) {
+	rsp, err := b.Run(server.ChecksumCmd(file), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	sum, cerr := server.ParseChecksum(b.Host, rsp)
+	return sum, cerr, nil
+}
@@ -0,0 +1,133 @@
+package remotelinux
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/pkg/sftp"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// pipeConn is one end of an in-memory connection
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// newTestSession returns a session with an SFTP client connected to
+// an in-process sftp server working on the local filesystem
+func newTestSession(t *testing.T) *RemoteSession {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	srv, err := sftp.NewServer(pipeConn{sr, sw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		srv.Serve()
+		sw.Close()
+	}()
+	c, err := sftp.NewClientPipe(cr, cw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &RemoteSession{Host: &server.Host{}, sftp: c}
+}
+
+func TestSFTPFileOps(t *testing.T) {
+	root, err := ioutil.TempDir("", "sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	b := newTestSession(t)
+	defer b.sftp.Close()
+
+	if _, fi, cerr, err := b.GetFileInfo(filepath.Join(root, "missing")); fi != nil || cerr != nil || err != nil {
+		t.Errorf("Expecting not found, got %v %v %v", fi, cerr, err)
+	}
+	if _, _, cerr, err := b.ReadFile(filepath.Join(root, "missing")); cerr == nil || err != nil {
+		t.Errorf("Expecting command error, got %v %v", cerr, err)
+	}
+	dir := filepath.Join(root, "a", "b", "c")
+	if cerr, err := b.MkDir(dir); cerr != nil || err != nil {
+		t.Fatalf("MkDir: %v %v", cerr, err)
+	}
+	if cerr, err := b.MkDir(dir); cerr != nil || err != nil {
+		t.Errorf("MkDir existing: %v %v", cerr, err)
+	}
+
+	// Larger than one packet
+	content := make([]byte, 70000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	file := filepath.Join(root, "a", "f")
+	if cerr, err := b.WriteFile(file, 0600, content); cerr != nil || err != nil {
+		t.Fatalf("WriteFile: %v %v", cerr, err)
+	}
+	fi, data, cerr, err := b.ReadFile(file)
+	if cerr != nil || err != nil || string(data) != string(content) {
+		t.Errorf("Wrong content: %d bytes, %v %v", len(data), cerr, err)
+	}
+	if fi.Mode() != 0600 || fi.Size() != int64(len(content)) {
+		t.Errorf("Wrong file info: %v %d", fi.Mode(), fi.Size())
+	}
+
+	// Writing through a symlink replaces the target, not the link
+	link := filepath.Join(root, "a", "link")
+	if err := os.Symlink(file, link); err != nil {
+		t.Fatal(err)
+	}
+	if cerr, err := b.WriteFile(link, 0640, []byte("new")); cerr != nil || err != nil {
+		t.Fatalf("WriteFile: %v %v", cerr, err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Symlink replaced: %v", err)
+	}
+	data, _ = ioutil.ReadFile(file)
+	if string(data) != "new" {
+		t.Errorf("Wrong content after write: %s", string(data))
+	}
+	if cerr, err := b.Chmod(file, 0644); cerr != nil || err != nil {
+		t.Errorf("Chmod: %v %v", cerr, err)
+	}
+	_, fi, _, _ = b.GetFileInfo(file)
+	if fi == nil || fi.Mode() != 0644 || fi.Size() != 3 || fi.IsDir() {
+		t.Errorf("Wrong file info: %+v", fi)
+	}
+
+	entries, cerr, err := b.ReadDir(filepath.Join(root, "a"))
+	if cerr != nil || err != nil {
+		t.Fatal(cerr, err)
+	}
+	names := make([]string, 0)
+	for _, x := range entries {
+		names = append(names, x.Name())
+	}
+	sort.Strings(names)
+	if len(names) != 3 || names[0] != "b" || names[1] != "f" || names[2] != "link" {
+		t.Errorf("Wrong entries: %v", names)
+	}
+}
+
+func TestIDNames(t *testing.T) {
+	names := parseIDNames([]byte("root:x:0:0:root:/root:/bin/bash\n# comment\nbad\nuser:x:1000:1000::/home/user:/bin/sh\n"))
+	if names[0] != "root" || names[1000] != "user" || len(names) != 2 {
+		t.Errorf("Wrong names: %v", names)
+	}
+	if id, ok := names.id("user"); !ok || id != 1000 {
+		t.Errorf("Wrong id: %d", id)
+	}
+	if id, ok := names.id("55"); !ok || id != 55 {
+		t.Errorf("Wrong numeric id: %d", id)
+	}
+	if _, ok := names.id("missing"); ok {
+		t.Errorf("Expecting missing user")
+	}
+}
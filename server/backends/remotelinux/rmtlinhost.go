@@ -3,13 +3,16 @@ package remotelinux
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	scp "github.com/hnakamur/go-scp"
+	"github.com/pkg/sftp"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 
@@ -35,6 +38,17 @@ type RemoteSession struct {
 	Client        *sshdial.Client
 	Host          *server.Host
 	ServerSession server.Session
+
+	sftpLock sync.Mutex
+	// sftp is the SFTP client of the session, started on first use
+	sftp        *sftp.Client
+	sftpSession *ssh.Session
+	// noSFTP is set if SFTP is not available on the host. Then file
+	// operations use scp and shell commands
+	noSFTP bool
+	// User and group names of the host, loaded on first use
+	users  idNames
+	groups idNames
 }
 
 // Close closes a session
@@ -44,6 +58,10 @@ func (b *RemoteSession) Close() {
 	if b.Session != nil {
 		b.Session.Close()
 	}
+	if b.sftp != nil {
+		b.sftp.Close()
+		b.sftpSession.Close()
+	}
 	if b.Client != nil {
 		b.Client.Close()
 	}
@@ -99,8 +117,8 @@ func (b *RemoteSession) newShellSession() (*ssh.Session, error) {
 // 	return nil, nil
 // }
 
-// WriteFile writes a remote file via scp
-func (b *RemoteSession) WriteFile(name string, perms os.FileMode, content []byte) (server.CmdErr, error) {
+// scpWriteFile writes a remote file via scp
+func (b *RemoteSession) scpWriteFile(name string, perms os.FileMode, content []byte) (server.CmdErr, error) {
 	logger := log.WithField("host", b.Host.ID)
 	t := time.Now()
 	fileInfo := scp.NewFileInfo(name, int64(len(content)), perms&os.ModePerm, t, t)
@@ -146,8 +164,8 @@ func (b *RemoteSession) WriteFile(name string, perms os.FileMode, content []byte
 // 	return fi, wr.Bytes(), nil, nil
 // }
 
-// ReadFile reads a remote file via scp
-func (b *RemoteSession) ReadFile(name string) (os.FileInfo, []byte, server.CmdErr, error) {
+// scpReadFile reads a remote file via scp
+func (b *RemoteSession) scpReadFile(name string) (os.FileInfo, []byte, server.CmdErr, error) {
 	logger := log.WithField("host", b.Host.ID)
 	logger.Debugf("ReadFile %s", name)
 	wr := bytes.Buffer{}
@@ -184,27 +202,26 @@ func (b *RemoteSession) RunShellCommand(cmd string, env map[string]string) ([]by
 	}
 	o := bytes.Buffer{}
 	e := bytes.Buffer{}
-	b.Stdout = &o
-	b.Stderr = &e
+	hostLogger := b.ServerSession.GetLogger(b.Host)
+	stdoutLog := &server.LineLogger{Logger: hostLogger, Prefix: "stdout: "}
+	stderrLog := &server.LineLogger{Logger: hostLogger, Prefix: "stderr: "}
+	b.Stdout = io.MultiWriter(&o, stdoutLog)
+	b.Stderr = io.MultiWriter(&e, stderrLog)
 
-	b.ServerSession.GetLogger(b.Host).Printf(cmd)
+	hostLogger.Printf(cmd)
 
 	cmd = Become(b.Host, cmd)
 	logger.Debugf("After become: %s", cmd)
 	err = sshSession.Run(cmd)
 	logger.Debugf("Ran %s: err: %v", cmd, err)
+	stdoutLog.Flush()
+	stderrLog.Flush()
 
 	out := o.Bytes()
-	if len(out) > 0 {
-		b.ServerSession.GetLogger(b.Host).Printf("stdout: %s", string(out))
-	}
 	er := e.Bytes()
-	if len(er) > 0 {
-		b.ServerSession.GetLogger(b.Host).Printf("stderr: %s", string(er))
-	}
 	exitStatus := 0
 	if err != nil {
-		b.ServerSession.GetLogger(b.Host).Printf("exec error: %s", err.Error())
+		hostLogger.Printf("exec error: %s", err.Error())
 		if c, ok := err.(*ssh.ExitError); ok {
 			exitStatus = c.ExitStatus()
 			err = nil
@@ -216,8 +233,8 @@ func (b *RemoteSession) RunShellCommand(cmd string, env map[string]string) ([]by
 	return nil, nil, exitStatus, err
 }
 
-// GetFileInfo retrieves file info from a host
-func (b *RemoteSession) GetFileInfo(file string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
+// shellGetFileInfo retrieves file info from a host using stat
+func (b *RemoteSession) shellGetFileInfo(file string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
 	logger := log.WithField("host", b.Host.ID)
 	out, e, _, err := b.RunShellCommand(fmt.Sprintf("\\stat -c \"%%s %%f %%u %%U %%g %%G %%X %%Y %%Z %%n\" %s", file), nil)
 	if err != nil {
@@ -237,20 +254,20 @@ func (b *RemoteSession) GetFileInfo(file string) (server.FileOwner, os.FileInfo,
 		x, _ := strconv.Atoi(w[0])
 		ret.FileSize = int64(x)
 		i, _ := strconv.ParseInt(w[1], 16, 64)
-		ret.FileMode = os.FileMode(i)
+		ret.FileMode = server.UnixFileMode(uint32(i))
 		fo.OwnerID = w[2]
 		fo.OwnerName = w[3]
 		fo.GroupID = w[4]
 		fo.GroupName = w[5]
 		ret.FileName = strings.Join(w[9:], " ")
-		ret.FileIsDir = ret.FileMode|os.ModeDir == os.ModeDir
+		ret.FileIsDir = ret.FileMode.IsDir()
 		return fo, ret, nil, nil
 	}
 	return server.FileOwner{}, nil, server.NewCmdErr(b.Host, "Cannot get file info"), nil
 }
 
-// MkDir creates dir
-func (b *RemoteSession) MkDir(path string) (server.CmdErr, error) {
+// shellMkDir creates dir using mkdir
+func (b *RemoteSession) shellMkDir(path string) (server.CmdErr, error) {
 	_, _, _, err := b.RunShellCommand(fmt.Sprintf("\\mkdir -p %s", path), nil)
 	if err != nil {
 		return nil, err
@@ -258,8 +275,8 @@ func (b *RemoteSession) MkDir(path string) (server.CmdErr, error) {
 	return nil, nil
 }
 
-// Chmod changes file mode
-func (b *RemoteSession) Chmod(path string, mode int) (server.CmdErr, error) {
+// shellChmod changes file mode using chmod
+func (b *RemoteSession) shellChmod(path string, mode int) (server.CmdErr, error) {
 	_, _, _, err := b.RunShellCommand(fmt.Sprintf("\\chmod 0%o %s", mode, path), nil)
 	if err != nil {
 		return nil, err
@@ -267,8 +284,8 @@ func (b *RemoteSession) Chmod(path string, mode int) (server.CmdErr, error) {
 	return nil, nil
 }
 
-// Chown changes file owner
-func (b *RemoteSession) Chown(path, u, g string) (server.CmdErr, error) {
+// shellChown changes file owner using chown
+func (b *RemoteSession) shellChown(path, u, g string) (server.CmdErr, error) {
 	if len(u) > 0 {
 		if len(g) > 0 {
 			_, e, _, err := b.RunShellCommand(fmt.Sprintf("\\chown %s:%s %s", u, g, path), nil)
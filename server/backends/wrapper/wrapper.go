@@ -0,0 +1,275 @@
+// Package wrapper implements the exec backend. The exec backend runs
+// commands on a host through a wrapper command running on localhost,
+// such as "docker exec -i {container} sh -c", "kubectl exec -i {pod}
+// -- sh -c", or "lxc exec {container} -- sh -c". The command to run on
+// the host is passed to the wrapper as its last argument. Files are
+// transferred by streaming them through the stdin/stdout of the
+// wrapper, so the wrapper must pass its stdin to the command.
+//
+// The {name} placeholders in the wrapper are replaced with the host
+// properties with the same name. {id} is replaced with the host ID,
+// and {hostname} is replaced with the hostname. The values are shell
+// quoted, so the placeholders must not be quoted in the wrapper.
+package wrapper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// Backend for a host reached using a wrapper command
+type Backend struct {
+	*server.Host
+}
+
+func init() {
+	server.RegisterBackend("exec", func(h *server.Host) server.HostBackend {
+		return &Backend{Host: h}
+	})
+}
+
+// NewSession returns a session wrapping host
+func (b Backend) NewSession(s server.Session, h *server.Host) (server.HostSession, error) {
+	wrapper, err := ExpandWrapper(b.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{Host: b.Host, Session: s, Wrapper: wrapper}, nil
+}
+
+var placeholderRx = regexp.MustCompile(`\{[a-zA-Z0-9_.-]+\}`)
+
+// ExpandWrapper replaces the placeholders in the wrapper of the host
+// with the shell quoted values
+func ExpandWrapper(h *server.Host) (string, error) {
+	if len(strings.TrimSpace(h.Wrapper)) == 0 {
+		return "", fmt.Errorf("No wrapper command for %s", h.ID)
+	}
+	properties := h.GetInfo().Properties
+	missing := make([]string, 0)
+	ret := placeholderRx.ReplaceAllStringFunc(h.Wrapper, func(in string) string {
+		name := in[1 : len(in)-1]
+		switch name {
+		case "id":
+			return server.ShellQuote(h.ID)
+		case "hostname":
+			return server.ShellQuote(h.Hostname)
+		}
+		if v, ok := properties[name]; ok {
+			return server.ShellQuote(v)
+		}
+		missing = append(missing, name)
+		return in
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("Undefined values in wrapper for %s: %s", h.ID, strings.Join(missing, ", "))
+	}
+	return ret, nil
+}
+
+// Session runs commands using the wrapper
+type Session struct {
+	Host    *server.Host
+	Session server.Session
+	// The wrapper command with placeholders replaced
+	Wrapper string
+}
+
+// Close closes a session
+func (s *Session) Close() {
+}
+
+// exec runs script on the host. If stdin is nonnil, it is passed to
+// the script
+func (s *Session) exec(script string, stdin io.Reader) ([]byte, []byte, int, error) {
+	logger := log.WithField("host", s.Host.ID)
+	if s.Host.Become == "sudo" {
+		// Fail instead of waiting for a password
+		script = "sudo -n sh -c " + server.ShellQuote(script)
+	}
+	cmdline := s.Wrapper + " " + server.ShellQuote(script)
+	logger.Debugf("Exec %s", cmdline)
+	command := exec.Command("/bin/sh", "-c", cmdline)
+	command.Stdin = stdin
+	out := bytes.Buffer{}
+	errout := bytes.Buffer{}
+	command.Stdout = &out
+	command.Stderr = &errout
+	err := command.Run()
+	if err != nil {
+		if c, ok := err.(*exec.ExitError); ok {
+			return out.Bytes(), errout.Bytes(), c.ProcessState.ExitCode(), nil
+		}
+		return nil, nil, 0, err
+	}
+	return out.Bytes(), errout.Bytes(), 0, nil
+}
+
+// Run runs cmd on the host
+func (s *Session) Run(cmd string, env map[string]string) (server.HostCommandResponse, error) {
+	logger := s.Session.GetLogger(s.Host)
+	logger.Printf("%s", cmd)
+	script := cmd
+	if len(env) > 0 {
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		exports := bytes.Buffer{}
+		for _, k := range keys {
+			fmt.Fprintf(&exports, "export %s=%s; ", k, server.ShellQuote(env[k]))
+		}
+		script = exports.String() + cmd
+	}
+	out, errout, status, err := s.exec(script, nil)
+	if err != nil {
+		logger.Printf("exec error: %s", err.Error())
+		return server.HostCommandResponse{}, err
+	}
+	if len(out) > 0 {
+		logger.Printf("stdout: %s", string(out))
+	}
+	if len(errout) > 0 {
+		logger.Printf("stderr: %s", string(errout))
+	}
+	return server.HostCommandResponse{Out: out, Err: errout, ExitCode: status}, nil
+}
+
+// cmdErr returns a command error if the command failed
+func (s *Session) cmdErr(errout []byte, status int) server.CmdErr {
+	if status == 0 {
+		return nil
+	}
+	if len(errout) > 0 {
+		return server.NewCmdErr(s.Host, "%s", strings.TrimSpace(string(errout)))
+	}
+	return server.NewCmdErr(s.Host, "Exit code %d", status)
+}
+
+// WriteFile writes a file on the host by streaming it to cat
+func (s *Session) WriteFile(name string, perms os.FileMode, content []byte) (server.CmdErr, error) {
+	q := server.ShellQuote(name)
+	_, errout, status, err := s.exec(fmt.Sprintf("cat > %s && chmod 0%o %s", q, perms&os.ModePerm, q), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	return s.cmdErr(errout, status), nil
+}
+
+// ReadFile reads a file from the host using cat
+func (s *Session) ReadFile(name string) (os.FileInfo, []byte, server.CmdErr, error) {
+	_, fi, cerr, err := s.GetFileInfo(name)
+	if err != nil || cerr != nil {
+		return nil, nil, cerr, err
+	}
+	if fi == nil {
+		return nil, nil, server.NewCmdErr(s.Host, "File not found: %s", name), nil
+	}
+	out, errout, status, err := s.exec("cat "+server.ShellQuote(name), nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cerr := s.cmdErr(errout, status); cerr != nil {
+		return nil, nil, cerr, nil
+	}
+	return fi, out, nil, nil
+}
+
+// Exit code used to signal a missing file
+const notFound = 3
+
+// GetFileInfo retrieves file info using stat. If the file does not
+// exist, returns nil file info
+func (s *Session) GetFileInfo(file string) (server.FileOwner, os.FileInfo, server.CmdErr, error) {
+	q := server.ShellQuote(file)
+	out, errout, status, err := s.exec(fmt.Sprintf("[ -e %s ] || exit %d; stat -c '%%s %%f %%u %%U %%g %%G %%Y' %s", q, notFound, q), nil)
+	if err != nil {
+		return server.FileOwner{}, nil, nil, err
+	}
+	if status == notFound {
+		return server.FileOwner{}, nil, nil, nil
+	}
+	if cerr := s.cmdErr(errout, status); cerr != nil {
+		return server.FileOwner{}, nil, cerr, nil
+	}
+	w := server.Words(string(out))
+	if len(w) != 7 {
+		return server.FileOwner{}, nil, server.NewCmdErr(s.Host, "Cannot get file info: %s", string(out)), nil
+	}
+	fo := server.FileOwner{OwnerID: w[2], OwnerName: w[3], GroupID: w[4], GroupName: w[5]}
+	fi := server.CommonFileInfo{FileName: file}
+	fi.FileSize, _ = strconv.ParseInt(w[0], 10, 64)
+	rawMode, _ := strconv.ParseUint(w[1], 16, 32)
+	fi.FileMode = server.UnixFileMode(uint32(rawMode))
+	fi.FileIsDir = fi.FileMode.IsDir()
+	mtime, _ := strconv.ParseInt(w[6], 10, 64)
+	fi.FileModTime = time.Unix(mtime, 0)
+	return fo, fi, nil, nil
+}
+
+// MkDir creates dir
+func (s *Session) MkDir(path string) (server.CmdErr, error) {
+	_, errout, status, err := s.exec("mkdir -p "+server.ShellQuote(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.cmdErr(errout, status), nil
+}
+
+// Chmod changes file mode
+func (s *Session) Chmod(path string, mode int) (server.CmdErr, error) {
+	_, errout, status, err := s.exec(fmt.Sprintf("chmod 0%o %s", mode, server.ShellQuote(path)), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.cmdErr(errout, status), nil
+}
+
+// Chown changes file owner and/or group
+func (s *Session) Chown(path, u, g string) (server.CmdErr, error) {
+	owner := u
+	if len(g) > 0 {
+		owner += ":" + g
+	}
+	if len(owner) == 0 {
+		return nil, nil
+	}
+	_, errout, status, err := s.exec(fmt.Sprintf("chown %s %s", server.ShellQuote(owner), server.ShellQuote(path)), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.cmdErr(errout, status), nil
+}
+
+// Capabilities returns the capabilities of the session. The output is
+// not streamed, and files are streamed through the wrapper
+func (s *Session) Capabilities() server.Capabilities {
+	return server.Capabilities{Become: s.Host.BecomeMethods(),
+		Checksum:     true,
+		Symlinks:     true,
+		Ownership:    true,
+		FileTransfer: server.TransferStream}
+}
+
+// Checksum returns the SHA256 checksum of the file using sha256sum
+func (s *Session) Checksum(file string) (string, server.CmdErr, error) {
+	out, errout, status, err := s.exec(server.ChecksumCmd(file), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	sum, cerr := server.ParseChecksum(s.Host, server.HostCommandResponse{Out: out, Err: errout, ExitCode: status})
+	return sum, cerr, nil
+}
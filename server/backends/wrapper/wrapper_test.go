@@ -0,0 +1,125 @@
+package wrapper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Print(...interface{})          {}
+func (nopLogger) Printf(string, ...interface{}) {}
+
+type testSession struct {
+	server.Session
+}
+
+func (testSession) GetLogger(*server.Host) server.Logger { return nopLogger{} }
+
+// stub is a wrapper that records the container name, and runs the
+// script locally
+const stub = `#!/bin/sh
+echo "$1" >> "$(dirname "$0")/containers"
+shift
+exec "$@"
+`
+
+func TestWrapper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wrapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stubFile := filepath.Join(dir, "stub")
+	ioutil.WriteFile(stubFile, []byte(stub), 0755)
+
+	host := &server.Host{HostInfo: pb.HostInfo{ID: "c1", Properties: map[string]string{"container": "box1"}},
+		BackendName: "exec",
+		Wrapper:     stubFile + " {container} sh -c"}
+	host.Backend = server.GetBackend("exec", host)
+	hs, err := host.Backend.NewSession(testSession{}, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hs.Close()
+
+	rsp, err := hs.Run("echo $X 'it''s'", map[string]string{"X": "a b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rsp.Out) != "a b its\n" || rsp.ExitCode != 0 {
+		t.Errorf("Wrong output: %+v", rsp)
+	}
+	rsp, _ = hs.Run("exit 5", nil)
+	if rsp.ExitCode != 5 {
+		t.Errorf("Wrong exit code: %d", rsp.ExitCode)
+	}
+
+	file := filepath.Join(dir, "sub dir", "file")
+	if cerr, err := hs.MkDir(filepath.Dir(file)); err != nil || cerr != nil {
+		t.Fatalf("Mkdir: %v %v", cerr, err)
+	}
+	_, fi, cerr, err := hs.GetFileInfo(file)
+	if fi != nil || cerr != nil || err != nil {
+		t.Errorf("Expecting missing file: %v %v %v", fi, cerr, err)
+	}
+	if cerr, err := hs.WriteFile(file, 0600, []byte("content\n")); err != nil || cerr != nil {
+		t.Fatalf("WriteFile: %v %v", cerr, err)
+	}
+	fi, data, cerr, err := hs.ReadFile(file)
+	if err != nil || cerr != nil {
+		t.Fatalf("ReadFile: %v %v", cerr, err)
+	}
+	if string(data) != "content\n" || fi.Size() != 8 || fi.Mode() != 0600 || fi.IsDir() {
+		t.Errorf("Wrong file: %s %+v", string(data), fi)
+	}
+	hs.Chmod(file, 0640)
+	_, fi, _, _ = hs.GetFileInfo(file)
+	if fi.Mode() != 0640 {
+		t.Errorf("Wrong mode: %o", fi.Mode())
+	}
+	_, fi, _, _ = hs.GetFileInfo(filepath.Dir(file))
+	if !fi.IsDir() {
+		t.Errorf("Expecting dir")
+	}
+	_, _, cerr, _ = hs.ReadFile(filepath.Join(dir, "missing"))
+	if cerr == nil {
+		t.Errorf("Expecting error for missing file")
+	}
+
+	containers, _ := ioutil.ReadFile(filepath.Join(dir, "containers"))
+	for _, x := range strings.Split(strings.TrimSpace(string(containers)), "\n") {
+		if x != "box1" {
+			t.Errorf("Wrong container: %s", x)
+		}
+	}
+
+	// Values are quoted
+	os.Remove(filepath.Join(dir, "containers"))
+	host.Properties = map[string]string{"container": "box 2; touch " + filepath.Join(dir, "injected")}
+	hs2, err := host.Backend.NewSession(testSession{}, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp, err := hs2.Run("true", nil); err != nil || rsp.ExitCode != 0 {
+		t.Errorf("Run failed: %+v %v", rsp, err)
+	}
+	containers, _ = ioutil.ReadFile(filepath.Join(dir, "containers"))
+	if string(containers) != host.Properties["container"]+"\n" {
+		t.Errorf("Wrong container: %s", string(containers))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "injected")); err == nil {
+		t.Errorf("Property is not quoted")
+	}
+
+	host.Wrapper = "docker exec -i {pod} sh -c"
+	if _, err := ExpandWrapper(host); err == nil {
+		t.Errorf("Expecting error for undefined placeholder")
+	}
+}
@@ -1,7 +1,9 @@
 package server
 
 import (
+	"fmt"
 	"os"
+	"strings"
 )
 
 // HostCommandResponse contains the response of the command
@@ -25,14 +27,75 @@ type HostSession interface {
 	MkDir(string) (CmdErr, error)
 	Chmod(string, int) (CmdErr, error)
 	Chown(string, string, string) (CmdErr, error)
+	// Capabilities returns what the session supports
+	Capabilities() Capabilities
 	Close()
 }
 
+// File transfer methods
+const (
+	TransferSFTP   = "sftp"
+	TransferSCP    = "scp"
+	TransferStream = "stream"
+	TransferLocal  = "local"
+	TransferMemory = "memory"
+)
+
+// Capabilities describes the operations supported by a backend, so
+// the server and the modules can choose a strategy for each host
+type Capabilities struct {
+	// Streaming is true if the command output is logged while the
+	// command runs
+	Streaming bool
+	// Become lists the supported privilege escalation methods
+	Become []string
+	// Checksum is true if file checksums can be computed on the
+	// host, so files can be compared without transferring them
+	Checksum bool
+	// Symlinks is true if the host filesystem supports symlinks
+	Symlinks bool
+	// AtomicRename is true if files are replaced atomically when
+	// written
+	AtomicRename bool
+	// Ownership is true if file modes and owners can be changed
+	Ownership bool
+	// FileTransfer is the method used to transfer files
+	FileTransfer string
+}
+
+// Checksummer is implemented by host sessions that can compute file
+// checksums on the host
+type Checksummer interface {
+	// Checksum returns the hex SHA256 checksum of a file. If the file
+	// does not exist, returns empty string
+	Checksum(file string) (string, CmdErr, error)
+}
+
+// Becomer is implemented by host backends that support privilege
+// escalation. BecomeMethods returns the supported methods without
+// connecting to the host, so they can be checked when a session is
+// opened. Backends embedding Host get the default methods.
+type Becomer interface {
+	BecomeMethods() []string
+}
+
+// BecomeMethods returns the privilege escalation methods supported by
+// the backend
+func BecomeMethods(b HostBackend) []string {
+	if x, ok := b.(Becomer); ok {
+		return x.BecomeMethods()
+	}
+	return nil
+}
+
 var backends = map[string]func(*Host) HostBackend{}
 
-// RegisterBackend registers a new backend
-func RegisterBackend(name string, b func(*Host) HostBackend) {
+// RegisterBackend registers a new backend, and returns the backend
+// previously registered with the same name, so it can be restored
+func RegisterBackend(name string, b func(*Host) HostBackend) func(*Host) HostBackend {
+	old := backends[name]
 	backends[name] = b
+	return old
 }
 
 // GetBackend returns the backend
@@ -44,3 +107,27 @@ func GetBackend(name string, h *Host) HostBackend {
 
 	return b(h)
 }
+
+// ChecksumNotFound is the exit code of ChecksumCmd if the file does
+// not exist
+const ChecksumNotFound = 3
+
+// ChecksumCmd returns a shell command that prints the SHA256 checksum
+// of file. The command exits with ChecksumNotFound if the file does
+// not exist
+func ChecksumCmd(file string) string {
+	q := ShellQuote(file)
+	return fmt.Sprintf("[ -e %s ] || exit %d; sha256sum %s", q, ChecksumNotFound, q)
+}
+
+// ParseChecksum returns the checksum from the output of ChecksumCmd
+func ParseChecksum(h *Host, rsp HostCommandResponse) (string, CmdErr) {
+	if rsp.ExitCode == ChecksumNotFound {
+		return "", nil
+	}
+	w := Words(string(rsp.Out))
+	if rsp.ExitCode != 0 || len(w) == 0 {
+		return "", NewCmdErr(h, "Cannot compute checksum: %s", strings.TrimSpace(string(rsp.Err)))
+	}
+	return w[0], nil
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBecomeMethods are the privilege escalation methods
+// implemented by Become
+var DefaultBecomeMethods = []string{"sudo"}
+
+// BecomeMethods returns the default become methods. Backends that
+// embed the host support them unless they override BecomeMethods
+func (h *Host) BecomeMethods() []string {
+	return DefaultBecomeMethods
+}
+
+// BecomeSudo returns a command that runs in as root using sudo. The
+// command is passed to the shell as a here-document, so it does not
+// need to be quoted
+func BecomeSudo(in string) string {
+	delim := uuid.New().String()
+	return fmt.Sprintf(`sudo -s --<<%s
+%s
+%s
+`, delim, in, delim)
+}
+
+// Become rewrites the command to become another user using the
+// become method of the host
+func Become(host *Host, in string) string {
+	if host.Become == "sudo" {
+		return BecomeSudo(in)
+	}
+	return in
+}
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+
+	jptr "github.com/dustin/go-jsonpointer"
+)
+
+// ConfigLayer is a named configuration source
+type ConfigLayer struct {
+	// Name describes where the layer comes from, e.g. the file name
+	Name string
+	// Label is nonempty if the layer only applies to the hosts with
+	// that label. Label layers have precedence over the group
+	// configuration, and the host configuration has precedence over
+	// them
+	Label string
+	// Override layers have precedence over all other layers,
+	// including the host configuration
+	Override bool
+	// Module is nonempty if the layer is only seen by that module,
+	// as the defaults of its configuration schema
+	Module string
+	Config interface{}
+}
+
+// List merge directives. In a configuration layer, a list can be
+// given as a map containing one of these to merge it with the list
+// in the lower precedence layers:
+//
+//	servers:
+//	  $append: [server3]
+//	users:
+//	  $merge:
+//	    - name: admin
+//	      shell: /bin/bash
+//	  $key: name
+//
+// $replace replaces the list, which is the same as giving the list
+// itself. $append appends the items. $merge merges the items with the
+// items of the lower list that have the same $key, and appends the
+// others. The default key is "name".
+const (
+	ListReplace = "$replace"
+	ListAppend  = "$append"
+	ListMerge   = "$merge"
+	ListKey     = "$key"
+)
+
+// listDirective returns the directive and the items if in is a list
+// merge directive
+func listDirective(in interface{}) (string, []interface{}, bool) {
+	m, ok := in.(map[string]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	for _, d := range []string{ListReplace, ListAppend, ListMerge} {
+		if v, ok := m[d]; ok {
+			items, _ := v.([]interface{})
+			return d, items, true
+		}
+	}
+	return "", nil, false
+}
+
+// mergeList merges the items into base based on the directive
+func mergeList(directive string, items []interface{}, key string, base interface{}) []interface{} {
+	baseList, _ := base.([]interface{})
+	ret := make([]interface{}, 0, len(baseList)+len(items))
+	if directive != ListReplace {
+		ret = append(ret, baseList...)
+	}
+	for _, item := range items {
+		item = mergeCfg(nil, item)
+		if directive == ListMerge {
+			if m, ok := item.(map[string]interface{}); ok && m[key] != nil {
+				found := false
+				for i, x := range ret {
+					if xm, ok := x.(map[string]interface{}); ok && reflect.DeepEqual(xm[key], m[key]) {
+						ret[i] = mergeCfg(x, item)
+						found = true
+						break
+					}
+				}
+				if found {
+					continue
+				}
+			}
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// mergeCfg returns top merged on base. Maps are merged recursively,
+// lists are merged based on merge directives, and other values in
+// top replace the values in base. The inputs are not modified
+func mergeCfg(base, top interface{}) interface{} {
+	if directive, items, ok := listDirective(top); ok {
+		key, _ := top.(map[string]interface{})[ListKey].(string)
+		if len(key) == 0 {
+			key = "name"
+		}
+		return mergeList(directive, items, key, base)
+	}
+	t, topMap := top.(map[string]interface{})
+	if !topMap {
+		return top
+	}
+	b, _ := base.(map[string]interface{})
+	ret := make(map[string]interface{}, len(b)+len(t))
+	for k, v := range b {
+		ret[k] = v
+	}
+	for k, v := range t {
+		ret[k] = mergeCfg(b[k], v)
+	}
+	return ret
+}
+
+func lookupCfg(layer interface{}, path string) interface{} {
+	m, ok := layer.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return jptr.Get(m, path)
+}
+
+// ResolveCfg returns the value at path from the configuration
+// layers. Layers are ordered from the highest precedence to the
+// lowest. If the value is a map in more than one layer, the maps are
+// merged recursively, and keys from the higher precedence layers
+// override the lower ones. Lists are merged using the list merge
+// directives. Otherwise the value from the highest precedence layer
+// is returned.
+func ResolveCfg(path string, layers ...interface{}) interface{} {
+	var ret interface{}
+	for i := len(layers) - 1; i >= 0; i-- {
+		v := lookupCfg(layers[i], path)
+		if v == nil {
+			continue
+		}
+		ret = mergeCfg(ret, v)
+	}
+	return ret
+}
+
+// CfgSource is a configuration layer that has a value at a path
+type CfgSource struct {
+	Layer string
+	Value interface{}
+	// Overridden is true if the value is replaced by a layer with
+	// higher precedence
+	Overridden bool
+}
+
+// ExplainCfg returns the value at path from the configuration layers
+// ordered from the highest precedence to the lowest, and the layers
+// that have a value at path in the same order
+func ExplainCfg(path string, layers ...ConfigLayer) (interface{}, []CfgSource) {
+	var ret interface{}
+	sources := make([]CfgSource, 0)
+	for i := len(layers) - 1; i >= 0; i-- {
+		v := lookupCfg(layers[i].Config, path)
+		if v == nil {
+			continue
+		}
+		_, baseMap := ret.(map[string]interface{})
+		_, topMap := v.(map[string]interface{})
+		directive, _, isList := listDirective(v)
+		merged := (baseMap && topMap && !isList) || (isList && directive != ListReplace)
+		if !merged {
+			for x := range sources {
+				sources[x].Overridden = true
+			}
+		}
+		ret = mergeCfg(ret, v)
+		sources = append(sources, CfgSource{Layer: layers[i].Name, Value: v})
+	}
+	for i, j := 0, len(sources)-1; i < j; i, j = i+1, j-1 {
+		sources[i], sources[j] = sources[j], sources[i]
+	}
+	return ret, sources
+}
+
+// SetCfg returns a configuration document containing value at path,
+// a JSON pointer. The path segments are map keys
+func SetCfg(path string, value interface{}) interface{} {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	ret := value
+	for i := len(segments) - 1; i >= 0; i-- {
+		if len(segments[i]) == 0 && i == 0 && len(segments) == 1 {
+			break
+		}
+		key := strings.Replace(strings.Replace(segments[i], "~1", "/", -1), "~0", "~", -1)
+		ret = map[string]interface{}{key: ret}
+	}
+	return ret
+}
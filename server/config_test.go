@@ -0,0 +1,85 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestResolveCfg(t *testing.T) {
+	host := map[string]interface{}{"db": map[string]interface{}{"port": 1}, "name": "host"}
+	group := map[string]interface{}{"db": map[string]interface{}{"port": 2, "host": "group"}}
+	global := map[string]interface{}{"db": map[string]interface{}{"user": "admin"}, "name": "global", "x": "y"}
+
+	db := ResolveCfg("/db", host, nil, group, global).(map[string]interface{})
+	if db["port"] != 1 || db["host"] != "group" || db["user"] != "admin" {
+		t.Errorf("Wrong merge: %v", db)
+	}
+	if ResolveCfg("/name", host, group, global) != "host" || ResolveCfg("/x", host, group, global) != "y" {
+		t.Errorf("Wrong precedence")
+	}
+	if ResolveCfg("/missing", host, group, global) != nil {
+		t.Errorf("Expecting nil")
+	}
+	if _, ok := group["db"].(map[string]interface{})["user"]; ok {
+		t.Errorf("Input modified")
+	}
+}
+
+func TestListMerge(t *testing.T) {
+	base := map[string]interface{}{
+		"servers": []interface{}{"a", "b"},
+		"users":   []interface{}{map[string]interface{}{"name": "admin", "shell": "sh"}},
+	}
+	top := map[string]interface{}{
+		"servers": map[string]interface{}{ListAppend: []interface{}{"c"}},
+		"users": map[string]interface{}{ListMerge: []interface{}{
+			map[string]interface{}{"name": "admin", "shell": "bash"},
+			map[string]interface{}{"name": "ops"}}},
+	}
+	if s := ResolveCfg("/servers", top, base).([]interface{}); len(s) != 3 || s[2] != "c" {
+		t.Errorf("Wrong append: %v", s)
+	}
+	users := ResolveCfg("/users", top, base).([]interface{})
+	if len(users) != 2 || users[0].(map[string]interface{})["shell"] != "bash" {
+		t.Errorf("Wrong merge: %v", users)
+	}
+	replace := map[string]interface{}{"servers": map[string]interface{}{ListReplace: []interface{}{"x"}}}
+	if s := ResolveCfg("/servers", replace, top, base).([]interface{}); len(s) != 1 || s[0] != "x" {
+		t.Errorf("Wrong replace: %v", s)
+	}
+	// A directive without a lower list is the list itself
+	if s := ResolveCfg("/servers", top).([]interface{}); len(s) != 1 {
+		t.Errorf("Wrong append to nothing: %v", s)
+	}
+	// Keys can be maps or lists
+	base = map[string]interface{}{"vols": []interface{}{
+		map[string]interface{}{"id": map[string]interface{}{"dc": "a"}, "size": 1},
+		map[string]interface{}{"id": []interface{}{"b"}, "size": 2}}}
+	top = map[string]interface{}{"vols": map[string]interface{}{ListKey: "id", ListMerge: []interface{}{
+		map[string]interface{}{"id": map[string]interface{}{"dc": "a"}, "size": 3},
+		map[string]interface{}{"id": []interface{}{"c"}, "size": 4}}}}
+	vols := ResolveCfg("/vols", top, base).([]interface{})
+	if len(vols) != 3 || vols[0].(map[string]interface{})["size"] != 3 || vols[1].(map[string]interface{})["size"] != 2 {
+		t.Errorf("Wrong merge with map keys: %v", vols)
+	}
+}
+
+func TestExplainCfg(t *testing.T) {
+	layers := []ConfigLayer{
+		{Name: "set", Config: SetCfg("/db/port", 3)},
+		{Name: "host", Config: map[string]interface{}{"db": map[string]interface{}{"user": "u"}}},
+		{Name: "global", Config: map[string]interface{}{"db": map[string]interface{}{"port": 1}}},
+	}
+	v, sources := ExplainCfg("/db/port", layers...)
+	if v != 3 || len(sources) != 2 || sources[0].Layer != "set" || sources[0].Overridden || !sources[1].Overridden {
+		t.Errorf("Wrong explain: %v %+v", v, sources)
+	}
+	v, sources = ExplainCfg("/db", layers...)
+	if m := v.(map[string]interface{}); m["port"] != 3 || m["user"] != "u" || len(sources) != 3 {
+		t.Errorf("Wrong explain: %v %+v", v, sources)
+	}
+	for _, s := range sources {
+		if s.Overridden {
+			t.Errorf("Merged map overridden: %+v", s)
+		}
+	}
+}
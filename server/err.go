@@ -41,6 +41,16 @@ func NewCmdErr(host *Host, msg string, args ...interface{}) CmdErr {
 	return cmdErr{host: host.ID, msg: fmt.Sprintf(msg, args...)}
 }
 
+// ErrUnsupported returns a command error for an operation not
+// supported by the backend of the host
+func ErrUnsupported(host *Host, op string) CmdErr {
+	backend := host.BackendName
+	if len(backend) == 0 {
+		backend = "unknown"
+	}
+	return NewCmdErr(host, "%s is unsupported on backend %s", op, backend)
+}
+
 // CmdErrFromErr returns a CmdErr from error. if err is nil, returns nil
 func CmdErrFromErr(host *Host, err error) CmdErr {
 	if err == nil {
@@ -61,3 +61,32 @@ func ParseFileMode(str string) os.FileMode {
 	}
 	return ret | (rwx(str[1:]) << 6) | (rwx(str[4:]) << 3) | (rwx(str[7:]))
 }
+
+// UnixFileMode converts a unix st_mode to os.FileMode
+func UnixFileMode(mode uint32) os.FileMode {
+	ret := os.FileMode(mode & 0777)
+	switch mode & 0170000 {
+	case 0040000:
+		ret |= os.ModeDir
+	case 0120000:
+		ret |= os.ModeSymlink
+	case 0010000:
+		ret |= os.ModeNamedPipe
+	case 0140000:
+		ret |= os.ModeSocket
+	case 0020000:
+		ret |= os.ModeDevice | os.ModeCharDevice
+	case 0060000:
+		ret |= os.ModeDevice
+	}
+	if mode&04000 != 0 {
+		ret |= os.ModeSetuid
+	}
+	if mode&02000 != 0 {
+		ret |= os.ModeSetgid
+	}
+	if mode&01000 != 0 {
+		ret |= os.ModeSticky
+	}
+	return ret
+}
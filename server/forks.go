@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+)
+
+// forks limits the number of host sessions open at the same time
+// across all modules of the run. nil means no limit
+var forks struct {
+	sync.RWMutex
+	slots chan struct{}
+}
+
+// SetForks sets the maximum number of host sessions that can be open
+// at the same time. Operations on other hosts wait until a session is
+// closed. 0 means no limit. It should be called before any host
+// sessions are opened.
+func SetForks(n int) {
+	forks.Lock()
+	defer forks.Unlock()
+	if n <= 0 {
+		forks.slots = nil
+		return
+	}
+	forks.slots = make(chan struct{}, n)
+}
+
+// acquireFork waits until a host session can be opened for s, and
+// returns the function that releases it. Returns an error if s is
+// closed while waiting
+func acquireFork(s Session) (func(), error) {
+	forks.RLock()
+	slots := forks.slots
+	forks.RUnlock()
+	if slots == nil {
+		return func() {}, nil
+	}
+	var done <-chan struct{}
+	if s != nil {
+		done = s.Done()
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-done:
+		return nil, fmt.Errorf("Session %s is closed", s.GetID())
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type countingBackend struct {
+	sync.Mutex
+	open, max int
+}
+
+type countingSession struct {
+	HostSession
+	b *countingBackend
+}
+
+func (b *countingBackend) NewSession(Session, *Host) (HostSession, error) {
+	b.Lock()
+	defer b.Unlock()
+	b.open++
+	if b.open > b.max {
+		b.max = b.open
+	}
+	return countingSession{b: b}, nil
+}
+
+func (s countingSession) Close() {
+	s.b.Lock()
+	s.b.open--
+	s.b.Unlock()
+}
+
+func TestForks(t *testing.T) {
+	SetForks(2)
+	defer SetForks(0)
+	b := &countingBackend{}
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := &Host{Backend: b}
+			ctx := h.NewCtx()
+			if _, err := ctx.New(nil); err != nil {
+				t.Error(err)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			ctx.Close()
+		}()
+	}
+	wg.Wait()
+	if b.max != 2 {
+		t.Errorf("Expecting at most 2 sessions, got %d", b.max)
+	}
+}
+
+type closingSession struct {
+	Session
+	done chan struct{}
+}
+
+func (s closingSession) GetID() string         { return "closing" }
+func (s closingSession) Done() <-chan struct{} { return s.done }
+
+func TestForksClosedSession(t *testing.T) {
+	SetForks(1)
+	defer SetForks(0)
+	b := &countingBackend{}
+	h1, h2 := &Host{Backend: b}, &Host{Backend: b}
+	ctx1 := h1.NewCtx()
+	if _, err := ctx1.New(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx1.Close()
+
+	s := closingSession{done: make(chan struct{})}
+	result := make(chan error)
+	go func() {
+		_, err := h2.NewCtx().New(s)
+		result <- err
+	}()
+	close(s.done)
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Errorf("Expecting error for closed session")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Waiting for a fork is not cancelled")
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Handler is a module function that is queued for a host when
+// notified, and called once for that host when the handlers are
+// flushed.
+type Handler struct {
+	Name   string
+	Module string
+	Func   string
+	// If true, the handler runs at the end of the module call that
+	// notified it. Otherwise it runs at the end of the run
+	AfterCall bool
+}
+
+// HandlerArgs is the input passed to a handler function
+type HandlerArgs struct {
+	HostID  string `json:"hostId"`
+	Handler string `json:"handler"`
+}
+
+// Handlers keeps the declared handlers of a session, and the
+// handlers queued for each host
+type Handlers struct {
+	sync.Mutex
+
+	decls []Handler
+	// handler name -> host id -> call id
+	queued map[string]map[string]string
+}
+
+type handlerRun struct {
+	handler Handler
+	hostID  string
+}
+
+// Declare a new handler. Handlers run in declaration order.
+// Redeclaring a handler with the same name replaces the old
+// declaration but keeps its position
+func (h *Handlers) Declare(handler Handler) error {
+	if len(handler.Name) == 0 {
+		return fmt.Errorf("Handler name is empty")
+	}
+	if len(handler.Module) == 0 || len(handler.Func) == 0 {
+		return fmt.Errorf("Module and function are required for handler %s", handler.Name)
+	}
+	h.Lock()
+	defer h.Unlock()
+	for i, x := range h.decls {
+		if x.Name == handler.Name {
+			h.decls[i] = handler
+			return nil
+		}
+	}
+	h.decls = append(h.decls, handler)
+	return nil
+}
+
+// Notify queues the handler for the host. A handler is queued only
+// once for a host no matter how many times it is notified. A handler
+// that runs after a call is moved to the last call that notified it,
+// so it runs even if it was not flushed after an earlier call.
+func (h *Handlers) Notify(hostID, name, callID string) error {
+	h.Lock()
+	defer h.Unlock()
+	var decl *Handler
+	for i, x := range h.decls {
+		if x.Name == name {
+			decl = &h.decls[i]
+			break
+		}
+	}
+	if decl == nil {
+		return fmt.Errorf("Unknown handler: %s", name)
+	}
+	if h.queued == nil {
+		h.queued = make(map[string]map[string]string)
+	}
+	hosts, ok := h.queued[name]
+	if !ok {
+		hosts = make(map[string]string)
+		h.queued[name] = hosts
+	}
+	if _, ok := hosts[hostID]; !ok || decl.AfterCall {
+		hosts[hostID] = callID
+	}
+	return nil
+}
+
+// Pending returns the names of the handlers queued for a host, in
+// declaration order
+func (h *Handlers) Pending(hostID string) []string {
+	h.Lock()
+	defer h.Unlock()
+	ret := make([]string, 0)
+	for _, x := range h.decls {
+		if _, ok := h.queued[x.Name][hostID]; ok {
+			ret = append(ret, x.Name)
+		}
+	}
+	return ret
+}
+
+// take removes the queued handlers selected by the filter, and
+// returns them in declaration order
+func (h *Handlers) take(filter func(Handler, string, string) bool) []handlerRun {
+	h.Lock()
+	defer h.Unlock()
+	ret := make([]handlerRun, 0)
+	for _, decl := range h.decls {
+		hosts := h.queued[decl.Name]
+		for _, hostID := range sortedKeys(hosts) {
+			if filter(decl, hostID, hosts[hostID]) {
+				ret = append(ret, handlerRun{handler: decl, hostID: hostID})
+				delete(hosts, hostID)
+			}
+		}
+	}
+	return ret
+}
+
+// Flush runs the queued handlers for the given hosts. If hosts is
+// empty, all queued handlers run.
+func (h *Handlers) Flush(mgr ModuleMgr, session string, hosts []string) (Response, error) {
+	return h.run(mgr, session, h.take(func(_ Handler, hostID, _ string) bool {
+		return len(hosts) == 0 || ArrayContains(hosts, hostID)
+	}))
+}
+
+// FlushCall runs the handlers that should run after a call, and that
+// are notified during that call
+func (h *Handlers) FlushCall(mgr ModuleMgr, session, callID string) (Response, error) {
+	return h.run(mgr, session, h.take(func(handler Handler, _, notifiedBy string) bool {
+		return handler.AfterCall && notifiedBy == callID
+	}))
+}
+
+// run runs all the handlers, because they are no longer queued. The
+// errors are combined
+func (h *Handlers) run(mgr ModuleMgr, session string, runs []handlerRun) (Response, error) {
+	ret := Response{Success: true}
+	errs := make([]string, 0)
+	for _, r := range runs {
+		log.Debugf("Running handler %s for %s", r.handler.Name, r.hostID)
+		data, err := json.Marshal(HandlerArgs{HostID: r.hostID, Handler: r.handler.Name})
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		rsp, err := mgr.SendRequest(session, r.handler.Module, r.handler.Func, data)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Handler %s failed for %s: %s", r.handler.Name, r.hostID, err))
+			continue
+		}
+		ret.Append(rsp)
+	}
+	if len(errs) > 0 {
+		return ret, errors.New(strings.Join(errs, "\n"))
+	}
+	return ret, nil
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testModuleMgr struct {
+	calls []string
+	// Calls to these functions fail
+	fail []string
+}
+
+func (m *testModuleMgr) SendRequest(session, module, funcName string, data []byte) (Response, error) {
+	var args HandlerArgs
+	json.Unmarshal(data, &args)
+	m.calls = append(m.calls, funcName+":"+args.HostID)
+	if ArrayContains(m.fail, funcName) {
+		return Response{}, fmt.Errorf("%s failed", funcName)
+	}
+	return Response{Success: true, Modified: true}, nil
+}
+
+func (m *testModuleMgr) CloseSession(string) {}
+
+func (m *testModuleMgr) Close() {}
+
+func TestHandlers(t *testing.T) {
+	h := Handlers{}
+	h.Declare(Handler{Name: "restart", Module: "m", Func: "restart"})
+	h.Declare(Handler{Name: "reload", Module: "m", Func: "reload"})
+	h.Declare(Handler{Name: "now", Module: "m", Func: "now", AfterCall: true})
+
+	if err := h.Notify("h1", "missing", "c1"); err == nil {
+		t.Errorf("Expecting error for unknown handler")
+	}
+	h.Notify("h2", "reload", "c1")
+	h.Notify("h1", "reload", "c1")
+	h.Notify("h1", "restart", "c1")
+	h.Notify("h1", "restart", "c2")
+	h.Notify("h1", "now", "c1")
+	h.Notify("h2", "now", "c2")
+
+	if p := h.Pending("h1"); len(p) != 3 || p[0] != "restart" || p[1] != "reload" || p[2] != "now" {
+		t.Errorf("Wrong pending handlers: %v", p)
+	}
+
+	mgr := &testModuleMgr{}
+	h.FlushCall(mgr, "s", "c1")
+	if len(mgr.calls) != 1 || mgr.calls[0] != "now:h1" {
+		t.Errorf("Wrong after-call handlers: %v", mgr.calls)
+	}
+
+	mgr = &testModuleMgr{}
+	h.Flush(mgr, "s", []string{"h1"})
+	if len(mgr.calls) != 2 || mgr.calls[0] != "restart:h1" || mgr.calls[1] != "reload:h1" {
+		t.Errorf("Wrong handlers for h1: %v", mgr.calls)
+	}
+
+	mgr = &testModuleMgr{}
+	rsp, _ := h.Flush(mgr, "s", nil)
+	if len(mgr.calls) != 2 || mgr.calls[0] != "reload:h2" || mgr.calls[1] != "now:h2" {
+		t.Errorf("Wrong remaining handlers: %v", mgr.calls)
+	}
+	if !rsp.Success || !rsp.Modified {
+		t.Errorf("Wrong response: %+v", rsp)
+	}
+
+	mgr = &testModuleMgr{}
+	h.Flush(mgr, "s", nil)
+	if len(mgr.calls) != 0 {
+		t.Errorf("Handlers ran twice: %v", mgr.calls)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := Handlers{}
+	h.Declare(Handler{Name: "restart", Module: "m", Func: "restart"})
+	h.Declare(Handler{Name: "reload", Module: "m", Func: "reload"})
+	h.Declare(Handler{Name: "now", Module: "m", Func: "now", AfterCall: true})
+	h.Notify("h1", "restart", "c1")
+	h.Notify("h2", "restart", "c1")
+	h.Notify("h1", "reload", "c1")
+
+	// All handlers run even if some fail
+	mgr := &testModuleMgr{fail: []string{"restart"}}
+	_, err := h.Flush(mgr, "s", nil)
+	if len(mgr.calls) != 3 {
+		t.Errorf("Wrong handlers: %v", mgr.calls)
+	}
+	if err == nil || !strings.Contains(err.Error(), "h1") || !strings.Contains(err.Error(), "h2") {
+		t.Errorf("Expecting combined errors, got %v", err)
+	}
+
+	// An after-call handler that is not flushed after the call that
+	// notified it runs after the next call notifying it
+	h.Notify("h1", "now", "c2")
+	h.Notify("h1", "now", "c3")
+	mgr = &testModuleMgr{}
+	h.FlushCall(mgr, "s", "c3")
+	if len(mgr.calls) != 1 || mgr.calls[0] != "now:h1" {
+		t.Errorf("Wrong after-call handlers: %v", mgr.calls)
+	}
+}
@@ -25,12 +25,21 @@ const Primary6 = "primary6"
 // LocalhostID is the "localhost"
 var LocalhostID = "localhost"
 
-// Host contains information about a host in the inventory
+// Host contains information about a host in the inventory. The
+// labels and properties in HostInfo can be changed at run time, so
+// they must be read using GetInfo, or while the host is locked
 type Host struct {
 	sync.Mutex
 	pb.HostInfo
 
 	Configuration interface{}
+	// Groups are the inventory groups the host belongs to, directly
+	// or through child groups, ordered from the highest precedence to
+	// the lowest
+	Groups []string
+	// GroupConfiguration contains the configuration of each group in
+	// Groups, in the same order
+	GroupConfiguration []interface{}
 
 	// The bastion host to use to connect this host
 	Bastion *Host
@@ -51,7 +60,59 @@ type Host struct {
 	// Become user methos
 	Become string
 
+	// BackendName is the name of the registered backend used to
+	// reach this host
+	BackendName string
+	// Wrapper is the command template used by the exec backend to run
+	// commands on the host, e.g. "docker exec -i {container} sh -c"
+	Wrapper string
+
 	Backend HostBackend
+
+	// sessions are the open sessions of the host. They are closed
+	// when the host is removed from the inventory
+	sessions []HostSession
+	removed  bool
+}
+
+// CloseSessions closes the open sessions of the host, and prevents
+// new sessions from being opened. It is called when the host is
+// removed from the inventory
+func (h *Host) CloseSessions() {
+	h.Lock()
+	sessions := h.sessions
+	h.sessions = nil
+	h.removed = true
+	h.Unlock()
+	for _, s := range sessions {
+		s.Close()
+	}
+}
+
+// addSession registers an open session. Returns false if the host is
+// removed
+func (h *Host) addSession(s HostSession) bool {
+	h.Lock()
+	defer h.Unlock()
+	if h.removed {
+		return false
+	}
+	h.sessions = append(h.sessions, s)
+	return true
+}
+
+// removeSession removes a closed session. Returns false if the
+// session was already closed by CloseSessions
+func (h *Host) removeSession(s HostSession) bool {
+	h.Lock()
+	defer h.Unlock()
+	for i, x := range h.sessions {
+		if x == s {
+			h.sessions = append(h.sessions[:i], h.sessions[i+1:]...)
+			return true
+		}
+	}
+	return false
 }
 
 // GetNetwork returns the network to connect to this host
@@ -103,6 +164,7 @@ type hostCtx struct {
 	session HostSession
 	ref     int
 	host    *Host
+	release func()
 }
 
 // Ctx is host session context
@@ -122,11 +184,25 @@ func (ctx *hostCtx) New(s Session) (HostSession, error) {
 		ctx.ref++
 		return ctx.session, nil
 	}
+	if b := ctx.host.Become; len(b) > 0 && !ArrayContains(BecomeMethods(ctx.host.Backend), b) {
+		return nil, ErrUnsupported(ctx.host, "become "+b)
+	}
 	var err error
+	if ctx.release, err = acquireFork(s); err != nil {
+		return nil, err
+	}
 	ctx.session, err = ctx.host.Backend.NewSession(s, ctx.host)
 	if err != nil {
+		ctx.session = nil
+		ctx.release()
 		return nil, err
 	}
+	if !ctx.host.addSession(ctx.session) {
+		ctx.session.Close()
+		ctx.session = nil
+		ctx.release()
+		return nil, NewCmdErr(ctx.host, "Host is removed from the inventory")
+	}
 	ctx.ref++
 	return ctx.session, nil
 }
@@ -136,16 +212,20 @@ func (ctx *hostCtx) Close() {
 	if ctx.ref > 0 {
 		ctx.ref--
 	}
-	if ctx.ref == 0 {
-		ctx.session.Close()
+	if ctx.ref == 0 && ctx.session != nil {
+		if ctx.host.removeSession(ctx.session) {
+			ctx.session.Close()
+		}
 		ctx.session = nil
+		ctx.release()
 	}
 }
 
 // Localhost is the localhost
 var Localhost = &Host{HostInfo: pb.HostInfo{ID: LocalhostID,
 	Labels:     make([]string, 0),
-	Properties: make(map[string]string)}}
+	Properties: make(map[string]string)},
+	BackendName: "localhost"}
 
 func init() {
 	Localhost.DiscoverIPs()
@@ -170,6 +250,44 @@ func (h *Host) Defaults() {
 	}
 }
 
+// GetInfo returns a copy of the host information
+func (h *Host) GetInfo() pb.HostInfo {
+	h.Lock()
+	defer h.Unlock()
+	info := pb.HostInfo{ID: h.ID,
+		Addresses:  h.Addresses,
+		Labels:     append([]string{}, h.Labels...),
+		Properties: make(map[string]string, len(h.Properties))}
+	for k, v := range h.Properties {
+		info.Properties[k] = v
+	}
+	return info
+}
+
+// SetLabels replaces the labels of the host
+func (h *Host) SetLabels(labels []string) {
+	h.Lock()
+	defer h.Unlock()
+	h.Labels = append([]string{}, labels...)
+}
+
+// SetProperties sets the properties of the host. If replace is true,
+// existing properties are removed
+func (h *Host) SetProperties(properties map[string]string, replace bool) {
+	h.Lock()
+	defer h.Unlock()
+	newProperties := make(map[string]string)
+	if !replace {
+		for k, v := range h.Properties {
+			newProperties[k] = v
+		}
+	}
+	for k, v := range properties {
+		newProperties[k] = v
+	}
+	h.Properties = newProperties
+}
+
 // HasLabel returns true if host has the label l
 func HasLabel(h *pb.HostInfo, l string) bool {
 	return ArrayContains(h.Labels, l)
@@ -294,6 +412,32 @@ func (h *Host) Chown(ctx Ctx, s Session, path string, user, group string) (CmdEr
 	return session.Chown(path, user, group)
 }
 
+// Capabilities returns the capabilities of the host backend
+func (h *Host) Capabilities(ctx Ctx, s Session) (Capabilities, error) {
+	session, err := ctx.New(s)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	defer ctx.Close()
+	return session.Capabilities(), nil
+}
+
+// Checksum returns the hex SHA256 checksum of a file on the host. If
+// the file does not exist, returns empty string. Returns an
+// unsupported error if the backend cannot compute checksums
+func (h *Host) Checksum(ctx Ctx, s Session, path string) (string, CmdErr, error) {
+	session, err := ctx.New(s)
+	if err != nil {
+		return "", nil, err
+	}
+	defer ctx.Close()
+	c, ok := session.(Checksummer)
+	if !ok || !session.Capabilities().Checksum {
+		return "", ErrUnsupported(h, "checksum"), nil
+	}
+	return c.Checksum(path)
+}
+
 // FileDesc describes the attributes of a file/directory
 type FileDesc struct {
 	Mode  *int
@@ -306,13 +450,18 @@ type FileDesc struct {
 
 // Ensure a file has the desired attributes
 func (h *Host) Ensure(ctx Ctx, s Session, path string, desc FileDesc) (bool, CmdErr, error) {
-	_, err := ctx.New(s)
+	session, err := ctx.New(s)
 	if err != nil {
 		return false, nil, err
 	}
 	defer ctx.Close()
 
 	log.Debugf("Ensure %s %+v ctx: %+v", path, desc, ctx)
+	if desc.Mode != nil || desc.UID != nil || desc.GID != nil || desc.User != nil || desc.Group != nil {
+		if !session.Capabilities().Ownership {
+			return false, ErrUnsupported(h, "Changing file mode and owner"), nil
+		}
+	}
 	owner, fi, _, err := h.GetFileInfo(ctx, s, path)
 	if err != nil {
 		return false, nil, err
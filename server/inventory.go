@@ -30,7 +30,8 @@ type Inventory interface {
 	// GetHostIDs returns the host IDs included in the inventory
 	GetHostIDs(string) ([]string, error)
 
-	// GetHostInfo returns the host information for the given hosts
+	// GetHostInfo returns copies of the host information for the
+	// given hosts
 	GetHostInfo([]string) ([]*pb.HostInfo, error)
 
 	// Release notifies the server that the inventory is no longer
@@ -44,6 +45,21 @@ type InternalInventory interface {
 	Inventory
 
 	GetHost([]string) ([]*Host, error)
+
+	// AddHost adds a new host to the inventory of all hosts
+	AddHost(*Host) error
+
+	// RemoveHost removes a host from all inventories
+	RemoveHost(string) error
+
+	// SetLabels replaces the labels of a host, and returns a copy of
+	// the host information
+	SetLabels(string, []string) (*pb.HostInfo, error)
+
+	// SetProperties sets the properties of a host. If replace is
+	// true, existing properties are removed. Returns a copy of the
+	// host information
+	SetProperties(string, map[string]string, bool) (*pb.HostInfo, error)
 }
 
 // NewInventoryID returns a new inventory ID. It skips AllHosts.
@@ -54,4 +70,26 @@ func NewInventoryID() string {
 // InventoryConfiguration contains the configuration loaded from the inventory
 type InventoryConfiguration struct {
 	PrivateKey *sshdial.RawPrivateKey
+	// LocalhostBecome is the become method for localhost
+	LocalhostBecome string
+	// Groups maps the inventory groups to their child groups
+	Groups map[string][]string
+}
+
+// InventoryLoader loads the hosts and the configuration from the
+// inventory source at location
+type InventoryLoader func(location string) (InventoryConfiguration, []*Host, map[string]interface{}, error)
+
+var inventoryLoaders = map[string]InventoryLoader{}
+
+// RegisterInventoryLoader registers a new inventory source. The
+// inventory can be given as name:location
+func RegisterInventoryLoader(name string, loader InventoryLoader) {
+	inventoryLoaders[name] = loader
+}
+
+// GetInventoryLoader returns the inventory loader registered with
+// name, or nil if there is no such loader
+func GetInventoryLoader(name string) InventoryLoader {
+	return inventoryLoaders[name]
 }
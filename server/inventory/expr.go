@@ -0,0 +1,428 @@
+package inventory
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+// Selector expressions combine label and property predicates with
+// boolean operators:
+//
+//	db && (prod || staging) && !maintenance
+//	role=web && dc!=east
+//
+// A bare word selects hosts having that label. key=value selects
+// hosts whose property key has value, and key!=value selects hosts
+// whose property key does not have value. Values containing spaces or
+// operator characters can be quoted with double quotes. ! binds
+// tighter than &&, which binds tighter than ||.
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokEq
+	tokNeq
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func isWordChar(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune("&|!()=\"", r)
+}
+
+func tokenize(expr string) ([]token, error) {
+	ret := make([]token, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			ret = append(ret, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			ret = append(ret, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case r == '=':
+			ret = append(ret, token{kind: tokEq, text: "=", pos: i})
+			i++
+		case r == '!':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				ret = append(ret, token{kind: tokNeq, text: "!=", pos: i})
+				i += 2
+			} else {
+				ret = append(ret, token{kind: tokNot, text: "!", pos: i})
+				i++
+			}
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("Expecting %c%c at %d", r, r, i)
+			}
+			kind := tokAnd
+			if r == '|' {
+				kind = tokOr
+			}
+			ret = append(ret, token{kind: kind, text: string([]rune{r, r}), pos: i})
+			i += 2
+		case r == '"':
+			start := i
+			i++
+			b := strings.Builder{}
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("Unterminated string at %d", start)
+			}
+			i++
+			ret = append(ret, token{kind: tokWord, text: b.String(), pos: start})
+		default:
+			start := i
+			for i < len(runes) && isWordChar(runes[i]) {
+				i++
+			}
+			ret = append(ret, token{kind: tokWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+	return append(ret, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token { return p.tokens[p.pos] }
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) errorf(t token, format string, args ...interface{}) error {
+	if t.kind == tokEOF {
+		return fmt.Errorf(format+" at end of expression", args...)
+	}
+	return fmt.Errorf(format+" at %d: %s", append(args, t.pos, t.text)...)
+}
+
+func (p *exprParser) parseOr() (*pb.Selector, error) {
+	operands := make([]*pb.Selector, 0)
+	for {
+		s, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, s)
+		if p.peek().kind != tokOr {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &pb.Selector{Select: &pb.Selector_Or{Or: &pb.SelectorList{Selectors: operands}}}, nil
+}
+
+func (p *exprParser) parseAnd() (*pb.Selector, error) {
+	operands := make([]*pb.Selector, 0)
+	for {
+		s, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, s)
+		if p.peek().kind != tokAnd {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &pb.Selector{Select: &pb.Selector_And{And: &pb.SelectorList{Selectors: operands}}}, nil
+}
+
+func (p *exprParser) parseUnary() (*pb.Selector, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNot:
+		s, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &pb.Selector{Select: &pb.Selector_Not{Not: s}}, nil
+	case tokLParen:
+		s, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, p.errorf(t, "Expecting )")
+		}
+		return s, nil
+	case tokWord:
+		return p.parseTerm(t)
+	}
+	return nil, p.errorf(t, "Unexpected token")
+}
+
+func (p *exprParser) parseTerm(word token) (*pb.Selector, error) {
+	op := p.peek()
+	if op.kind != tokEq && op.kind != tokNeq {
+		return &pb.Selector{Select: &pb.Selector_HasAllLabels{HasAllLabels: &pb.LabelSet{Labels: []string{word.text}}}}, nil
+	}
+	p.next()
+	value := p.next()
+	if value.kind != tokWord {
+		return nil, p.errorf(value, "Expecting property value")
+	}
+	s := &pb.Selector{Select: &pb.Selector_HasAllProperty{HasAllProperty: &pb.PropertySet{Properties: []*pb.PropertySet_KVS{{Key: word.text, Values: []string{value.text}}}}}}
+	if op.kind == tokNeq {
+		return &pb.Selector{Select: &pb.Selector_Not{Not: s}}, nil
+	}
+	return s, nil
+}
+
+// ParseSelector parses a selector expression
+func ParseSelector(expr string) (*pb.Selector, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid selector %s: %v", expr, err)
+	}
+	p := &exprParser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, fmt.Errorf("Empty selector")
+	}
+	s, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid selector %s: %v", expr, err)
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("Invalid selector %s: %v", expr, p.errorf(t, "Unexpected token"))
+	}
+	return s, nil
+}
+
+// CompileSelector compiles the selector into a Matcher. Expressions
+// are parsed, and patterns and networks are compiled once. Returns an
+// error if any of them is invalid. Missing selectors are invalid, and
+// missing sets are empty
+func CompileSelector(s *pb.Selector) (Matcher, error) {
+	if s == nil {
+		return nil, fmt.Errorf("Invalid selector")
+	}
+	switch k := s.Select.(type) {
+	case *pb.Selector_Expr:
+		sel, err := ParseSelector(k.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return CompileSelector(sel)
+	case *pb.Selector_And:
+		l, err := compileList(k.And)
+		if err != nil {
+			return nil, err
+		}
+		return func(h *pb.HostInfo) bool {
+			for _, m := range l {
+				if !m(h) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case *pb.Selector_Or:
+		l, err := compileList(k.Or)
+		if err != nil {
+			return nil, err
+		}
+		return func(h *pb.HostInfo) bool {
+			for _, m := range l {
+				if m(h) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_Not:
+		m, err := CompileSelector(k.Not)
+		if err != nil {
+			return nil, err
+		}
+		return func(h *pb.HostInfo) bool { return !m(h) }, nil
+	case *pb.Selector_IDGlob:
+		patterns := k.IDGlob.GetPatterns()
+		for _, x := range patterns {
+			if _, err := path.Match(x, ""); err != nil {
+				return nil, fmt.Errorf("Invalid pattern %s: %v", x, err)
+			}
+		}
+		return func(h *pb.HostInfo) bool {
+			for _, x := range patterns {
+				if ok, _ := path.Match(x, h.ID); ok {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_IDRegex:
+		rxs := make([]*regexp.Regexp, 0, len(k.IDRegex.GetPatterns()))
+		for _, x := range k.IDRegex.GetPatterns() {
+			rx, err := regexp.Compile(x)
+			if err != nil {
+				return nil, err
+			}
+			rxs = append(rxs, rx)
+		}
+		return func(h *pb.HostInfo) bool {
+			for _, rx := range rxs {
+				if rx.MatchString(h.ID) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_InCIDR:
+		networks := make([]*net.IPNet, 0, len(k.InCIDR.GetCIDRs()))
+		for _, x := range k.InCIDR.GetCIDRs() {
+			_, network, err := net.ParseCIDR(x)
+			if err != nil {
+				return nil, err
+			}
+			networks = append(networks, network)
+		}
+		return func(h *pb.HostInfo) bool {
+			for _, network := range networks {
+				for _, a := range h.Addresses {
+					if ip := net.ParseIP(a.Address); ip != nil && network.Contains(ip) {
+						return true
+					}
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_HasPropertyRegex:
+		if k.HasPropertyRegex == nil {
+			return nil, fmt.Errorf("Invalid selector")
+		}
+		key := k.HasPropertyRegex.Key
+		rx, err := regexp.Compile(k.HasPropertyRegex.Regex)
+		if err != nil {
+			return nil, err
+		}
+		return func(h *pb.HostInfo) bool {
+			v, ok := h.Properties[key]
+			return ok && rx.MatchString(v)
+		}, nil
+	case *pb.Selector_HasProperties:
+		keys := k.HasProperties.GetKeys()
+		return func(h *pb.HostInfo) bool {
+			for _, x := range keys {
+				if _, ok := h.Properties[x]; !ok {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case *pb.Selector_HasAllLabels:
+		labels := k.HasAllLabels.GetLabels()
+		return func(h *pb.HostInfo) bool {
+			for _, r := range labels {
+				if !server.HasLabel(h, r) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case *pb.Selector_HasAnyLabel:
+		labels := k.HasAnyLabel.GetLabels()
+		return func(h *pb.HostInfo) bool {
+			for _, r := range labels {
+				if server.HasLabel(h, r) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_HasNoneLabels:
+		labels := k.HasNoneLabels.GetLabels()
+		return func(h *pb.HostInfo) bool {
+			for _, r := range labels {
+				if server.HasLabel(h, r) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case *pb.Selector_ByID:
+		ids := k.ByID.GetIDs()
+		return func(h *pb.HostInfo) bool {
+			for _, r := range ids {
+				if h.ID == r {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_HasAnyProperty:
+		properties := k.HasAnyProperty.GetProperties()
+		return func(h *pb.HostInfo) bool {
+			for _, p := range properties {
+				if isMatch(p, h) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *pb.Selector_HasAllProperty:
+		properties := k.HasAllProperty.GetProperties()
+		return func(h *pb.HostInfo) bool {
+			for _, p := range properties {
+				if !isMatch(p, h) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return nil, fmt.Errorf("Invalid selector: %v", s)
+}
+
+func compileList(l *pb.SelectorList) ([]Matcher, error) {
+	ret := make([]Matcher, 0, len(l.GetSelectors()))
+	for _, x := range l.GetSelectors() {
+		m, err := CompileSelector(x)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, m)
+	}
+	return ret, nil
+}
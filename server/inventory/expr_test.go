@@ -0,0 +1,61 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+func TestSelectorExpr(t *testing.T) {
+	hosts := []*server.Host{
+		{HostInfo: pb.HostInfo{ID: "db1", Labels: []string{"db", "prod"}, Properties: map[string]string{"dc": "east"}}},
+		{HostInfo: pb.HostInfo{ID: "db2", Labels: []string{"db", "staging", "maintenance"}}},
+		{HostInfo: pb.HostInfo{ID: "db3", Labels: []string{"db", "staging"}, Properties: map[string]string{"dc": "west coast"}}},
+		{HostInfo: pb.HostInfo{ID: "web1", Labels: []string{"web", "prod"}, Properties: map[string]string{"dc": "east"}}},
+	}
+	ids := func(expr string) string {
+		h, err := Limit(hosts, expr)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			return ""
+		}
+		ret := ""
+		for _, x := range h {
+			ret += x.ID + " "
+		}
+		return ret
+	}
+	for expr, expected := range map[string]string{
+		"db && (prod || staging) && !maintenance": "db1 db3 ",
+		"db && prod || staging && !maintenance":   "db1 db3 ",
+		"!!web":                                   "web1 ",
+		"dc=east && !db":                          "web1 ",
+		"dc!=east":                                "db2 db3 ",
+		`dc="west coast"`:                         "db3 ",
+		"!(db || web)":                            "",
+	} {
+		if x := ids(expr); x != expected {
+			t.Errorf("%s: expected %q got %q", expr, expected, x)
+		}
+	}
+	for _, bad := range []string{"", "db &", "db && (prod", "dc=", "db prod", "a | b", `"x`} {
+		if _, err := ParseSelector(bad); err == nil {
+			t.Errorf("Expecting error for %q", bad)
+		}
+	}
+
+	srv := NewInvServer(hosts, server.InventoryConfiguration{}, nil)
+	id, err := srv.Select(server.AllHosts, &pb.Selector{Select: &pb.Selector_Or{Or: &pb.SelectorList{Selectors: []*pb.Selector{
+		{Select: &pb.Selector_Expr{Expr: "web"}},
+		{Select: &pb.Selector_Not{Not: &pb.Selector{Select: &pb.Selector_HasAnyLabel{HasAnyLabel: &pb.LabelSet{Labels: []string{"staging", "web"}}}}}}}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, _ := srv.GetHostIDs(id); len(x) != 2 || x[0] != "db1" || x[1] != "web1" {
+		t.Errorf("Wrong selection: %v", x)
+	}
+	if _, err := srv.Select(server.AllHosts, &pb.Selector{Select: &pb.Selector_Expr{Expr: "(db"}}); err == nil {
+		t.Errorf("Expecting error")
+	}
+}
@@ -57,6 +57,26 @@ type InvServer struct {
 	Cfg      server.InventoryConfiguration
 }
 
+// Limit returns the hosts matching the selector expression
+func Limit(hosts []*server.Host, expr string) ([]*server.Host, error) {
+	sel, err := ParseSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	m, err := CompileSelector(sel)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*server.Host, 0, len(hosts))
+	for _, host := range hosts {
+		info := host.GetInfo()
+		if m(&info) {
+			ret = append(ret, host)
+		}
+	}
+	return ret, nil
+}
+
 // NewInvServer creates a new inventory server instance
 func NewInvServer(hosts []*server.Host, cfg server.InventoryConfiguration, session server.Session) *InvServer {
 	ret := &InvServer{AllHosts: &hostSet{id: server.AllHosts},
@@ -78,11 +98,20 @@ func (srv *InvServer) Select(from string, selectors ...*pb.Selector) (string, er
 	if !ok {
 		return "", fmt.Errorf("Inventory not found: %s", from)
 	}
+	compiled := make([]Matcher, 0, len(selectors))
+	for _, sel := range selectors {
+		m, err := CompileSelector(sel)
+		if err != nil {
+			return "", err
+		}
+		compiled = append(compiled, m)
+	}
 	hosts := make([]*server.Host, 0)
 	for _, host := range inv.hosts {
 		ok := true
-		for _, sel := range selectors {
-			if !IsMatch(sel, &host.HostInfo) {
+		info := host.GetInfo()
+		for _, m := range compiled {
+			if !m(&info) {
 				ok = false
 				break
 			}
@@ -218,7 +247,7 @@ func (srv *InvServer) GetHost(req []string) ([]*server.Host, error) {
 	return ret, nil
 }
 
-// GetHostInfo returns information about some hosts
+// GetHostInfo returns copies of the information of some hosts
 func (srv *InvServer) GetHostInfo(req []string) ([]*pb.HostInfo, error) {
 	ret, err := srv.GetHost(req)
 	if err != nil {
@@ -226,11 +255,98 @@ func (srv *InvServer) GetHostInfo(req []string) ([]*pb.HostInfo, error) {
 	}
 	out := make([]*pb.HostInfo, 0, len(ret))
 	for _, x := range ret {
-		out = append(out, &x.HostInfo)
+		info := x.GetInfo()
+		out = append(out, &info)
 	}
 	return out, nil
 }
 
+// AddHost adds a new host to the inventory of all hosts. If the host
+// has no private key, the private key of the inventory is used
+func (srv *InvServer) AddHost(host *server.Host) error {
+	srv.Lock()
+	defer srv.Unlock()
+
+	if host.ID == server.LocalhostID || srv.AllHosts.find(host.ID) != nil {
+		return fmt.Errorf("Host already exists: %s", host.ID)
+	}
+	if host.KeyAuth == nil {
+		host.KeyAuth = srv.Cfg.PrivateKey
+	}
+	srv.AllHosts.add(srv.Session, host)
+	return nil
+}
+
+// RemoveHost removes a host from all inventories, and closes its
+// open sessions. A host that is the bastion of another host cannot be
+// removed
+func (srv *InvServer) RemoveHost(id string) error {
+	host, err := srv.removeHost(id)
+	if err != nil {
+		return err
+	}
+	// Sessions are closed after the inventory is unlocked, since
+	// closing them may block
+	host.CloseSessions()
+	return nil
+}
+
+func (srv *InvServer) removeHost(id string) (*server.Host, error) {
+	srv.Lock()
+	defer srv.Unlock()
+
+	host := srv.AllHosts.find(id)
+	if host == nil {
+		return nil, fmt.Errorf("Host not found: %s", id)
+	}
+	for _, x := range srv.AllHosts.hosts {
+		if x.Bastion == host {
+			return nil, fmt.Errorf("Host %s is the bastion of %s", id, x.ID)
+		}
+	}
+	for _, set := range srv.Sets {
+		hosts := make([]*server.Host, 0, len(set.hosts))
+		for _, x := range set.hosts {
+			if x.ID != id {
+				hosts = append(hosts, x)
+			}
+		}
+		set.hosts = hosts
+	}
+	return host, nil
+}
+
+// SetLabels replaces the labels of a host, and returns a copy of the
+// host information
+func (srv *InvServer) SetLabels(id string, labels []string) (*pb.HostInfo, error) {
+	srv.Lock()
+	defer srv.Unlock()
+
+	host := srv.AllHosts.find(id)
+	if host == nil {
+		return nil, fmt.Errorf("Host not found: %s", id)
+	}
+	host.SetLabels(labels)
+	info := host.GetInfo()
+	return &info, nil
+}
+
+// SetProperties sets the properties of a host. If replace is true,
+// existing properties are removed. Returns a copy of the host
+// information
+func (srv *InvServer) SetProperties(id string, properties map[string]string, replace bool) (*pb.HostInfo, error) {
+	srv.Lock()
+	defer srv.Unlock()
+
+	host := srv.AllHosts.find(id)
+	if host == nil {
+		return nil, fmt.Errorf("Host not found: %s", id)
+	}
+	host.SetProperties(properties, replace)
+	info := host.GetInfo()
+	return &info, nil
+}
+
 // Release notifies the server that the inventory id is no longer in use
 func (srv *InvServer) Release(id string) {
 	srv.Lock()
@@ -0,0 +1,95 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+// NewHost builds a host from a host description, validates it, and
+// creates its backend. The ssh bastion of the host is looked up in
+// inv
+func NewHost(req *pb.NewHost, inv server.InternalInventory) (*server.Host, error) {
+	if req == nil {
+		return nil, errors.New("Host required")
+	}
+	host := &server.Host{HostInfo: pb.HostInfo{ID: req.ID,
+		Labels:     append([]string{}, req.Labels...),
+		Properties: make(map[string]string)}}
+	for k, v := range req.Properties {
+		host.Properties[k] = v
+	}
+	if req.SSH != nil {
+		if len(req.SSH.Hostname) == 0 {
+			return nil, errors.New("Empty hostname")
+		}
+		if req.SSH.Port < 0 || req.SSH.Port > 65535 {
+			return nil, fmt.Errorf("Invalid port: %d", req.SSH.Port)
+		}
+		if len(req.SSH.Become) > 0 && req.SSH.Become != "sudo" {
+			return nil, fmt.Errorf("Unsupported become method: %s", req.SSH.Become)
+		}
+		host.Hostname = req.SSH.Hostname
+		host.Network = req.SSH.Network
+		host.Port = int(req.SSH.Port)
+		host.LoginUser = req.SSH.User
+		host.LoginPassword = req.SSH.Password
+		server.AddSecret(host.LoginPassword)
+		host.Become = req.SSH.Become
+		if len(req.SSH.Bastion) > 0 {
+			if inv == nil {
+				return nil, fmt.Errorf("Bastion %s of %s is not defined", req.SSH.Bastion, req.ID)
+			}
+			bastion, err := inv.GetHost([]string{req.SSH.Bastion})
+			if err != nil || len(bastion) == 0 {
+				return nil, fmt.Errorf("Bastion %s of %s is not defined", req.SSH.Bastion, req.ID)
+			}
+			host.Bastion = bastion[0]
+		}
+	}
+	if len(req.Configuration) > 0 {
+		if err := json.Unmarshal(req.Configuration, &host.Configuration); err != nil {
+			return nil, fmt.Errorf("Invalid configuration for %s: %v", req.ID, err)
+		}
+	}
+	host.BackendName = req.Backend
+	if len(host.BackendName) == 0 {
+		host.BackendName = "linux"
+	}
+	host.Wrapper = req.Wrapper
+	if host.BackendName == "exec" && len(host.Wrapper) == 0 {
+		return nil, fmt.Errorf("Wrapper required for exec backend in %s", req.ID)
+	}
+	host.Defaults()
+	if len(host.ID) == 0 {
+		return nil, errors.New("Host ID required")
+	}
+	for _, a := range req.Addresses {
+		ip := net.ParseIP(a.Address)
+		if ip == nil {
+			return nil, fmt.Errorf("Cannot parse address %s", a.Address)
+		}
+		name := a.Name
+		if len(name) == 0 {
+			name = server.Primary
+		}
+		host.Addresses = append(host.Addresses, &pb.Address{Name: name, Address: ip.String()})
+	}
+	if len(host.Addresses) == 0 && len(host.Hostname) > 0 {
+		if err := host.DiscoverIPs(); err != nil {
+			return nil, err
+		}
+	}
+	if host.BackendName == "linux" && len(host.Hostname) == 0 {
+		return nil, fmt.Errorf("SSH hostname required for %s", req.ID)
+	}
+	host.Backend = server.GetBackend(host.BackendName, host)
+	if host.Backend == nil {
+		return nil, fmt.Errorf("Unknown backend %s for %s", host.BackendName, host.ID)
+	}
+	return host, nil
+}
@@ -0,0 +1,151 @@
+// Package script loads the inventory from an executable. The
+// executable is run with the --list argument, and it should print the
+// inventory as a JSON document to stdout. The document has the same
+// structure as the YAML inventory:
+//
+//	{
+//	  "hosts": [
+//	     {"id": "web1", "address": "10.0.0.1", "labels": ["web"], "properties": {"dc": "east"}}
+//	  ],
+//	  "labels": { "db": ["db1"] },
+//	  "configuration": { ... }
+//	}
+//
+// The output of the executable is cached for CacheTTL, so repeated
+// runs do not query the inventory sources every time. Outputs
+// containing passwords or other secrets are not cached.
+//
+// The loader is registered as "exec", so an inventory executable is
+// run only if it is given as exec:location.
+package script
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/inventory/yml"
+)
+
+// CacheTTL is how long the output of an inventory executable is
+// reused. If zero, the executable is run every time
+var CacheTTL = 5 * time.Minute
+
+// CacheDir is the directory the inventory outputs are cached. If
+// empty, the user cache directory is used
+var CacheDir string
+
+func init() {
+	server.RegisterInventoryLoader("exec", LoadInventory)
+}
+
+// LoadInventory runs the executable and loads the inventory from its
+// output, or from the cache if the cached output is recent enough
+func LoadInventory(executable string) (server.InventoryConfiguration, []*server.Host, map[string]interface{}, error) {
+	data, cached, err := getInventory(executable)
+	if err != nil {
+		return server.InventoryConfiguration{}, nil, nil, err
+	}
+	cfg, hosts, config, err := yml.ParseInventory(data)
+	if err != nil {
+		return cfg, hosts, config, fmt.Errorf("Invalid inventory from %s: %v", executable, err)
+	}
+	if len(cached) > 0 {
+		if hasSecrets(data, cfg, hosts) {
+			log.Debugf("Inventory contains secrets, not caching it")
+			os.Remove(cached)
+		} else {
+			writeCache(cached, data)
+		}
+	}
+	return cfg, hosts, config, nil
+}
+
+// hasSecrets returns true if the inventory has passwords, or
+// contains any known secret values
+func hasSecrets(data []byte, cfg server.InventoryConfiguration, hosts []*server.Host) bool {
+	if cfg.PrivateKey != nil && len(cfg.PrivateKey.Passphrase) > 0 {
+		return true
+	}
+	for _, h := range hosts {
+		if len(h.LoginPassword) > 0 {
+			return true
+		}
+	}
+	return server.Redact(string(data)) != string(data)
+}
+
+// IsExecutable returns true if the file is a regular file that can be
+// executed
+func IsExecutable(file string) bool {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	return fi.Mode().IsRegular() && fi.Mode()&0111 != 0
+}
+
+// cacheFile returns the name of the cache file for the executable
+func cacheFile(executable string) (string, error) {
+	abs, err := filepath.Abs(executable)
+	if err != nil {
+		return "", err
+	}
+	dir := CacheDir
+	if len(dir) == 0 {
+		d, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(d, "watermelon", "inventory")
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// getInventory returns the cached output if it is recent enough, or
+// runs the executable. If the executable is run, also returns the
+// cache file the output should be written to
+func getInventory(executable string) ([]byte, string, error) {
+	var cached string
+	if CacheTTL > 0 {
+		var err error
+		cached, err = cacheFile(executable)
+		if err != nil {
+			log.Debugf("Cannot use inventory cache: %v", err)
+		} else if fi, err := os.Stat(cached); err == nil && time.Since(fi.ModTime()) < CacheTTL {
+			if data, err := ioutil.ReadFile(cached); err == nil {
+				log.Debugf("Using cached inventory %s", cached)
+				return data, "", nil
+			}
+		}
+	}
+	log.Debugf("Running inventory executable %s", executable)
+	out := bytes.Buffer{}
+	errout := bytes.Buffer{}
+	cmd := exec.Command(executable, "--list")
+	cmd.Stdout = &out
+	cmd.Stderr = &errout
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("Inventory executable %s failed: %v %s", executable, err, strings.TrimSpace(errout.String()))
+	}
+	return out.Bytes(), cached, nil
+}
+
+func writeCache(cached string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(cached), 0700); err != nil {
+		log.Debugf("Cannot create inventory cache dir: %v", err)
+	} else if err := ioutil.WriteFile(cached, data, 0600); err != nil {
+		log.Debugf("Cannot write inventory cache: %v", err)
+	}
+}
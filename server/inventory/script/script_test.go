@@ -0,0 +1,88 @@
+package script
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/bserdar/watermelon/server/backends/remotelinux"
+)
+
+const inventoryScript = `#!/bin/sh
+[ "$1" = "--list" ] || exit 1
+echo run >> "$(dirname "$0")/runs"
+echo '{"hosts": [{"id": "h1", "address": "127.0.0.2", "labels": ["web"], "properties": {"dc": "east"}},
+                 {"id": "h2", "address": "127.0.0.3"}],
+       "labels": {"db": ["h2"]},
+       "configuration": {"key": "value"}}'
+`
+
+func TestScriptInventory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scriptinv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	CacheDir = filepath.Join(dir, "cache")
+	exe := filepath.Join(dir, "inv")
+	ioutil.WriteFile(exe, []byte(inventoryScript), 0755)
+	if !IsExecutable(exe) {
+		t.Errorf("Expecting executable")
+	}
+
+	runs := func() int {
+		data, _ := ioutil.ReadFile(filepath.Join(dir, "runs"))
+		return len(data) / len("run\n")
+	}
+	_, hosts, config, err := LoadInventory(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 || hosts[0].Properties["dc"] != "east" || hosts[1].Labels[0] != "db" || config["key"] != "value" {
+		t.Errorf("Wrong inventory: %v %v", hosts, config)
+	}
+	if _, _, _, err := LoadInventory(exe); err != nil || runs() != 1 {
+		t.Errorf("Expecting cached inventory: %d runs, %v", runs(), err)
+	}
+
+	// Expire the cache
+	cached, _ := cacheFile(exe)
+	old := time.Now().Add(-2 * CacheTTL)
+	os.Chtimes(cached, old, old)
+	if _, _, _, err := LoadInventory(exe); err != nil || runs() != 2 {
+		t.Errorf("Expecting new run: %d runs, %v", runs(), err)
+	}
+
+	ioutil.WriteFile(exe, []byte("#!/bin/sh\necho failed >&2\nexit 1\n"), 0755)
+	CacheTTL = 0
+	if _, _, _, err := LoadInventory(exe); err == nil {
+		t.Errorf("Expecting error")
+	}
+}
+
+func TestScriptInventorySecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scriptinv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	CacheDir = filepath.Join(dir, "cache")
+	CacheTTL = time.Minute
+	exe := filepath.Join(dir, "inv")
+	ioutil.WriteFile(exe, []byte(`#!/bin/sh
+echo '{"hosts": [{"id": "h1", "address": "127.0.0.2", "ssh": {"hostname": "h1", "password": "secretpw"}}]}'
+`), 0755)
+	_, hosts, _, err := LoadInventory(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 1 || hosts[0].LoginPassword != "secretpw" {
+		t.Errorf("Wrong inventory: %v", hosts)
+	}
+	cached, _ := cacheFile(exe)
+	if _, err := os.Stat(cached); !os.IsNotExist(err) {
+		t.Errorf("Inventory with secrets cached: %v", err)
+	}
+}
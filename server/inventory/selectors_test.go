@@ -0,0 +1,101 @@
+package inventory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+func TestPatternSelectors(t *testing.T) {
+	hosts := []*server.Host{
+		{HostInfo: pb.HostInfo{ID: "web-1", Addresses: []*pb.Address{{Name: server.Primary, Address: "10.20.1.5"}},
+			Properties: map[string]string{"os": "rhel8", "rack": "a1"}}},
+		{HostInfo: pb.HostInfo{ID: "web-2", Addresses: []*pb.Address{{Name: server.Primary, Address: "10.30.0.1"}, {Name: "internal", Address: "10.20.9.9"}},
+			Properties: map[string]string{"os": "ubuntu20"}}},
+		{HostInfo: pb.HostInfo{ID: "db-1", Addresses: []*pb.Address{{Name: server.Primary, Address: "fd00::1"}},
+			Properties: map[string]string{"os": "rhel7", "rack": "b2"}}},
+		{HostInfo: pb.HostInfo{ID: "db-2"}},
+	}
+	srv := NewInvServer(hosts, server.InventoryConfiguration{}, nil)
+	sel := func(s *pb.Selector) string {
+		id, err := srv.Select(server.AllHosts, s)
+		if err != nil {
+			t.Errorf("%v: %v", s, err)
+			return ""
+		}
+		ids, _ := srv.GetHostIDs(id)
+		return strings.Join(ids, " ")
+	}
+	for _, tc := range []struct {
+		sel      *pb.Selector
+		expected string
+	}{
+		{&pb.Selector{Select: &pb.Selector_IDGlob{IDGlob: &pb.PatternSet{Patterns: []string{"web-*"}}}}, "web-1 web-2"},
+		{&pb.Selector{Select: &pb.Selector_IDGlob{IDGlob: &pb.PatternSet{Patterns: []string{"db-[2-9]", "web-1"}}}}, "db-2 web-1"},
+		{&pb.Selector{Select: &pb.Selector_IDRegex{IDRegex: &pb.PatternSet{Patterns: []string{"^db-\\d$"}}}}, "db-1 db-2"},
+		{&pb.Selector{Select: &pb.Selector_InCIDR{InCIDR: &pb.CIDRSet{CIDRs: []string{"10.20.0.0/16"}}}}, "web-1 web-2"},
+		{&pb.Selector{Select: &pb.Selector_InCIDR{InCIDR: &pb.CIDRSet{CIDRs: []string{"10.30.0.0/24", "fd00::/8"}}}}, "db-1 web-2"},
+		{&pb.Selector{Select: &pb.Selector_HasPropertyRegex{HasPropertyRegex: &pb.PropertyRegex{Key: "os", Regex: "^rhel"}}}, "db-1 web-1"},
+		{&pb.Selector{Select: &pb.Selector_HasProperties{HasProperties: &pb.PropertyKeys{Keys: []string{"os", "rack"}}}}, "db-1 web-1"},
+		{&pb.Selector{Select: &pb.Selector_Not{Not: &pb.Selector{Select: &pb.Selector_HasProperties{HasProperties: &pb.PropertyKeys{Keys: []string{"os"}}}}}}, "db-2"},
+		{&pb.Selector{Select: &pb.Selector_And{}}, "db-1 db-2 web-1 web-2"},
+	} {
+		if x := sel(tc.sel); x != tc.expected {
+			t.Errorf("%v: expected %s got %s", tc.sel, tc.expected, x)
+		}
+	}
+
+	for _, bad := range []*pb.Selector{
+		{Select: &pb.Selector_IDGlob{IDGlob: &pb.PatternSet{Patterns: []string{"web-["}}}},
+		{Select: &pb.Selector_IDRegex{IDRegex: &pb.PatternSet{Patterns: []string{"web-("}}}},
+		{Select: &pb.Selector_InCIDR{InCIDR: &pb.CIDRSet{CIDRs: []string{"10.20.0.0"}}}},
+		{Select: &pb.Selector_Or{Or: &pb.SelectorList{Selectors: []*pb.Selector{{Select: &pb.Selector_HasPropertyRegex{HasPropertyRegex: &pb.PropertyRegex{Key: "os", Regex: "["}}}}}}},
+		nil,
+		{},
+		{Select: &pb.Selector_Not{}},
+		{Select: &pb.Selector_HasPropertyRegex{}},
+		{Select: &pb.Selector_Or{Or: &pb.SelectorList{Selectors: []*pb.Selector{nil}}}},
+	} {
+		if _, err := srv.Select(server.AllHosts, bad); err == nil {
+			t.Errorf("Expecting error for %v", bad)
+		}
+		if _, err := IsMatch(bad, &hosts[0].HostInfo); err == nil {
+			t.Errorf("Expecting match error for %v", bad)
+		}
+	}
+}
+
+func TestConcurrentUpdate(t *testing.T) {
+	hosts := []*server.Host{
+		{HostInfo: pb.HostInfo{ID: "h1", Labels: []string{"web"}, Properties: map[string]string{"os": "rhel8"}}},
+		{HostInfo: pb.HostInfo{ID: "h2", Labels: []string{"db"}, Properties: map[string]string{"os": "rhel7"}}},
+	}
+	srv := NewInvServer(hosts, server.InventoryConfiguration{}, nil)
+	sel := &pb.Selector{Select: &pb.Selector_HasPropertyRegex{HasPropertyRegex: &pb.PropertyRegex{Key: "os", Regex: "^rhel"}}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			srv.SetProperties("h1", map[string]string{"os": fmt.Sprintf("rhel%d", i)}, false)
+			srv.SetLabels("h2", []string{"db", fmt.Sprint(i)})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		id, err := srv.Select(server.AllHosts, sel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids, _ := srv.GetHostIDs(id); len(ids) != 2 {
+			t.Errorf("Wrong selection: %v", ids)
+		}
+		info, _ := srv.GetHostInfo([]string{"h1", "h2"})
+		proto.Marshal(info[0])
+		hosts[0].GetInfo()
+	}
+	<-done
+}
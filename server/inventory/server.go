@@ -127,14 +127,61 @@ func (s srv) Release(ctx context.Context, req *pb.InvIdRequest) (*pb.Empty, erro
 	return &pb.Empty{}, nil
 }
 
+// AddHost adds a new host to the inventory of all hosts
+func (s srv) AddHost(ctx context.Context, req *pb.InvAddHostRequest) (*pb.HostInfo, error) {
+	session := server.GetSession(req.Session)
+	if session == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	host, err := NewHost(req.Host, session.GetInv())
+	if err != nil {
+		return nil, err
+	}
+	if err := session.GetInv().AddHost(host); err != nil {
+		return nil, err
+	}
+	info := host.GetInfo()
+	return &info, nil
+}
+
+// RemoveHost removes a host from all inventories
+func (s srv) RemoveHost(ctx context.Context, req *pb.InvRemoveHostRequest) (*pb.Empty, error) {
+	session := server.GetSession(req.Session)
+	if session == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	if err := session.GetInv().RemoveHost(req.HostId); err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+// SetLabels replaces the labels of a host
+func (s srv) SetLabels(ctx context.Context, req *pb.InvSetLabelsRequest) (*pb.HostInfo, error) {
+	session := server.GetSession(req.Session)
+	if session == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	return session.GetInv().SetLabels(req.HostId, req.Labels)
+}
+
+// SetProperties sets the properties of a host
+func (s srv) SetProperties(ctx context.Context, req *pb.InvSetPropertiesRequest) (*pb.HostInfo, error) {
+	session := server.GetSession(req.Session)
+	if session == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	return session.GetInv().SetProperties(req.HostId, req.Properties, req.Replace)
+}
+
 // NewServer returns a new inventory grpc server
 func NewServer() pb.InventoryServer {
 	return srv{}
 }
 
 func isMatch(in *pb.PropertySet_KVS, h *pb.HostInfo) bool {
-	if v, ok := h.Properties[in.Key]; ok {
-		for _, x := range in.Values {
+	if v, ok := h.Properties[in.GetKey()]; ok {
+		for _, x := range in.GetValues() {
 			if x == v {
 				return true
 			}
@@ -143,50 +190,17 @@ func isMatch(in *pb.PropertySet_KVS, h *pb.HostInfo) bool {
 	return false
 }
 
-func IsMatch(s *pb.Selector, h *pb.HostInfo) bool {
-	switch k := s.Select.(type) {
-	case *pb.Selector_HasAllLabels:
-		for _, r := range k.HasAllLabels.Labels {
-			if !server.HasLabel(h, r) {
-				return false
-			}
-		}
-		return true
-	case *pb.Selector_HasAnyLabel:
-		for _, r := range k.HasAnyLabel.Labels {
-			if server.HasLabel(h, r) {
-				return true
-			}
-		}
-		return false
-	case *pb.Selector_HasNoneLabels:
-		for _, r := range k.HasNoneLabels.Labels {
-			if server.HasLabel(h, r) {
-				return false
-			}
-		}
-		return true
-	case *pb.Selector_ByID:
-		for _, r := range k.ByID.IDs {
-			if h.ID == r {
-				return true
-			}
-		}
-		return false
-	case *pb.Selector_HasAnyProperty:
-		for _, p := range k.HasAnyProperty.Properties {
-			if isMatch(p, h) {
-				return true
-			}
-		}
-		return false
-	case *pb.Selector_HasAllProperty:
-		for _, p := range k.HasAllProperty.Properties {
-			if !isMatch(p, h) {
-				return false
-			}
-		}
-		return true
+// Matcher is a compiled selector. It returns true if the host
+// matches the selector
+type Matcher func(*pb.HostInfo) bool
+
+// IsMatch returns true if the host matches the selector. Returns an
+// error if the selector is invalid. Use CompileSelector to match a
+// selector against many hosts
+func IsMatch(s *pb.Selector, h *pb.HostInfo) (bool, error) {
+	m, err := CompileSelector(s)
+	if err != nil {
+		return false, err
 	}
-	return false
+	return m(h), nil
 }
@@ -0,0 +1,159 @@
+package yml
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Host IDs can contain range patterns that expand into multiple
+// hosts. [01:40] expands to 01, 02, ..., 40 keeping the width of the
+// start value, [1:40:2] expands to 1, 3, ..., 39, and [a:f] expands to
+// a, b, ..., f. If there are multiple ranges, all combinations are
+// expanded.
+//
+// In an expanded host, {id} is replaced with the host ID, {index} is
+// replaced with the value of the first range, and {index1}, {index2},
+// ... are replaced with the value of the corresponding range, in the
+// ssh hostname and bastion, addresses, and property values. The
+// values are zero padded the same way as the ID. {num}, {num1},
+// {num2}, ... are the values without the padding, e.g. for
+// addresses.
+
+var rangePattern = regexp.MustCompile(`\[([0-9]+|[a-zA-Z]):([0-9]+|[a-zA-Z])(?::([0-9]+))?\]`)
+
+// maxExpansion limits the number of hosts a single pattern can expand
+// to, to catch typos such as [1:100000]
+const maxExpansion = 10000
+
+// rangeValues returns the values of the range given by the submatches
+// of rangePattern
+func rangeValues(start, end, step string) ([]string, error) {
+	s := 1
+	if len(step) > 0 {
+		var err error
+		if s, err = strconv.Atoi(step); err != nil || s <= 0 {
+			return nil, fmt.Errorf("Invalid step: %s", step)
+		}
+	}
+	ret := make([]string, 0)
+	if x, err := strconv.Atoi(start); err == nil {
+		y, err := strconv.Atoi(end)
+		if err != nil || y < x {
+			return nil, fmt.Errorf("Invalid range: [%s:%s]", start, end)
+		}
+		if (y-x)/s >= maxExpansion {
+			return nil, fmt.Errorf("Range too large: [%s:%s]", start, end)
+		}
+		format := "%d"
+		if len(start) > 1 && start[0] == '0' {
+			format = fmt.Sprintf("%%0%dd", len(start))
+		}
+		for i := x; i <= y; i += s {
+			ret = append(ret, fmt.Sprintf(format, i))
+		}
+		return ret, nil
+	}
+	if len(end) != 1 || end[0] < start[0] || (end[0] <= 'Z') != (start[0] <= 'Z') {
+		return nil, fmt.Errorf("Invalid range: [%s:%s]", start, end)
+	}
+	for c := int(start[0]); c <= int(end[0]); c += s {
+		ret = append(ret, string(rune(c)))
+	}
+	return ret, nil
+}
+
+// unpadded returns the range value without the leading zeros
+func unpadded(v string) string {
+	if x := strings.TrimLeft(v, "0"); len(x) < len(v) {
+		if len(x) == 0 {
+			return "0"
+		}
+		return x
+	}
+	return v
+}
+
+// expandPattern expands the ranges in pattern. Returns the expanded
+// strings, and the range values used for each
+func expandPattern(pattern string) ([]string, [][]string, error) {
+	loc := rangePattern.FindStringSubmatchIndex(pattern)
+	if loc == nil {
+		return []string{pattern}, [][]string{nil}, nil
+	}
+	sub := func(i int) string {
+		if loc[2*i] < 0 {
+			return ""
+		}
+		return pattern[loc[2*i]:loc[2*i+1]]
+	}
+	values, err := rangeValues(sub(1), sub(2), sub(3))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", pattern, err)
+	}
+	rest, restIndexes, err := expandPattern(pattern[loc[1]:])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values)*len(rest) > maxExpansion {
+		return nil, nil, fmt.Errorf("%s: Too many hosts", pattern)
+	}
+	ret := make([]string, 0, len(values)*len(rest))
+	indexes := make([][]string, 0, len(values)*len(rest))
+	for _, v := range values {
+		for i, r := range rest {
+			ret = append(ret, pattern[:loc[0]]+v+r)
+			indexes = append(indexes, append([]string{v}, restIndexes[i]...))
+		}
+	}
+	return ret, indexes, nil
+}
+
+// expand returns the hosts described by h. If the host ID does not
+// contain range patterns, returns h
+func (h Host) expand() ([]Host, error) {
+	ids, indexes, err := expandPattern(h.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 1 && indexes[0] == nil {
+		return []Host{h}, nil
+	}
+	ret := make([]Host, 0, len(ids))
+	for i, id := range ids {
+		pairs := []string{"{id}", id}
+		for n, v := range indexes[i] {
+			num := unpadded(v)
+			if n == 0 {
+				pairs = append(pairs, "{index}", v, "{num}", num)
+			}
+			pairs = append(pairs, fmt.Sprintf("{index%d}", n+1), v, fmt.Sprintf("{num%d}", n+1), num)
+		}
+		r := strings.NewReplacer(pairs...)
+		host := h
+		host.ID = id
+		host.Address = r.Replace(h.Address)
+		if h.SSH != nil {
+			ssh := *h.SSH
+			ssh.Hostname = r.Replace(ssh.Hostname)
+			ssh.Bastion = r.Replace(ssh.Bastion)
+			host.SSH = &ssh
+		}
+		if h.Properties != nil {
+			host.Properties = make(map[string]string, len(h.Properties))
+			for k, v := range h.Properties {
+				host.Properties[k] = r.Replace(v)
+			}
+		}
+		if h.Labels != nil {
+			host.Labels = append([]string{}, h.Labels...)
+		}
+		host.Addresses = append(host.Addresses[:0:0], h.Addresses...)
+		for x := range host.Addresses {
+			host.Addresses[x].Address = r.Replace(host.Addresses[x].Address)
+		}
+		ret = append(ret, host)
+	}
+	return ret, nil
+}
@@ -0,0 +1,199 @@
+package yml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// Group defines a group of hosts. A group contains the hosts listed
+// in it, and all the hosts of its child groups.
+type Group struct {
+	Children      []string          `yaml:"children,omitempty"`
+	Hosts         []string          `yaml:"hosts,omitempty"`
+	Properties    map[string]string `yaml:"properties,omitempty"`
+	Configuration interface{}       `yaml:"configuration,omitempty"`
+}
+
+// checkGroups makes sure all child groups are defined and there are
+// no cycles. Returns false if there are problems
+func (i *Inventory) checkGroups(report func(err error, path ...interface{})) bool {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	ok := true
+	state := map[string]int{}
+	var visit func(name string, path []string)
+	visit = func(name string, path []string) {
+		path = append(path, name)
+		switch state[name] {
+		case visiting:
+			report(fmt.Errorf("Group cycle: %s", strings.Join(path, " -> ")), "groups", path[0], "children")
+			ok = false
+			return
+		case done:
+			return
+		}
+		state[name] = visiting
+		for _, child := range i.Groups[name].Children {
+			if _, defined := i.Groups[child]; !defined {
+				report(fmt.Errorf("Group %s has undefined child group %s", name, child), "groups", name, "children")
+				ok = false
+				continue
+			}
+			visit(child, path)
+		}
+		state[name] = done
+	}
+	for _, name := range i.groupNames() {
+		visit(name, nil)
+	}
+	return ok
+}
+
+// readGroupOrder records the order of the groups in the inventory
+// document
+func (i *Inventory) readGroupOrder(data []byte) {
+	var doc struct {
+		Groups yaml.Node `yaml:"groups"`
+	}
+	if yaml.Unmarshal(data, &doc) != nil || doc.Groups.Kind != yaml.MappingNode {
+		return
+	}
+	i.groupOrder = make([]string, 0, len(doc.Groups.Content)/2)
+	for n := 0; n+1 < len(doc.Groups.Content); n += 2 {
+		i.groupOrder = append(i.groupOrder, doc.Groups.Content[n].Value)
+	}
+}
+
+// groupNames returns the group names in the order they are defined in
+// the inventory. Groups whose order is not known come after them,
+// sorted by name
+func (i *Inventory) groupNames() []string {
+	ret := make([]string, 0, len(i.Groups))
+	seen := make(map[string]bool, len(i.Groups))
+	for _, name := range i.groupOrder {
+		if _, ok := i.Groups[name]; ok && !seen[name] {
+			seen[name] = true
+			ret = append(ret, name)
+		}
+	}
+	rest := make([]string, 0)
+	for k := range i.Groups {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(ret, rest...)
+}
+
+// hostGroups returns the groups of a host ordered from the highest
+// precedence to the lowest. Groups closer to the host have higher
+// precedence. If two groups are at the same distance from the host,
+// the group defined later in the inventory has higher precedence.
+func (i *Inventory) hostGroups(hostID string, groupHosts, parents map[string][]string) []string {
+	order := map[string]int{}
+	for n, name := range i.groupNames() {
+		order[name] = n
+	}
+	dist := map[string]int{}
+	queue := make([]string, 0)
+	for _, name := range i.groupNames() {
+		if server.ArrayContains(groupHosts[name], hostID) {
+			dist[name] = 0
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		for _, p := range parents[g] {
+			if _, ok := dist[p]; !ok {
+				dist[p] = dist[g] + 1
+				queue = append(queue, p)
+			}
+		}
+	}
+	ret := make([]string, 0, len(dist))
+	for k := range dist {
+		ret = append(ret, k)
+	}
+	sort.Slice(ret, func(a, b int) bool {
+		if dist[ret[a]] != dist[ret[b]] {
+			return dist[ret[a]] < dist[ret[b]]
+		}
+		return order[ret[a]] > order[ret[b]]
+	})
+	return ret
+}
+
+// applyGroups adds group names to host labels, and sets the group
+// properties and configuration of the hosts. Host properties
+// override group properties. References to the invalid hosts are not
+// reported.
+func (i *Inventory) applyGroups(hosts []*server.Host, invalid map[string]bool, report func(err error, path ...interface{})) {
+	if len(i.Groups) == 0 {
+		return
+	}
+	if !i.checkGroups(report) {
+		return
+	}
+	parents := map[string][]string{}
+	groupHosts := map[string][]string{}
+	for _, name := range i.groupNames() {
+		for _, child := range i.Groups[name].Children {
+			parents[child] = append(parents[child], name)
+		}
+		for j, pattern := range i.Groups[name].Hosts {
+			ids, _, err := expandPattern(pattern)
+			if err != nil {
+				report(err, "groups", name, "hosts", j)
+				continue
+			}
+			for _, h := range ids {
+				found := false
+				for _, host := range hosts {
+					if host.ID == h {
+						found = true
+						break
+					}
+				}
+				if !found && !invalid[h] {
+					report(fmt.Errorf("Host %s is referenced in group %s, but it is not defined", h, name), "groups", name, "hosts", j)
+				}
+			}
+			groupHosts[name] = append(groupHosts[name], ids...)
+		}
+	}
+
+	for _, host := range hosts {
+		groups := i.hostGroups(host.ID, groupHosts, parents)
+		if len(groups) == 0 {
+			continue
+		}
+		host.Groups = groups
+		host.GroupConfiguration = make([]interface{}, 0, len(groups))
+		properties := map[string]string{}
+		for x := len(groups) - 1; x >= 0; x-- {
+			for k, v := range i.Groups[groups[x]].Properties {
+				properties[k] = v
+			}
+		}
+		for k, v := range host.Properties {
+			properties[k] = v
+		}
+		host.Properties = properties
+		for _, g := range groups {
+			if !server.ArrayContains(host.Labels, g) {
+				host.Labels = append(host.Labels, g)
+			}
+			host.GroupConfiguration = append(host.GroupConfiguration, server.MapYaml(i.Groups[g].Configuration))
+		}
+	}
+}
@@ -1,17 +1,20 @@
 package yml
 
 import (
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"path/filepath"
+	"sort"
 	"strings"
 
-	yaml "gopkg.in/yaml.v2"
+	yaml "gopkg.in/yaml.v3"
 
 	"github.com/bserdar/watermelon/server"
 	"github.com/bserdar/watermelon/server/pb"
+	"github.com/bserdar/watermelon/server/session"
 	sshdial "github.com/bserdar/watermelon/server/ssh"
+	"github.com/bserdar/watermelon/server/vault"
 )
 
 // Inventory contains the hosts and labels as parsed from YML
@@ -21,6 +24,17 @@ type Inventory struct {
 	Configuration  interface{}         `yaml:"configuration,omitempty"`
 	Hosts          []Host              `yaml:"hosts,omitempty"`
 	Labels         map[string][]string `yaml:"labels,omitempty"`
+	Groups         map[string]Group    `yaml:"groups,omitempty"`
+	Localhost      *Localhost          `yaml:"localhost,omitempty"`
+
+	// groupOrder is the order the groups are defined in the document
+	groupOrder []string
+}
+
+// Localhost configures how commands run on localhost
+type Localhost struct {
+	// Become is the become method for localhost, e.g. sudo
+	Become string `yaml:"become,omitempty"`
 }
 
 // SSH specifics
@@ -31,6 +45,8 @@ type SSH struct {
 	User     string `yaml:"user,omitempty"`
 	Password string `yaml:"password,omitempty"`
 	Become   string `yaml:"become,omitempty"`
+	// Bastion is the ID of the host used to reach this host
+	Bastion string `yaml:"bastion,omitempty"`
 }
 
 // Host defines a YAML host
@@ -46,6 +62,10 @@ type Host struct {
 	} `yaml:"addresses"`
 	SSH           *SSH        `yaml:"ssh,omitempty"`
 	Configuration interface{} `yaml:"configuration,omitempty"`
+	// The backend used to reach the host. Default is linux
+	Backend string `yaml:"backend,omitempty"`
+	// The wrapper command template for the exec backend
+	Wrapper string `yaml:"wrapper,omitempty"`
 }
 
 func (h *Host) toHost() (*server.Host, error) {
@@ -55,19 +75,31 @@ func (h *Host) toHost() (*server.Host, error) {
 	if h.SSH != nil {
 		host.Hostname = h.SSH.Hostname
 		if len(h.SSH.Hostname) == 0 {
-			return nil, errors.New("Empty hostname")
+			return nil, fmt.Errorf("Empty hostname for %s", h.ID)
 		}
 		host.Network = h.SSH.Network
 		host.Port = h.SSH.Port
 		host.LoginUser = h.SSH.User
 		host.LoginPassword = h.SSH.Password
+		server.AddSecret(host.LoginPassword)
 		host.Become = h.SSH.Become
 	}
 	host.Configuration = server.MapYaml(h.Configuration)
+	host.BackendName = h.Backend
+	if len(host.BackendName) == 0 {
+		host.BackendName = "linux"
+	}
+	host.Wrapper = h.Wrapper
+	if host.BackendName == "exec" && len(h.Wrapper) == 0 {
+		return nil, fmt.Errorf("Wrapper required for exec backend in %s", h.ID)
+	}
 
 	host.Defaults()
 	if len(h.Address) == 0 {
-		if len(h.Addresses) == 0 {
+		if len(h.Addresses) == 0 && len(host.Hostname) == 0 {
+			// Nothing to discover. Hosts that are not reached by
+			// hostname (e.g. containers) may not have an address
+		} else if len(h.Addresses) == 0 {
 			// Need to discover addresses
 			err := host.DiscoverIPs()
 			if err != nil {
@@ -100,17 +132,26 @@ func (h *Host) toHost() (*server.Host, error) {
 	return host, nil
 }
 
-// ToInventory converts the YAML inventory to a host array
+// ToInventory converts the YAML inventory to a host array. Returns the
+// first problem found in the inventory
 func (i *Inventory) ToInventory() ([]*server.Host, error) {
-	ret := make([]*server.Host, 0, len(i.Hosts))
-	for _, h := range i.Hosts {
-		host, err := h.toHost()
-		if err != nil {
-			return nil, err
+	var first error
+	ret := i.toInventory(func(err error, path ...interface{}) {
+		if first == nil {
+			first = err
 		}
-		ret = append(ret, host)
+	})
+	if first != nil {
+		return nil, first
 	}
-	errors := make([]string, 0)
+	return ret, nil
+}
+
+// toInventory converts the YAML inventory to a host array. All the
+// problems are passed to report with the path of the inventory item
+// they are about, e.g. "hosts", 2, "address"
+func (i *Inventory) toInventory(report func(err error, path ...interface{})) []*server.Host {
+	ret := make([]*server.Host, 0, len(i.Hosts))
 	find := func(id string) *server.Host {
 		for _, h := range ret {
 			if h.ID == id {
@@ -119,45 +160,161 @@ func (i *Inventory) ToInventory() ([]*server.Host, error) {
 		}
 		return nil
 	}
-	for k, l := range i.Labels {
-		for _, h := range l {
-			host := find(h)
-			if host == nil {
-				errors = append(errors, h)
+	// Index of the host definition of each host
+	index := map[string]int{}
+	bastions := map[string]string{}
+	// The hosts that cannot be converted are already reported, so
+	// references to them are not reported as undefined
+	invalid := map[string]bool{}
+	for n, h := range i.Hosts {
+		expanded, err := h.expand()
+		if err != nil {
+			report(err, "hosts", n, "id")
+			continue
+		}
+		for _, x := range expanded {
+			host, err := x.toHost()
+			if err != nil {
+				report(err, "hosts", n)
+				invalid[x.ID] = true
+				continue
+			}
+			if find(host.ID) != nil {
+				report(fmt.Errorf("Duplicate host: %s", host.ID), "hosts", n, "id")
+				continue
+			}
+			if x.SSH != nil && len(x.SSH.Bastion) > 0 {
+				bastions[host.ID] = x.SSH.Bastion
+			}
+			index[host.ID] = n
+			ret = append(ret, host)
+		}
+	}
+	for _, host := range ret {
+		id, ok := bastions[host.ID]
+		if !ok {
+			continue
+		}
+		if host.Bastion = find(id); host.Bastion == nil && !invalid[id] {
+			report(fmt.Errorf("Bastion %s of %s is not defined", id, host.ID), "hosts", index[host.ID], "ssh", "bastion")
+		}
+	}
+	for _, host := range ret {
+		// Report a cycle once, for the host with the smallest ID in it
+		chain := []string{host.ID}
+		for b := host.Bastion; b != nil && len(chain) <= len(ret); b = b.Bastion {
+			chain = append(chain, b.ID)
+			if b == host {
+				smallest := true
+				for _, x := range chain {
+					smallest = smallest && x >= host.ID
+				}
+				if smallest {
+					report(fmt.Errorf("Bastion cycle: %s", strings.Join(chain, " -> ")), "hosts", index[host.ID], "ssh", "bastion")
+				}
+				break
 			}
-			host.Labels = append(host.Labels, k)
 		}
 	}
-	if len(errors) > 0 {
-		return nil, fmt.Errorf("These hosts are referenced in the inventory, but they are not defined: %s", strings.Join(errors, ","))
+	for _, k := range sortedLabelNames(i.Labels) {
+		for j, pattern := range i.Labels[k] {
+			ids, _, err := expandPattern(pattern)
+			if err != nil {
+				report(err, "labels", k, j)
+				continue
+			}
+			for _, h := range ids {
+				host := find(h)
+				if host == nil {
+					if !invalid[h] {
+						report(fmt.Errorf("Host %s is referenced in label %s, but it is not defined", h, k), "labels", k, j)
+					}
+					continue
+				}
+				host.Labels = append(host.Labels, k)
+			}
+		}
 	}
+	i.applyGroups(ret, invalid, report)
 
 	for _, x := range ret {
-		x.Backend = server.GetBackend("linux", x)
+		x.Backend = server.GetBackend(x.BackendName, x)
+		if x.Backend == nil {
+			report(fmt.Errorf("Unknown backend %s for %s", x.BackendName, x.ID), "hosts", index[x.ID], "backend")
+		}
 	}
-	return ret, nil
+	return ret
 }
 
-// LoadInventory loads inventory from a yaml file
+func sortedLabelNames(labels map[string][]string) []string {
+	ret := make([]string, 0, len(labels))
+	for k := range labels {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func init() {
+	server.RegisterInventoryLoader("yaml", LoadInventory)
+}
+
+// LoadInventory loads inventory from a yaml file. The relative file
+// paths of the valueFrom references in it are relative to the
+// directory of the file
 func LoadInventory(f string) (server.InventoryConfiguration, []*server.Host, map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return server.InventoryConfiguration{}, nil, nil, err
+	}
+	cfg, hosts, configuration, err := ParseInventory(data)
+	dir := filepath.Dir(f)
+	for _, host := range hosts {
+		host.Configuration = session.ResolvePaths(host.Configuration, dir)
+		for i, g := range host.GroupConfiguration {
+			host.GroupConfiguration[i] = session.ResolvePaths(g, dir)
+		}
+	}
+	if configuration != nil {
+		configuration = session.ResolvePaths(configuration, dir).(map[string]interface{})
+	}
+	return cfg, hosts, configuration, err
+}
+
+// ParseInventory parses an inventory document. Since JSON is also
+// YAML, the document can be JSON as well
+func ParseInventory(data []byte) (server.InventoryConfiguration, []*server.Host, map[string]interface{}, error) {
 	var inv Inventory
 	var cfg server.InventoryConfiguration
-	data, err := ioutil.ReadFile(f)
+	data, err := vault.DecryptDocument(data)
 	if err != nil {
 		return cfg, nil, nil, err
 	}
-
 	err = yaml.Unmarshal(data, &inv)
 	if err != nil {
 		return cfg, nil, nil, err
 	}
+	inv.readGroupOrder(data)
 	if len(inv.PrivateKeyFile) > 0 {
 		pk, err := ioutil.ReadFile(inv.PrivateKeyFile)
 		if err != nil {
 			return cfg, nil, nil, err
 		}
+		if pk, err = vault.Decrypt(pk); err != nil {
+			return cfg, nil, nil, err
+		}
+		server.AddSecret(inv.Passphrase)
 		cfg.PrivateKey = &sshdial.RawPrivateKey{PEMData: pk, Passphrase: inv.Passphrase}
 	}
+	if inv.Localhost != nil {
+		cfg.LocalhostBecome = inv.Localhost.Become
+	}
+	if len(inv.Groups) > 0 {
+		cfg.Groups = make(map[string][]string, len(inv.Groups))
+		for name, g := range inv.Groups {
+			cfg.Groups[name] = g.Children
+		}
+	}
 	hi, err := inv.ToInventory()
 	if cfg.PrivateKey != nil {
 		for _, host := range hi {
@@ -1,11 +1,13 @@
 package yml
 
 import (
+	"strings"
 	"testing"
 
-	yml "gopkg.in/yaml.v2"
+	yml "gopkg.in/yaml.v3"
 
 	"github.com/bserdar/watermelon/server"
+	_ "github.com/bserdar/watermelon/server/backends/remotelinux"
 	"github.com/bserdar/watermelon/server/inventory"
 	"github.com/bserdar/watermelon/server/pb"
 )
@@ -68,3 +70,259 @@ labels:
 		t.Errorf("Expecting label")
 	}
 }
+
+func TestGroups(t *testing.T) {
+	in := `---
+hosts:
+  - id: h1
+    address: 127.0.0.2
+    properties:
+      tier: frontend
+  - id: h2
+    address: 127.0.0.3
+groups:
+  prod:
+    children: [web, db]
+    properties:
+      env: prod
+      tier: none
+    configuration:
+      db:
+        host: prod-db
+        port: 5432
+  web:
+    hosts: [h1]
+    properties:
+      tier: web
+    configuration:
+      db:
+        port: 6432
+  db:
+    hosts: [h2]
+`
+	var invd Inventory
+	if err := yml.Unmarshal([]byte(in), &invd); err != nil {
+		t.Fatal(err)
+	}
+	i, err := invd.ToInventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, h2 := i[0], i[1]
+	if len(h1.Groups) != 2 || h1.Groups[0] != "web" || h1.Groups[1] != "prod" {
+		t.Errorf("Wrong groups: %v", h1.Groups)
+	}
+	if !server.ArrayContains(h1.Labels, "web") || !server.ArrayContains(h1.Labels, "prod") || !server.ArrayContains(h2.Labels, "db") {
+		t.Errorf("Wrong labels: %v %v", h1.Labels, h2.Labels)
+	}
+	if h1.Properties["tier"] != "frontend" || h1.Properties["env"] != "prod" || h2.Properties["tier"] != "none" {
+		t.Errorf("Wrong properties: %v %v", h1.Properties, h2.Properties)
+	}
+	db := server.ResolveCfg("/db", append([]interface{}{h1.Configuration}, h1.GroupConfiguration...)...).(map[string]interface{})
+	if db["host"] != "prod-db" || db["port"] != 6432 {
+		t.Errorf("Wrong config: %v", db)
+	}
+
+	invd.Groups["db"] = Group{Children: []string{"prod"}}
+	if _, err := invd.ToInventory(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expecting cycle error, got %v", err)
+	}
+	invd.Groups["db"] = Group{Hosts: []string{"h3"}}
+	if _, err := invd.ToInventory(); err == nil {
+		t.Errorf("Expecting undefined host error")
+	}
+}
+
+func TestGroupOrder(t *testing.T) {
+	in := `---
+hosts:
+  - id: h1
+    address: 127.0.0.2
+groups:
+  zeta:
+    hosts: [h1]
+    properties:
+      from: zeta
+  alpha:
+    hosts: [h1]
+    properties:
+      from: alpha
+`
+	_, hosts, _, err := ParseInventory([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The group defined later has precedence
+	if h := hosts[0]; len(h.Groups) != 2 || h.Groups[0] != "alpha" || h.Properties["from"] != "alpha" {
+		t.Errorf("Wrong groups: %v %v", h.Groups, h.Properties)
+	}
+}
+
+func TestHostRanges(t *testing.T) {
+	in := `---
+hosts:
+  - id: web[01:12].dc1.example.com
+    ssh:
+      hostname: "{id}"
+      user: admin
+    address: 10.0.1.{num}
+    labels: [web]
+    properties:
+      rack: "r{index}"
+  - id: db-[a:c][1:2]
+    address: 10.0.2.1
+    properties:
+      shard: "{index1}-{index2}"
+labels:
+  primary:
+    - db-[a:b]1
+groups:
+  first:
+    hosts:
+      - web[01:03:2].dc1.example.com
+`
+	var invd Inventory
+	if err := yml.Unmarshal([]byte(in), &invd); err != nil {
+		t.Fatal(err)
+	}
+	i, err := invd.ToInventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(i) != 18 {
+		t.Fatalf("Expecting 18 hosts, got %d", len(i))
+	}
+	web := i[11]
+	if web.ID != "web12.dc1.example.com" || web.Hostname != web.ID || web.LoginUser != "admin" ||
+		web.Properties["rack"] != "r12" || web.Addresses[0].Address != "10.0.1.12" || !server.HasLabel(&web.HostInfo, "web") {
+		t.Errorf("Wrong host: %+v", web)
+	}
+	if i[0].Hostname != "web01.dc1.example.com" || i[0].Properties["rack"] != "r01" || i[0].Addresses[0].Address != "10.0.1.1" {
+		t.Errorf("Hosts share data: %+v", i[0])
+	}
+	for _, x := range i {
+		inFirst := x.ID == "web01.dc1.example.com" || x.ID == "web03.dc1.example.com"
+		if server.HasLabel(&x.HostInfo, "first") != inFirst {
+			t.Errorf("Wrong group for %s: %v", x.ID, x.Labels)
+		}
+	}
+	db := i[12:]
+	if db[0].ID != "db-a1" || db[5].ID != "db-c2" || db[3].Properties["shard"] != "b-2" {
+		t.Errorf("Wrong hosts: %s %s %v", db[0].ID, db[5].ID, db[3].Properties)
+	}
+	if !server.HasLabel(&db[0].HostInfo, "primary") || !server.HasLabel(&db[2].HostInfo, "primary") || server.HasLabel(&db[1].HostInfo, "primary") {
+		t.Errorf("Wrong labels")
+	}
+
+	invd.Hosts = append(invd.Hosts, Host{ID: "db-b1"})
+	if _, err := invd.ToInventory(); err == nil || !strings.Contains(err.Error(), "Duplicate") {
+		t.Errorf("Expecting duplicate error, got %v", err)
+	}
+	for _, bad := range []string{"x[5:1]", "x[a:5]", "x[1:100000]", "x[a:Z]"} {
+		if _, _, err := expandPattern(bad); err == nil {
+			t.Errorf("Expecting error for %s", bad)
+		}
+	}
+}
+
+func TestBastion(t *testing.T) {
+	in := `---
+hosts:
+  - id: gw[1:2]
+    address: 10.0.0.{index}
+    ssh:
+      hostname: 10.0.0.{index}
+  - id: inner
+    address: 10.0.1.1
+    ssh:
+      hostname: 10.0.1.1
+      bastion: gw2
+  - id: app[1:2]
+    address: 10.0.2.{index}
+    ssh:
+      hostname: 10.0.2.{index}
+      bastion: gw{index}
+`
+	var invd Inventory
+	if err := yml.Unmarshal([]byte(in), &invd); err != nil {
+		t.Fatal(err)
+	}
+	i, err := invd.ToInventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i[0].Bastion != nil || i[2].Bastion != i[1] || i[3].Bastion != i[0] || i[4].Bastion != i[1] {
+		t.Errorf("Wrong bastions")
+	}
+
+	invd.Hosts[1].SSH.Bastion = "missing"
+	if _, err := invd.ToInventory(); err == nil || !strings.Contains(err.Error(), "not defined") {
+		t.Errorf("Expecting undefined bastion, got %v", err)
+	}
+	invd.Hosts[0].SSH.Bastion = "inner"
+	invd.Hosts[1].SSH.Bastion = "gw1"
+	if _, err := invd.ToInventory(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expecting bastion cycle, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	in := `---
+configuration:
+  password:
+    valueFrom:
+      type: nosuch
+hosts:
+  - id: a
+    address: 10.0.0.x
+    ssh:
+      hostname: a
+  - id: b
+    address: 10.0.0.2
+    colour: red
+    ssh:
+      hostname: b
+  - id: b
+    address: 10.0.0.3
+    ssh:
+      hostname: b
+  - id: c
+    address: 10.0.0.4
+    ssh:
+      hostname: c
+      bastion: a
+labels:
+  web:
+    - b
+    - ghost
+    - a
+groups:
+  g:
+    hosts:
+      - a
+`
+	problems := Validate("inv.yml", []byte(in))
+	expected := []string{
+		"inv.yml:4: Unknown extension: nosuch",
+		"inv.yml:7: Cannot parse address 10.0.0.x",
+		"inv.yml:13: field colour not found in type yml.Host",
+		"inv.yml:16: Duplicate host: b",
+		"inv.yml:28: Host ghost is referenced in label web, but it is not defined",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expecting %d problems, got %v", len(expected), problems)
+	}
+	for i, p := range problems {
+		if p.Error() != expected[i] {
+			t.Errorf("Expecting %s, got %s", expected[i], p.Error())
+		}
+	}
+
+	// Undefined hosts in labels are reported, not dereferenced
+	var invd Inventory
+	yml.Unmarshal([]byte(in), &invd)
+	invd.Hosts = invd.Hosts[1:2]
+	if _, err := invd.ToInventory(); err == nil || !strings.Contains(err.Error(), "ghost") {
+		t.Errorf("Expecting undefined host error, got %v", err)
+	}
+}
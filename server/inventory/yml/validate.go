@@ -0,0 +1,178 @@
+package yml
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server/session"
+	"github.com/bserdar/watermelon/server/vault"
+)
+
+// Problem is a problem found in an inventory or a configuration
+// file. Line is 0 if the problem is not about a particular line
+type Problem struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (p Problem) Error() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Msg)
+}
+
+var errorLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// errorProblems converts the errors returned by the YAML parser to
+// problems
+func errorProblems(file string, err error) []Problem {
+	msgs := []string{err.Error()}
+	if t, ok := err.(*yaml.TypeError); ok {
+		msgs = t.Errors
+	}
+	ret := make([]Problem, 0, len(msgs))
+	for _, msg := range msgs {
+		p := Problem{File: file, Msg: msg}
+		if m := errorLine.FindStringSubmatch(msg); m != nil {
+			p.Line, _ = strconv.Atoi(m[1])
+			p.Msg = m[2]
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+// locate returns the line of the item at path in the document. If
+// the item does not exist, returns the line of the closest ancestor
+// that exists
+func locate(doc *yaml.Node, path []interface{}) int {
+	n := doc
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	line := n.Line
+	for _, p := range path {
+		var next *yaml.Node
+		switch k := p.(type) {
+		case string:
+			if n.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(n.Content); i += 2 {
+					if n.Content[i].Value == k {
+						next = n.Content[i+1]
+						line = n.Content[i].Line
+						break
+					}
+				}
+			}
+		case int:
+			if n.Kind == yaml.SequenceNode && k < len(n.Content) {
+				next = n.Content[k]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return line
+}
+
+// checkRefs reports the valueFrom references under n that cannot be
+// resolved. Only the references in configuration sections are
+// checked, unless inCfg is true
+func checkRefs(file string, n *yaml.Node, inCfg bool) []Problem {
+	ret := make([]Problem, 0)
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, x := range n.Content {
+			ret = append(ret, checkRefs(file, x, inCfg)...)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if inCfg && key.Value == "valueFrom" {
+				var ref interface{}
+				err := value.Decode(&ref)
+				if err == nil {
+					if m, ok := ref.(map[string]interface{}); ok {
+						ref = session.ResolveRefPaths(m, filepath.Dir(file))
+					}
+					err = session.CheckRef(ref)
+				}
+				if err != nil {
+					ret = append(ret, Problem{File: file, Line: key.Line, Msg: err.Error()})
+				}
+				continue
+			}
+			ret = append(ret, checkRefs(file, value, inCfg || key.Value == "configuration")...)
+		}
+	}
+	return ret
+}
+
+// Validate loads the inventory document, and returns all the problems
+// found in it. Unlike ParseInventory, it does not stop at the first
+// problem
+func Validate(file string, data []byte) []Problem {
+	data, err := vault.Decrypt(data)
+	if err != nil {
+		return []Problem{{File: file, Msg: err.Error()}}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return errorProblems(file, err)
+	}
+	problems := make([]Problem, 0)
+	// Unknown keys are only detected by the strict decoder
+	var strict Inventory
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&strict); err != nil {
+		problems = append(problems, errorProblems(file, err)...)
+	}
+	// Type errors are already reported by the strict decoder
+	var inv Inventory
+	yaml.Unmarshal(data, &inv)
+	inv.readGroupOrder(data)
+
+	if len(inv.PrivateKeyFile) > 0 {
+		if _, err := ioutil.ReadFile(inv.PrivateKeyFile); err != nil {
+			problems = append(problems, Problem{File: file, Line: locate(&root, []interface{}{"privateKey"}), Msg: err.Error()})
+		}
+	}
+	inv.toInventory(func(err error, path ...interface{}) {
+		problems = append(problems, Problem{File: file, Line: locate(&root, path), Msg: err.Error()})
+	})
+	for n, h := range inv.Hosts {
+		if (len(h.Backend) == 0 || h.Backend == "linux") && h.SSH == nil {
+			problems = append(problems, Problem{File: file, Line: locate(&root, []interface{}{"hosts", n}), Msg: fmt.Sprintf("No ssh hostname for %s", h.ID)})
+		}
+	}
+	problems = append(problems, checkRefs(file, &root, false)...)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// ValidateConfig parses a configuration file, and returns the
+// problems found in it
+func ValidateConfig(file string, data []byte) []Problem {
+	data, err := vault.Decrypt(data)
+	if err != nil {
+		return []Problem{{File: file, Msg: err.Error()}}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return errorProblems(file, err)
+	}
+	return checkRefs(file, &root, true)
+}
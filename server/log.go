@@ -1,5 +1,9 @@
 package server
 
+import (
+	"bytes"
+)
+
 // Logging provides access to loggers
 type Logging interface {
 	// New returns the logger for the host. If logToStdout is true, then
@@ -13,3 +17,36 @@ type Logger interface {
 	Print(...interface{})
 	Printf(string, ...interface{})
 }
+
+// LineLogger is an io.Writer that prints every complete line written
+// to it using the logger, so the output of a command is logged as it
+// runs
+type LineLogger struct {
+	Logger Logger
+	// Prefix is printed before every line
+	Prefix string
+	buf    []byte
+}
+
+// Write prints the complete lines in p, and keeps the incomplete last
+// line until the next write
+func (l *LineLogger) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		ix := bytes.IndexByte(l.buf, '\n')
+		if ix == -1 {
+			break
+		}
+		l.Logger.Print(l.Prefix + string(l.buf[:ix]))
+		l.buf = l.buf[ix+1:]
+	}
+	return len(p), nil
+}
+
+// Flush prints the incomplete last line, if any
+func (l *LineLogger) Flush() {
+	if len(l.buf) > 0 {
+		l.Logger.Print(l.Prefix + string(l.buf))
+		l.buf = nil
+	}
+}
@@ -57,7 +57,7 @@ func (l *Logger) Printf(format string, v ...interface{}) {
 }
 
 func (l *Logger) queue(msg string) {
-	formatted := Formatter(time.Now(), l.Host, msg)
+	formatted := Formatter(time.Now(), l.Host, server.Redact(msg))
 	if len(formatted) > 0 {
 		if l.LogStdout {
 			log.Infof("[%s] %s", l.Host.ID, formatted)
@@ -20,7 +20,7 @@ func (w *Response) Append(rsp Response) {
 	}
 	if len(rsp.ErrorMsg) > 0 {
 		if len(w.ErrorMsg) > 0 {
-			w.ErrorMsg = "\n" + rsp.ErrorMsg
+			w.ErrorMsg += "\n" + rsp.ErrorMsg
 		} else {
 			w.ErrorMsg = rsp.ErrorMsg
 		}
@@ -32,5 +32,7 @@ func (w *Response) Append(rsp Response) {
 type ModuleMgr interface {
 	// SendRequest to a module function
 	SendRequest(session, module, funcName string, data []byte) (Response, error)
+	// CloseSession drops the state kept for a session
+	CloseSession(session string)
 	Close()
 }
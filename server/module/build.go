@@ -0,0 +1,289 @@
+package module
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BuildCache builds Go modules, and keeps the binaries in a
+// directory keyed by a hash of the module sources. A module is
+// rebuilt only if its sources, go.mod, go.sum, module.w, the Go
+// version, or the build environment change. Only the latest binary of
+// a module is kept.
+//
+// The sources of a module are all the Go files of the Go module
+// containing the module directory, so changes to the packages the
+// module imports from the same Go module cause a rebuild. Packages
+// imported from other Go modules are identified by go.sum.
+type BuildCache struct {
+	// Dir is the directory containing the binaries
+	Dir string
+
+	once      sync.Once
+	goVersion string
+	goErr     error
+}
+
+// DefaultBuildCacheDir returns the default build cache directory
+// under the user cache directory, or an empty string if there is no
+// user cache directory
+func DefaultBuildCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "watermelon", "modules")
+}
+
+// NewBuildCache returns a build cache using dir
+func NewBuildCache(dir string) *BuildCache {
+	return &BuildCache{Dir: dir}
+}
+
+// BuildError is returned if a module cannot be built. Output contains
+// the compiler diagnostics
+type BuildError struct {
+	Module string
+	Dir    string
+	Output string
+	Err    error
+}
+
+func (e BuildError) Error() string {
+	if len(e.Output) == 0 {
+		return fmt.Sprintf("Cannot build module %s in %s: %v", e.Module, e.Dir, e.Err)
+	}
+	return fmt.Sprintf("Cannot build module %s in %s: %v\n%s", e.Module, e.Dir, e.Err, e.Output)
+}
+
+// version returns the output of go version, or an error if the go
+// command is not available
+func (b *BuildCache) version() (string, error) {
+	b.once.Do(func() {
+		out, err := exec.Command("go", "version").Output()
+		if err != nil {
+			b.goErr = err
+			return
+		}
+		b.goVersion = strings.TrimSpace(string(out))
+	})
+	return b.goVersion, b.goErr
+}
+
+// Build returns the binary of the module in dir, building it if there
+// is no binary for the current sources. Returns false if dir is not a
+// Go main package, or if the go command is not available. Then the
+// module should be run using module.w
+func (b *BuildCache) Build(module, dir string) (string, bool, error) {
+	if !isMainPackage(dir) {
+		return "", false, nil
+	}
+	root, ok := goModuleRoot(dir)
+	if !ok {
+		return "", false, nil
+	}
+	version, err := b.version()
+	if err != nil {
+		log.Debugf("Cannot run go: %v, using module.w for %s", err, module)
+		return "", false, nil
+	}
+	hash, err := sourceHash(root, dir, version)
+	if err != nil {
+		return "", false, BuildError{Module: module, Dir: dir, Err: err}
+	}
+	moduleDir, err := b.moduleDir(dir)
+	if err != nil {
+		return "", false, BuildError{Module: module, Dir: dir, Err: err}
+	}
+	bin := filepath.Join(moduleDir, hash, filepath.Base(dir))
+	if fi, err := os.Stat(bin); err == nil && fi.Mode().IsRegular() {
+		log.Debugf("Module %s is up to date: %s", module, bin)
+		return bin, true, nil
+	}
+	log.Infof("Building module %s", module)
+	if err := os.MkdirAll(filepath.Dir(bin), 0775); err != nil {
+		return "", false, BuildError{Module: module, Dir: dir, Err: err}
+	}
+	// Build into a temporary file, and rename it, so concurrent runs
+	// never see a partial binary
+	tmp, err := ioutil.TempFile(filepath.Dir(bin), ".build")
+	if err != nil {
+		return "", false, BuildError{Module: module, Dir: dir, Err: err}
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	cmd := exec.Command("go", "build", "-o", tmp.Name(), ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", false, BuildError{Module: module, Dir: dir, Output: strings.TrimSpace(string(out)), Err: err}
+	}
+	if err := os.Rename(tmp.Name(), bin); err != nil {
+		return "", false, BuildError{Module: module, Dir: dir, Err: err}
+	}
+	evict(moduleDir, hash)
+	return bin, true, nil
+}
+
+// moduleDir returns the directory containing the binaries of the
+// module in dir
+func (b *BuildCache) moduleDir(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(absDir))
+	return filepath.Join(b.Dir, hex.EncodeToString(sum[:8])), nil
+}
+
+// evict removes the binaries of a module other than the one for hash
+func evict(moduleDir, hash string) {
+	entries, err := ioutil.ReadDir(moduleDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.Name() != hash {
+			log.Debugf("Removing stale binary %s", filepath.Join(moduleDir, e.Name()))
+			os.RemoveAll(filepath.Join(moduleDir, e.Name()))
+		}
+	}
+}
+
+// isMainPackage returns if dir contains Go files of package main
+func isMainPackage(dir string) bool {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(token.NewFileSet(), f, nil, parser.PackageClauseOnly)
+		if err == nil && parsed.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// goModuleRoot returns the directory containing the go.mod of dir
+func goModuleRoot(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !fi.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// isSource returns if the file under the Go module root is an input
+// to the build
+func isSource(name string) bool {
+	switch filepath.Ext(name) {
+	case ".go":
+		return !strings.HasSuffix(name, "_test.go")
+	case ".s", ".c", ".h":
+		return true
+	}
+	return name == "go.mod" || name == "go.sum" || name == "modules.txt"
+}
+
+// sourceHash returns the hash of the sources of the Go module at
+// root, the module directory and its module.w, the Go version, and
+// the build environment
+func sourceHash(root, dir, version string) (string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := info.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" {
+				return filepath.SkipDir
+			}
+			// Nested Go modules are not a part of this module
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() && isSource(info.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00", version, runtime.GOOS, runtime.GOARCH, absDir)
+	for _, env := range []string{"GOOS", "GOARCH", "GOFLAGS", "CGO_ENABLED"} {
+		fmt.Fprintf(h, "%s=%s\x00", env, os.Getenv(env))
+	}
+	if script := filepath.Join(dir, "module.w"); fileExists(script) {
+		fmt.Fprint(h, "module.w\x00")
+		if err := hashFile(h, script); err != nil {
+			return "", err
+		}
+	}
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f)
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+		if err := hashFile(h, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\x00%d\x00", n)
+	return err
+}
+
+func fileExists(name string) bool {
+	fi, err := os.Stat(name)
+	return err == nil && fi.Mode().IsRegular()
+}
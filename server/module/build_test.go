@@ -0,0 +1,67 @@
+package module
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	mdir := filepath.Join(src, "modules", "db")
+	os.MkdirAll(mdir, 0775)
+	os.MkdirAll(filepath.Join(src, "lib"), 0775)
+	os.MkdirAll(filepath.Join(src, "modules", "script"), 0775)
+	ioutil.WriteFile(filepath.Join(src, "go.mod"), []byte("module example.com/m\n\ngo 1.14\n"), 0664)
+	ioutil.WriteFile(filepath.Join(src, "lib", "lib.go"), []byte("package lib\n\nconst Name = \"a\"\n"), 0664)
+	ioutil.WriteFile(filepath.Join(mdir, "main.go"), []byte("package main\n\nimport \"example.com/m/lib\"\n\nfunc main() { println(lib.Name) }\n"), 0664)
+
+	cache := NewBuildCache(filepath.Join(dir, "cache"))
+	if _, ok, err := cache.Build("script", filepath.Join(src, "modules", "script")); ok || err != nil {
+		t.Errorf("Expecting no build for a non-Go module: %v %v", ok, err)
+	}
+	bin1, ok, err := cache.Build("db", mdir)
+	if !ok || err != nil {
+		t.Fatalf("Build failed: %v %v", ok, err)
+	}
+	if _, err := os.Stat(bin1); err != nil {
+		t.Errorf("No binary: %v", err)
+	}
+	// Unchanged sources use the cached binary
+	fi, _ := os.Stat(bin1)
+	bin2, _, err := cache.Build("db", mdir)
+	if err != nil || bin2 != bin1 {
+		t.Errorf("Expecting cached binary %s, got %s %v", bin1, bin2, err)
+	}
+	if fi2, _ := os.Stat(bin2); fi2 == nil || !fi2.ModTime().Equal(fi.ModTime()) {
+		t.Errorf("Binary is rebuilt")
+	}
+	// A change in an imported package causes a rebuild
+	ioutil.WriteFile(filepath.Join(src, "lib", "lib.go"), []byte("package lib\n\nconst Name = \"b\"\n"), 0664)
+	bin3, _, err := cache.Build("db", mdir)
+	if err != nil || bin3 == bin1 {
+		t.Errorf("Expecting rebuild, got %s %v", bin3, err)
+	}
+	if _, err := os.Stat(bin1); err == nil {
+		t.Errorf("Stale binary is not removed")
+	}
+	// A change in module.w causes a rebuild
+	ioutil.WriteFile(filepath.Join(mdir, "module.w"), []byte("go run . $*\n"), 0664)
+	bin4, _, err := cache.Build("db", mdir)
+	if err != nil || bin4 == bin3 {
+		t.Errorf("Expecting rebuild for module.w, got %s %v", bin4, err)
+	}
+	// Build errors contain the compiler diagnostics
+	ioutil.WriteFile(filepath.Join(src, "lib", "lib.go"), []byte("package lib\n\nconst Name = \n"), 0664)
+	_, _, err = cache.Build("db", mdir)
+	if _, ok := err.(BuildError); !ok || !strings.Contains(err.Error(), "lib.go") {
+		t.Errorf("Expecting build error, got %v", err)
+	}
+}
@@ -4,22 +4,35 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/bserdar/watermelon/server"
 	"github.com/bserdar/watermelon/server/pb"
+	"github.com/bserdar/watermelon/server/schema"
 )
 
+// SchemaFile is the name of the JSON module schema file in a module
+// directory
+const SchemaFile = "schema.json"
+
 // LifecycleManager deals with managing the external module
 // lifecycle. Create a LifecycleManager for the module, and then
 // start the module. The lifecycle manager waits for the module to
@@ -37,22 +50,64 @@ type LifecycleManager struct {
 	// first loading of the module in this run
 	RunModuleScript func(first bool, dir string) error
 
+	// BuildCache builds Go modules. If nil, all modules are built and
+	// run using module.w
+	BuildCache *BuildCache
+
+	// RunModuleBinary should run the module binary built by
+	// BuildCache in the module dir. Should return error only if
+	// execution fails
+	RunModuleBinary func(bin, dir string) error
+
 	// LocalModuleFunc is called to see if the module is local. If it
 	// is, then this should handle the call to the module, and should
 	// return response,true,err. If the module is not local, it should
 	// return response,false,nil
 	LocalModuleFunc func(session, module, funcName string, data []byte) (server.Response, bool, error)
 
+	// Output is where the messages printed by the modules are
+	// written. If nil, they are written to stdout
+	Output io.Writer
+
+	// MaxRestarts is the number of times a module that died is
+	// restarted within RestartWindow
+	MaxRestarts int
+
+	// RestartWindow is the period the restarts are counted in. If 0,
+	// all the restarts during the lifetime of the manager are counted
+	RestartWindow time.Duration
+
+	// PingTimeout is how long the supervisor waits for a ping
+	// response. If 0, DefaultPingTimeout is used
+	PingTimeout time.Duration
+
 	modules      map[string]*moduleInfo
 	builtModules map[string]struct{}
-
-	// this is used during module load, to store the module name that's being loaded
-	nextModuleName string
-	connCh         chan error
+	// Results of the configuration validations by module and session
+	validated map[string]error
+	// The request server ports of the modules not started by the
+	// manager
+	localPorts map[int32]string
+
+	// nextProcess is the process of the module being loaded. The
+	// lifecycle server uses it to get the name of the module that
+	// connects
+	procMu      sync.Mutex
+	nextProcess *process
+
+	// Restart times by module name
+	restarts map[string][]time.Time
+	// The modules that died, and are not restarted yet
+	dead map[string]ModuleError
+	// Closed to stop the supervisor
+	stopCh chan struct{}
+	// Closed when the supervisor stops
+	supervisorDone chan struct{}
 }
 
 type response struct {
-	err error
+	err         error
+	description *pb.ModuleDescription
 }
 
 type moduleInfo struct {
@@ -62,75 +117,169 @@ type moduleInfo struct {
 	conn   *grpc.ClientConn
 	reqCh  chan pb.LifecycleRequest
 	respCh chan response
+	// done is closed when the lifecycle connection ends
+	done chan struct{}
+	proc *process
+
+	// lastPing is the time of the last ping or call to the module,
+	// in UnixNano. Accessed atomically
+	lastPing int64
 
-	lastPing time.Time
+	// schema is the configuration and the function arguments the
+	// module expects, nil if the module declares none
+	schema *schema.ModuleSchema
 }
 
-// Pings the module to see if it is still alive
-func (m *moduleInfo) Ping() error {
+// request sends a lifecycle request to the module and waits for the
+// response. If timeout is nonzero, waits at most timeout
+func (m *moduleInfo) request(req pb.LifecycleRequest, timeout time.Duration) (response, error) {
 	m.Lock()
 	defer m.Unlock()
 
-	m.reqCh <- pb.LifecycleRequest{RequestType: pb.LifecycleRequest_PING}
-	rsp := <-m.respCh
-	if rsp.err != nil {
-		return rsp.err
+	if err := m.proc.err(); err != nil {
+		return response{}, err
+	}
+	var expire <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expire = timer.C
+	}
+	select {
+	case m.reqCh <- req:
+	case <-m.done:
+		return response{}, fmt.Errorf("Lifecycle connection to %s is closed", m.name)
+	case <-expire:
+		return response{}, fmt.Errorf("Timeout")
+	}
+	select {
+	case rsp := <-m.respCh:
+		return rsp, rsp.err
+	case <-m.done:
+		return response{}, fmt.Errorf("Lifecycle connection to %s is closed", m.name)
+	case <-expire:
+		return response{}, fmt.Errorf("Timeout")
+	}
+}
+
+// Pings the module to see if it is still alive
+func (m *moduleInfo) Ping(timeout time.Duration) error {
+	if _, err := m.request(pb.LifecycleRequest{RequestType: pb.LifecycleRequest_PING}, timeout); err != nil {
+		return err
 	}
-	m.lastPing = time.Now()
+	m.seen()
 	return nil
 }
 
+// seen records that the module responded
+func (m *moduleInfo) seen() {
+	atomic.StoreInt64(&m.lastPing, time.Now().UnixNano())
+}
+
+// lastSeen returns the last time the module responded
+func (m *moduleInfo) lastSeen() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&m.lastPing))
+}
+
+// Describe asks the module its description
+func (m *moduleInfo) Describe() (*pb.ModuleDescription, error) {
+	rsp, err := m.request(pb.LifecycleRequest{RequestType: pb.LifecycleRequest_DESCRIBE}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.description, nil
+}
+
 // Connect is called by the client runtime once the module loads. When
 // connected, this will initialize the module information in the
 // server, and ping the module. A failed ping will remove the module
 // from the module info map. The lifecycle manager must be locked during
 // this call
 func (mgr *LifecycleManager) Connect(stream pb.Lifecycle_ConnectServer) error {
-	log.Debugf("Connect is called, waiting for %s", mgr.nextModuleName)
-	if len(mgr.nextModuleName) == 0 || mgr.connCh == nil {
+	mgr.procMu.Lock()
+	proc := mgr.nextProcess
+	mgr.procMu.Unlock()
+	if proc == nil {
 		return fmt.Errorf("Invalid state, unexpected connection")
 	}
+	log.Debugf("Connect is called, waiting for %s", proc.module)
 	// Receive the connect msg
 	req, err := stream.Recv()
 	if err != nil {
-		mgr.connCh <- err
+		proc.connCh <- err
 		return err
 	}
 	// Module is built, tag it
-	mgr.builtModules[mgr.nextModuleName] = struct{}{}
+	mgr.builtModules[proc.module] = struct{}{}
 	if req.RequestType != pb.LifecycleRequest_CONNECT {
 		err = fmt.Errorf("Invalid state, expecting connect")
-		mgr.connCh <- err
+		proc.connCh <- err
 		return err
 	}
 
 	connectMsg := req.GetConnectMsg()
-	if _, ok := mgr.modules[mgr.nextModuleName]; ok {
-		err = fmt.Errorf("Duplicate module %s", mgr.nextModuleName)
-		mgr.connCh <- err
+	if _, ok := mgr.modules[proc.module]; ok {
+		err = fmt.Errorf("Duplicate module %s", proc.module)
+		proc.connCh <- err
 		return err
 	}
+	var sch *schema.ModuleSchema
+	if len(connectMsg.Schema) > 0 {
+		if sch, err = schema.Parse(connectMsg.Schema); err != nil {
+			err = fmt.Errorf("Invalid schema for %s: %v", proc.module, err)
+			proc.connCh <- err
+			return err
+		}
+	}
 	log.Debugf("Connect ok")
 	mod := &moduleInfo{server: fmt.Sprintf("localhost:%d", connectMsg.Port),
-		name:   mgr.nextModuleName,
-		reqCh:  make(chan pb.LifecycleRequest),
-		respCh: make(chan response)}
+		name:  proc.module,
+		reqCh: make(chan pb.LifecycleRequest),
+		// Buffered, so a response to a timed out request does not
+		// block the loop
+		respCh: make(chan response, 1),
+		done:   make(chan struct{}),
+		proc:   proc,
+		schema: sch}
+	mod.seen()
+	defer close(mod.done)
 	mgr.modules[mod.name] = mod
-	mgr.connCh <- nil
+	proc.setConnected()
+	proc.connCh <- nil
 	log.Debugf("Connect complete")
 	// Loop
 	for {
 		select {
+		case <-stream.Context().Done():
+			proc.lostConnection(stream.Context().Err())
+			return stream.Context().Err()
 		case r := <-mod.reqCh:
 			switch r.RequestType {
 			case pb.LifecycleRequest_PING:
 				err := stream.Send(&r)
+				if err == nil {
+					_, err = stream.Recv()
+				}
+				mod.respCh <- response{err: err}
 				if err != nil {
-					close(mod.respCh)
+					proc.lostConnection(err)
 					return err
 				}
-				_, err = stream.Recv()
-				mod.respCh <- response{err: err}
+			case pb.LifecycleRequest_DESCRIBE:
+				err := stream.Send(&r)
+				var rsp *pb.LifecycleRequest
+				if err == nil {
+					rsp, err = stream.Recv()
+				}
+				if err != nil {
+					mod.respCh <- response{err: err}
+					proc.lostConnection(err)
+					return err
+				}
+				if rsp.RequestType != pb.LifecycleRequest_DESCRIBE {
+					err = fmt.Errorf("Invalid response to describe from %s", mod.name)
+				}
+				mod.respCh <- response{err: err, description: rsp.GetDescription()}
 			case pb.LifecycleRequest_TERM:
 				return nil
 			}
@@ -156,6 +305,52 @@ func (mgr *LifecycleManager) ModuleCall(ctx context.Context, req *pb.ModuleWorkR
 	return &pb.Response{Success: ws.Success,
 		FuncName: ws.FuncName,
 		ErrorMsg: ws.ErrorMsg,
+		Modified: ws.Modified,
+		Data:     ws.Data}, nil
+}
+
+// DeclareHandler declares a handler for the session
+func (mgr *LifecycleManager) DeclareHandler(ctx context.Context, req *pb.HandlerRequest) (*pb.Empty, error) {
+	session := server.GetSession(req.Session)
+	if session == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	err := session.GetHandlers().Declare(server.Handler{Name: req.Name,
+		Module:    req.Module,
+		Func:      req.FuncName,
+		AfterCall: req.AfterCall})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+// Notify queues a handler for a host
+func (mgr *LifecycleManager) Notify(ctx context.Context, req *pb.NotifyRequest) (*pb.Empty, error) {
+	session, h, err := server.GetHostAndSession(req.Session, req.HostId)
+	if err != nil {
+		return nil, err
+	}
+	err = session.GetHandlers().Notify(h.ID, req.Handler, req.CallId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Empty{}, nil
+}
+
+// FlushHandlers runs the queued handlers
+func (mgr *LifecycleManager) FlushHandlers(ctx context.Context, req *pb.FlushHandlersRequest) (*pb.Response, error) {
+	session := server.GetSession(req.Session)
+	if session == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	ws, err := session.GetHandlers().Flush(mgr, req.Session, req.HostIds)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Response{Success: ws.Success,
+		ErrorMsg: ws.ErrorMsg,
+		Modified: ws.Modified,
 		Data:     ws.Data}, nil
 }
 
@@ -171,7 +366,11 @@ func (mgr *LifecycleManager) Log(ctx context.Context, req *pb.LogRequest) (*pb.E
 
 // Print a message
 func (mgr *LifecycleManager) Print(ctx context.Context, req *pb.LogRequest) (*pb.Empty, error) {
-	fmt.Printf(req.Msg)
+	out := mgr.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprint(out, server.Redact(req.Msg))
 	return &pb.Empty{}, nil
 }
 
@@ -184,7 +383,37 @@ func (mgr *LifecycleManager) GetArgs(ctx context.Context, req *pb.Session) (*pb.
 	return &pb.Args{Args: session.GetArgs()}, nil
 }
 
-// GetCfg returns a configuration item by name
+// moduleByPort returns the name of the module whose request server
+// listens on port, or empty string if there is none
+func (mgr *LifecycleManager) moduleByPort(port int32) string {
+	if port == 0 {
+		return ""
+	}
+	addr := fmt.Sprintf("localhost:%d", port)
+	mgr.Lock()
+	defer mgr.Unlock()
+	if name, ok := mgr.localPorts[port]; ok {
+		return name
+	}
+	for name, mod := range mgr.modules {
+		if mod.server == addr {
+			return name
+		}
+	}
+	return ""
+}
+
+// SetModulePort records the request server port of a module that is
+// not started by the manager, such as a module running in-process, so
+// its configuration requests are identified
+func (mgr *LifecycleManager) SetModulePort(module string, port int) {
+	mgr.Lock()
+	mgr.localPorts[int32(port)] = module
+	mgr.Unlock()
+}
+
+// GetCfg returns a configuration item by name, as seen by the calling
+// module
 func (mgr *LifecycleManager) GetCfg(ctx context.Context, req *pb.CfgRequest) (*pb.CfgResponse, error) {
 	log.Debugf("GetCfg %s", req.Path)
 	session := server.GetSession(req.Session)
@@ -192,7 +421,11 @@ func (mgr *LifecycleManager) GetCfg(ctx context.Context, req *pb.CfgRequest) (*p
 		log.Debugf("Bad session")
 		return nil, fmt.Errorf("Invalid session %s", req.Session)
 	}
-	data := session.GetCfg(req.HostId, req.Path)
+	data, err := session.GetModuleCfg(mgr.moduleByPort(req.Port), req.HostId, req.Path)
+	if err != nil {
+		log.Errorf("GetCfg %s: %v", req.Path, err)
+		return nil, err
+	}
 	if data == nil {
 		log.Debugf("Cfg %s not found", req.Path)
 		return &pb.CfgResponse{}, nil
@@ -207,12 +440,25 @@ func (mgr *LifecycleManager) GetCfg(ctx context.Context, req *pb.CfgRequest) (*p
 
 // Close and shutdown all modules
 func (mgr *LifecycleManager) Close() {
-	for len(mgr.modules) > 0 {
-		for k := range mgr.modules {
-			log.Debugf("Shutting down %s", k)
-			mgr.end(k)
-			break
-		}
+	mgr.Lock()
+	stop, done := mgr.stopCh, mgr.supervisorDone
+	mgr.stopCh = nil
+	mgr.Unlock()
+	// Wait for the supervisor, so it does not ping modules that are
+	// shutting down
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	mgr.Lock()
+	names := make([]string, 0, len(mgr.modules))
+	for k := range mgr.modules {
+		names = append(names, k)
+	}
+	mgr.Unlock()
+	for _, k := range names {
+		log.Debugf("Shutting down %s", k)
+		mgr.end(k)
 	}
 }
 
@@ -221,7 +467,16 @@ func (mgr *LifecycleManager) end(name string) {
 	defer mgr.Unlock()
 	mod, ok := mgr.modules[name]
 	if ok {
-		mod.reqCh <- pb.LifecycleRequest{RequestType: pb.LifecycleRequest_TERM}
+		dead := mod.proc.err() != nil
+		mod.proc.terminate()
+		if !dead {
+			select {
+			case mod.reqCh <- pb.LifecycleRequest{RequestType: pb.LifecycleRequest_TERM}:
+			case <-mod.done:
+			}
+		} else {
+			mod.proc.kill()
+		}
 		if mod.conn != nil {
 			mod.conn.Close()
 		}
@@ -229,11 +484,26 @@ func (mgr *LifecycleManager) end(name string) {
 	}
 }
 
+// AddSecrets marks values as secrets
+func (mgr *LifecycleManager) AddSecrets(ctx context.Context, req *pb.SecretsRequest) (*pb.Empty, error) {
+	if server.GetSession(req.Session) == nil {
+		return nil, server.ErrInvalidSession(req.Session)
+	}
+	server.AddSecret(req.Values...)
+	return &pb.Empty{}, nil
+}
+
 // NewLifecycleManager returns  a new lifecycle manager  to keep track
 // of modules connected to the server
 func NewLifecycleManager() *LifecycleManager {
 	return &LifecycleManager{modules: make(map[string]*moduleInfo),
-		builtModules: make(map[string]struct{})}
+		builtModules:  make(map[string]struct{}),
+		validated:     make(map[string]error),
+		localPorts:    make(map[int32]string),
+		restarts:      make(map[string][]time.Time),
+		dead:          make(map[string]ModuleError),
+		MaxRestarts:   DefaultMaxRestarts,
+		RestartWindow: DefaultRestartWindow}
 }
 
 // SendRequest calls a function in a module with the data, and
@@ -249,6 +519,10 @@ func (mgr *LifecycleManager) SendRequest(session, module, funcName string, data
 
 	mod, err := mgr.load(module)
 	if err != nil {
+		switch err.(type) {
+		case BuildError, ModuleError:
+			return server.Response{}, err
+		}
 		return server.Response{}, fmt.Errorf("Module not found: %s", module)
 	}
 	mgr.Lock()
@@ -260,19 +534,160 @@ func (mgr *LifecycleManager) SendRequest(session, module, funcName string, data
 			return server.Response{}, err
 		}
 	}
-	mod.lastPing = time.Now()
+	mod.seen()
 	mgr.Unlock()
+	if err := mgr.ValidateCall(module, mod.schema, session, funcName, data); err != nil {
+		return server.Response{}, err
+	}
 	cli := pb.NewRequestProcessorClient(mod.conn)
 	logger.Debugf("Calling module %s.%s with %+v", module, funcName, string(data))
-	ws, err := cli.Process(context.Background(), &pb.Request{Session: session, FuncName: funcName, Data: data})
+	callID := uuid.New().String()
+	ws, err := cli.Process(context.Background(), &pb.Request{Session: session, FuncName: funcName, Data: data, CallId: callID})
 	logger.Debugf("Module %s.%s returned: %v %v", module, funcName, ws, err)
 	if err != nil {
+		// If the module died during the call, report why. The
+		// connection becomes unavailable before the module is known
+		// to be dead, so wait for it only then
+		var wait time.Duration
+		if status.Code(err) == codes.Unavailable {
+			wait = time.Second
+		}
+		if merr := mod.proc.waitDead(wait); merr != nil {
+			return server.Response{}, merr
+		}
 		return server.Response{}, err
 	}
-	return server.Response{Success: ws.Success,
+	mod.seen()
+	ret := server.Response{Success: ws.Success,
 		FuncName: ws.FuncName,
-		ErrorMsg: ws.ErrorMsg,
-		Data:     ws.Data}, nil
+		ErrorMsg: server.Redact(ws.ErrorMsg),
+		Modified: ws.Modified,
+		Data:     ws.Data}
+	// Run the handlers notified during this call
+	if s := server.GetSession(session); s != nil {
+		hrsp, err := s.GetHandlers().FlushCall(mgr, session, callID)
+		if err != nil {
+			return ret, err
+		}
+		ret.Append(hrsp)
+	}
+	return ret, nil
+}
+
+// ValidateCall validates the configuration against the module schema
+// the first time the module is called in a session, and the function
+// arguments for every call
+func (mgr *LifecycleManager) ValidateCall(module string, sch *schema.ModuleSchema, sessionID, funcName string, data []byte) error {
+	if sch == nil {
+		return nil
+	}
+	if fs, ok := sch.Functions[funcName]; ok {
+		var args interface{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &args); err != nil {
+				return fmt.Errorf("Invalid arguments for %s.%s: %v", module, funcName, err)
+			}
+		} else if fs.Type == "object" {
+			args = map[string]interface{}{}
+		}
+		if v := fs.Validate("", args); len(v) > 0 {
+			return violationsError(fmt.Sprintf("Invalid arguments for %s.%s:", module, funcName), v, nil)
+		}
+	}
+	s := server.GetSession(sessionID)
+	if s == nil || sch.Config == nil {
+		return nil
+	}
+	key := module + "\x00" + sessionID
+	mgr.Lock()
+	err, done := mgr.validated[key]
+	mgr.Unlock()
+	if done {
+		return err
+	}
+	err = validateConfig(s, module, sch.Config)
+	mgr.Lock()
+	mgr.validated[key] = err
+	mgr.Unlock()
+	return err
+}
+
+// CloseSession drops the configuration validation results of the
+// session
+func (mgr *LifecycleManager) CloseSession(session string) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	for key := range mgr.validated {
+		if strings.HasSuffix(key, "\x00"+session) {
+			delete(mgr.validated, key)
+		}
+	}
+}
+
+// validateConfig adds the defaults of the configuration schema to the
+// session as a layer only seen by the module, and validates the
+// configuration seen by each host, or the global configuration if
+// there are no hosts. Returns an error containing all the violations
+func validateConfig(s server.Session, module string, cfg *schema.Schema) error {
+	d := cfg.Defaults()
+	if d != nil {
+		s.AddDefaultConfig(server.ConfigLayer{Name: "schema " + module, Module: module, Config: d})
+	}
+	title := fmt.Sprintf("Configuration does not match the schema of %s:", module)
+	var ids []string
+	if inv := s.GetInv(); inv != nil {
+		var err error
+		if ids, err = inv.GetHostIDs(server.AllHosts); err != nil {
+			return err
+		}
+	}
+	if len(ids) == 0 {
+		value, _, err := s.ExplainCfg("", "")
+		if err != nil {
+			return err
+		}
+		return violationsError(title, cfg.Validate("", server.ResolveCfg("", value, d)), nil)
+	}
+	violations := make([]schema.Violation, 0)
+	hosts := make(map[string][]string)
+	for _, id := range ids {
+		value, _, err := s.ExplainCfg(id, "")
+		if err != nil {
+			return err
+		}
+		for _, v := range cfg.Validate("", server.ResolveCfg("", value, d)) {
+			msg := v.Error()
+			if _, ok := hosts[msg]; !ok {
+				violations = append(violations, v)
+			}
+			hosts[msg] = append(hosts[msg], id)
+		}
+	}
+	// Do not list the hosts for the problems common to all hosts
+	for msg, h := range hosts {
+		if len(h) == len(ids) {
+			delete(hosts, msg)
+		}
+	}
+	return violationsError(title, violations, hosts)
+}
+
+// violationsError returns an error listing the violations, or nil if
+// there are none. hosts are the hosts that have each violation
+func violationsError(title string, violations []schema.Violation, hosts map[string][]string) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	b := strings.Builder{}
+	b.WriteString(title)
+	for _, v := range violations {
+		b.WriteString("\n  ")
+		b.WriteString(v.Error())
+		if h := hosts[v.Error()]; len(h) > 0 {
+			fmt.Fprintf(&b, " (hosts %s)", strings.Join(h, ", "))
+		}
+	}
+	return errors.New(b.String())
 }
 
 // load loads a module if it is not loaded
@@ -281,7 +696,27 @@ func (mgr *LifecycleManager) load(module string) (*moduleInfo, error) {
 	defer mgr.Unlock()
 	mi, ok := mgr.modules[module]
 	if ok {
-		return mi, nil
+		err := mi.proc.err()
+		if err == nil {
+			return mi, nil
+		}
+		if mi.conn != nil {
+			mi.conn.Close()
+		}
+		delete(mgr.modules, module)
+		mgr.dead[module] = err.(ModuleError)
+	}
+	if merr, ok := mgr.dead[module]; ok {
+		// The module is dead. Restart it if it did not reach the
+		// restart limit
+		restarts := mgr.recentRestarts(module)
+		if len(restarts) >= mgr.MaxRestarts {
+			merr.Msg = fmt.Sprintf("%s, not restarting after %d restarts", merr.Msg, len(restarts))
+			return nil, merr
+		}
+		mgr.restarts[module] = append(restarts, time.Now())
+		delete(mgr.dead, module)
+		log.Warnf("Restarting module %s (%d/%d)", module, len(mgr.restarts[module]), mgr.MaxRestarts)
 	}
 	log.Debugf("Loading %s", module)
 	// Module not loaded. Now load it
@@ -292,37 +727,112 @@ func (mgr *LifecycleManager) load(module string) (*moduleInfo, error) {
 	log.Debugf("Module found under %s", moduleDir)
 	// Found the directory containing the module. Run the contents of module.w
 	// Setup the lifecycle server to receive connection from this module
-	mgr.nextModuleName = module
-	mgr.connCh = make(chan error)
+	// The connection result, and the exit error if the process exits
+	// before connecting
+	connCh := make(chan error, 2)
+	proc := newProcess(module, connCh)
+	mgr.procMu.Lock()
+	mgr.nextProcess = proc
+	mgr.procMu.Unlock()
 	defer func() {
-		mgr.nextModuleName = ""
-		mgr.connCh = nil
+		mgr.procMu.Lock()
+		mgr.nextProcess = nil
+		mgr.procMu.Unlock()
 	}()
 
+	first := true
+	if _, ok := mgr.builtModules[module]; ok {
+		first = false
+	}
 	go func() {
-		first := true
-		if _, ok := mgr.builtModules[module]; ok {
-			first = false
-		}
-		// This runs the module.w script under current dir. This will
-		// return an error only if execution fails
-		err := mgr.RunModuleScript(first, moduleDir)
-		if err != nil {
-			mgr.connCh <- err
+		if mgr.BuildCache != nil && mgr.RunModuleBinary != nil {
+			bin, ok, err := mgr.BuildCache.Build(module, moduleDir)
+			if err != nil {
+				connCh <- err
+				return
+			}
+			if ok {
+				proc.processExited(mgr.RunModuleBinary(bin, moduleDir))
+				return
+			}
 		}
+		// This runs the module.w script under current dir. This
+		// returns when the module exits
+		proc.processExited(mgr.RunModuleScript(first, moduleDir))
 	}()
 	log.Debugf("Waiting connect")
 	// Wait for the connection from the module
-	err := <-mgr.connCh
+	err := <-connCh
 	log.Debugf("Connection done, err: %v", err)
 	// We get a nil or error from this one
 	if err != nil {
+		if merr, ok := err.(ModuleError); ok {
+			mgr.dead[module] = merr
+		}
 		return nil, err
 	}
 	mi, _ = mgr.modules[module]
+	// A schema file in the module directory has precedence over the
+	// schema sent by the module
+	data, err := ioutil.ReadFile(filepath.Join(moduleDir, SchemaFile))
+	if err == nil {
+		sch, err := schema.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Join(moduleDir, SchemaFile), err)
+		}
+		sch.Merge(mi.schema)
+		mi.schema = sch
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
 	return mi, nil
 }
 
+// Describe loads the module and returns its description
+func (mgr *LifecycleManager) Describe(module string) (*pb.ModuleDescription, error) {
+	mi, err := mgr.load(module)
+	if err != nil {
+		return nil, err
+	}
+	return mi.Describe()
+}
+
+// Modules returns the names of all the modules under the module
+// lookup dirs, sorted. A module found in more than one lookup dir is
+// listed once
+func (mgr *LifecycleManager) Modules() []string {
+	found := map[string]struct{}{}
+	for _, x := range mgr.ModuleLookupDirs {
+		root := filepath.Join(filepath.SplitList(x)...)
+		if len(root) == 0 {
+			continue
+		}
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if path != root && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Name() == "module.w" {
+				if rel, err := filepath.Rel(root, filepath.Dir(path)); err == nil && rel != "." {
+					found[filepath.ToSlash(rel)] = struct{}{}
+				}
+			}
+			return nil
+		})
+	}
+	ret := make([]string, 0, len(found))
+	for k := range found {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
 // SearchModuleDir finds a module directory from the given name. It
 // looks up the search directories to see if such module is under any
 // one of them
@@ -341,15 +851,36 @@ func (mgr *LifecycleManager) SearchModuleDir(module string) (string, bool) {
 
 // ExecModule executes the module script and listens to its output
 func (mgr *LifecycleManager) ExecModule(name string, args ...string) error {
-	log.Debugf("Exec %s %v", name, args)
-	cmd := exec.Command(name, args...)
+	return mgr.ExecModuleIn("", name, args...)
+}
 
-	out := func(in io.Reader, errorf func(format string, args ...interface{})) {
+// ExecModuleIn executes the module in dir and listens to its
+// output. If this is called while loading a module, the last lines of
+// stderr are kept for the errors reported when the module dies
+func (mgr *LifecycleManager) ExecModuleIn(dir, name string, args ...string) error {
+	log.Debugf("Exec %s %v in %s", name, args, dir)
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	// The module runs in its own process group, so kill can reach
+	// the processes started by the module script
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	mgr.procMu.Lock()
+	proc := mgr.nextProcess
+	mgr.procMu.Unlock()
+
+	// Wait reads all output before calling cmd.Wait, so the stderr
+	// tail is complete when the process exits
+	var outputs sync.WaitGroup
+	out := func(in io.Reader, errorf func(format string, args ...interface{}), tail bool) {
+		defer outputs.Done()
 		rd := bufio.NewReader(in)
 		for {
 			str, err := rd.ReadString('\n')
 			if len(str) > 0 {
 				errorf("From %s: %s", name, str)
+				if tail && proc != nil {
+					proc.addStderr(str)
+				}
 			}
 			if err != nil {
 				break
@@ -361,15 +892,20 @@ func (mgr *LifecycleManager) ExecModule(name string, args ...string) error {
 	if err != nil {
 		return err
 	}
-	go out(stderr, log.Errorf)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
 	}
-	go out(stdout, log.Infof)
 	err = cmd.Start()
 	if err != nil {
 		return err
 	}
+	if proc != nil {
+		proc.setCmd(cmd)
+	}
+	outputs.Add(2)
+	go out(stderr, log.Errorf, true)
+	go out(stdout, log.Infof, false)
+	outputs.Wait()
 	return cmd.Wait()
 }
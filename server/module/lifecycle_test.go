@@ -0,0 +1,16 @@
+package module
+
+import (
+	"testing"
+)
+
+func TestCloseSession(t *testing.T) {
+	mgr := NewLifecycleManager()
+	mgr.validated["m\x00s-1"] = nil
+	mgr.validated["n\x00s-1"] = nil
+	mgr.validated["m\x00s-11"] = nil
+	mgr.CloseSession("s-1")
+	if _, ok := mgr.validated["m\x00s-11"]; len(mgr.validated) != 1 || !ok {
+		t.Errorf("Wrong validations: %v", mgr.validated)
+	}
+}
@@ -0,0 +1,280 @@
+package module
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPingInterval is how often the supervisor pings the modules
+	DefaultPingInterval = 10 * time.Second
+
+	// DefaultPingTimeout is how long the supervisor waits for a ping
+	// response before marking a module dead
+	DefaultPingTimeout = 10 * time.Second
+
+	// DefaultMaxRestarts is how many times a module that died is
+	// restarted within DefaultRestartWindow
+	DefaultMaxRestarts = 3
+
+	// DefaultRestartWindow is the period the restarts of a module are
+	// counted in
+	DefaultRestartWindow = 10 * time.Minute
+
+	// StderrTailLines is the number of the last stderr lines of a
+	// module included in errors
+	StderrTailLines = 20
+)
+
+// ModuleError is returned when a module process dies, or cannot be
+// started. Stderr contains the last lines the module wrote to stderr
+type ModuleError struct {
+	Module string
+	Msg    string
+	Stderr []string
+}
+
+func (e ModuleError) Error() string {
+	if len(e.Stderr) == 0 {
+		return fmt.Sprintf("Module %s %s", e.Module, e.Msg)
+	}
+	return fmt.Sprintf("Module %s %s, stderr:\n  %s", e.Module, e.Msg, strings.Join(e.Stderr, "\n  "))
+}
+
+// process is a module process started by load
+type process struct {
+	sync.Mutex
+	module string
+	cmd    *exec.Cmd
+	stderr []string
+	// connCh receives the result of the connection. If the process
+	// exits before connecting, the exit error is sent to connCh
+	connCh    chan error
+	connected bool
+	exited    bool
+	// dead is the reason why the module is dead, empty if it is alive
+	dead string
+	// lost is set if the module is dead because the lifecycle
+	// connection is lost. Then the exit status replaces the reason
+	lost bool
+	// deadCh is closed when the module is marked dead, and exitCh is
+	// closed when the process exits
+	deadCh chan struct{}
+	exitCh chan struct{}
+}
+
+func newProcess(module string, connCh chan error) *process {
+	return &process{module: module, connCh: connCh,
+		deadCh: make(chan struct{}),
+		exitCh: make(chan struct{})}
+}
+
+// addStderr adds a line to the stderr tail
+func (p *process) addStderr(line string) {
+	p.Lock()
+	defer p.Unlock()
+	p.stderr = append(p.stderr, strings.TrimRight(line, "\n"))
+	if len(p.stderr) > StderrTailLines {
+		p.stderr = p.stderr[len(p.stderr)-StderrTailLines:]
+	}
+}
+
+func (p *process) setCmd(cmd *exec.Cmd) {
+	p.Lock()
+	p.cmd = cmd
+	p.Unlock()
+}
+
+func (p *process) setConnected() {
+	p.Lock()
+	p.connected = true
+	p.Unlock()
+}
+
+// markDead marks the module dead. The first reason is kept
+func (p *process) markDead(reason string) {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.dead) == 0 {
+		log.Warnf("Module %s %s", p.module, reason)
+		p.setDead(reason)
+	}
+}
+
+// setDead sets the reason why the module is dead. Must be called
+// with the lock held
+func (p *process) setDead(reason string) {
+	p.dead = reason
+	select {
+	case <-p.deadCh:
+	default:
+		close(p.deadCh)
+	}
+}
+
+// lostConnection marks the module dead because the lifecycle
+// connection ended. This usually happens because the process exited,
+// so the reason is replaced when the exit status is known
+func (p *process) lostConnection(err error) {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.dead) == 0 {
+		p.setDead(fmt.Sprintf("lost the lifecycle connection: %v", err))
+		p.lost = true
+	}
+}
+
+// terminate marks the module dead without a warning, because it is
+// shut down
+func (p *process) terminate() {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.dead) == 0 {
+		p.setDead("is terminated")
+	}
+}
+
+// err returns a ModuleError if the module is dead
+func (p *process) err() error {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.dead) == 0 {
+		return nil
+	}
+	return ModuleError{Module: p.module, Msg: p.dead, Stderr: append([]string{}, p.stderr...)}
+}
+
+// processExited is called when the module process ends
+func (p *process) processExited(err error) {
+	reason := "exited"
+	if err != nil {
+		reason = fmt.Sprintf("exited: %v", err)
+	}
+	p.Lock()
+	p.exited = true
+	close(p.exitCh)
+	connected := p.connected
+	if !connected {
+		reason += " before connecting"
+	}
+	if p.lost {
+		p.dead = ""
+		p.lost = false
+	}
+	p.Unlock()
+	p.markDead(reason)
+	if !connected {
+		p.connCh <- p.err()
+	}
+}
+
+// kill kills the module process if it is running. The module runs in
+// its own process group, so the children of the module script, which
+// keep its output open, are killed as well
+func (p *process) kill() {
+	p.Lock()
+	defer p.Unlock()
+	if p.cmd != nil && p.cmd.Process != nil && !p.exited {
+		syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// waitDead waits until the module is marked dead and its process
+// exits, or until the timeout expires. Returns the ModuleError if the
+// module is dead
+func (p *process) waitDead(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-p.deadCh:
+	case <-timer.C:
+		return p.err()
+	}
+	p.Lock()
+	started := p.cmd != nil
+	p.Unlock()
+	if started {
+		select {
+		case <-p.exitCh:
+		case <-timer.C:
+		}
+	}
+	return p.err()
+}
+
+// Supervise pings the modules every interval until the lifecycle
+// manager is closed. A module that does not respond within
+// PingTimeout is marked dead and killed. Dead modules are restarted
+// when they are used next, at most MaxRestarts times within
+// RestartWindow
+func (mgr *LifecycleManager) Supervise(interval time.Duration) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	if mgr.stopCh != nil {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	mgr.stopCh = stop
+	mgr.supervisorDone = done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				mgr.pingAll(interval)
+			}
+		}
+	}()
+}
+
+// recentRestarts returns the restart times of the module within the
+// restart window. The manager must be locked
+func (mgr *LifecycleManager) recentRestarts(module string) []time.Time {
+	restarts := mgr.restarts[module]
+	if mgr.RestartWindow <= 0 {
+		return restarts
+	}
+	since := time.Now().Add(-mgr.RestartWindow)
+	ret := make([]time.Time, 0, len(restarts))
+	for _, t := range restarts {
+		if t.After(since) {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// pingAll pings the live modules that were not used during the last
+// interval
+func (mgr *LifecycleManager) pingAll(interval time.Duration) {
+	mgr.RLock()
+	modules := make([]*moduleInfo, 0, len(mgr.modules))
+	for _, m := range mgr.modules {
+		modules = append(modules, m)
+	}
+	timeout := mgr.PingTimeout
+	mgr.RUnlock()
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	for _, m := range modules {
+		if m.proc.err() != nil || time.Since(m.lastSeen()) < interval {
+			continue
+		}
+		if err := m.Ping(timeout); err != nil {
+			m.proc.markDead(fmt.Sprintf("is not responding: %v", err))
+			m.proc.kill()
+		}
+	}
+}
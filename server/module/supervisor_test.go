@@ -0,0 +1,162 @@
+package module
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/pb"
+)
+
+func startTestManager(t *testing.T, cacheDir string) (*LifecycleManager, func()) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	dir, _ := filepath.Abs("testdata")
+	lmgr := NewLifecycleManager()
+	lmgr.ModuleLookupDirs = []string{dir}
+	lmgr.LocalModuleFunc = func(string, string, string, []byte) (server.Response, bool, error) {
+		return server.Response{}, false, nil
+	}
+	lmgr.BuildCache = NewBuildCache(cacheDir)
+	lmgr.RunModuleBinary = func(bin, dir string) error {
+		return lmgr.ExecModuleIn(dir, bin, addr)
+	}
+	lmgr.RunModuleScript = func(first bool, dir string) error {
+		return lmgr.ExecModule("/bin/sh", "-c", fmt.Sprintf("cd %s;/bin/sh ./module.w run %s", dir, addr))
+	}
+	grpcServer := grpc.NewServer()
+	pb.RegisterLifecycleServer(grpcServer, lmgr)
+	go grpcServer.Serve(listener)
+	return lmgr, func() {
+		lmgr.Close()
+		grpcServer.Stop()
+	}
+}
+
+func TestRestart(t *testing.T) {
+	cacheDir, err := ioutil.TempDir("", "supervisor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cacheDir)
+	lmgr, stop := startTestManager(t, cacheDir)
+	defer stop()
+	lmgr.MaxRestarts = 1
+	lmgr.Supervise(time.Hour)
+
+	if _, err := lmgr.SendRequest("", "crash", "Ok", nil); err != nil {
+		t.Fatalf("Ok failed: %v", err)
+	}
+	_, err = lmgr.SendRequest("", "crash", "Crash", nil)
+	if _, ok := err.(ModuleError); !ok {
+		t.Fatalf("Expecting module error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "crashing on purpose") || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Expecting exit status and stderr, got %v", err)
+	}
+	// The module is restarted
+	if _, err := lmgr.SendRequest("", "crash", "Ok", nil); err != nil {
+		t.Fatalf("Ok after restart failed: %v", err)
+	}
+	lmgr.SendRequest("", "crash", "Crash", nil)
+	// Restart limit is reached
+	_, err = lmgr.SendRequest("", "crash", "Ok", nil)
+	if err == nil || !strings.Contains(err.Error(), "not restarting") {
+		t.Errorf("Expecting restart limit error, got %v", err)
+	}
+	// The dead module is removed, and it is not restarted until the
+	// restart window passes
+	lmgr.Lock()
+	if _, ok := lmgr.modules["crash"]; ok {
+		t.Errorf("Dead module is not removed")
+	}
+	if _, ok := lmgr.dead["crash"]; !ok || len(lmgr.restarts["crash"]) != 1 {
+		t.Errorf("Wrong restart state: %v %v", lmgr.dead, lmgr.restarts)
+	}
+	lmgr.restarts["crash"][0] = time.Now().Add(-2 * lmgr.RestartWindow)
+	lmgr.Unlock()
+	if _, err := lmgr.SendRequest("", "crash", "Ok", nil); err != nil {
+		t.Fatalf("Ok after restart window failed: %v", err)
+	}
+	lmgr.Lock()
+	if _, ok := lmgr.dead["crash"]; ok || len(lmgr.restarts["crash"]) != 1 {
+		t.Errorf("Wrong restart state: %v %v", lmgr.dead, lmgr.restarts)
+	}
+	lmgr.Unlock()
+	lmgr.Close()
+	if len(lmgr.modules) != 0 {
+		t.Errorf("Modules are not shut down: %v", lmgr.modules)
+	}
+}
+
+func TestExitBeforeConnect(t *testing.T) {
+	lmgr, stop := startTestManager(t, "")
+	defer stop()
+	lmgr.BuildCache = nil
+
+	done := make(chan error)
+	go func() {
+		_, err := lmgr.SendRequest("", "early", "Ok", nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "cannot start") || !strings.Contains(err.Error(), "before connecting") {
+			t.Errorf("Expecting exit error with stderr, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Module load did not fail")
+	}
+}
+
+func TestKillHangingModule(t *testing.T) {
+	lmgr, stop := startTestManager(t, "")
+	defer stop()
+	lmgr.BuildCache = nil
+
+	done := make(chan error)
+	go func() {
+		_, err := lmgr.SendRequest("", "hang", "Ok", nil)
+		done <- err
+	}()
+	// Wait until the module is started, and kill it
+	var proc *process
+	for i := 0; i < 1000 && proc == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+		lmgr.procMu.Lock()
+		if p := lmgr.nextProcess; p != nil {
+			p.Lock()
+			if p.cmd != nil && p.cmd.Process != nil {
+				proc = p
+			}
+			p.Unlock()
+		}
+		lmgr.procMu.Unlock()
+	}
+	if proc == nil {
+		t.Fatal("Module is not started")
+	}
+	proc.kill()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "before connecting") {
+			t.Errorf("Expecting exit error, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		// Kill what is left, so the manager can be stopped
+		syscall.Kill(-proc.cmd.Process.Pid, syscall.SIGKILL)
+		t.Fatal("Killed module did not exit")
+	}
+}
@@ -0,0 +1,21 @@
+// This module is used by the supervisor tests. Crash exits the
+// module after writing to stderr
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bserdar/watermelon/client"
+)
+
+var _ = client.Export("Ok", func(*client.Session) {})
+
+var _ = client.Export("Crash", func(*client.Session) {
+	fmt.Fprintln(os.Stderr, "crashing on purpose")
+	os.Exit(3)
+})
+
+func main() {
+	client.Run(os.Args[1:], nil, nil)
+}
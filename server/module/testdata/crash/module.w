@@ -0,0 +1,4 @@
+#!/bin/sh
+
+shift
+go run . $*
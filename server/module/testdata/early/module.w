@@ -0,0 +1,4 @@
+#!/bin/sh
+
+echo "cannot start" >&2
+exit 4
@@ -0,0 +1,5 @@
+#!/bin/sh
+
+# This module never connects. The child keeps the output of the
+# module open after the script is killed
+sleep 600
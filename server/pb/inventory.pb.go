@@ -4,10 +4,12 @@
 package pb
 
 import (
+	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
-	context "golang.org/x/net/context"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -20,7 +22,7 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 // InvId contains an inventory id
 type InvId struct {
@@ -341,6 +343,215 @@ func (m *PropertySet_KVS) GetValues() []string {
 	return nil
 }
 
+// PatternSet contains glob or regex patterns. A selector using a
+// pattern set matches if any of the patterns match
+type PatternSet struct {
+	Patterns             []string `protobuf:"bytes,1,rep,name=Patterns,proto3" json:"Patterns,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PatternSet) Reset()         { *m = PatternSet{} }
+func (m *PatternSet) String() string { return proto.CompactTextString(m) }
+func (*PatternSet) ProtoMessage()    {}
+func (*PatternSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{6}
+}
+
+func (m *PatternSet) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PatternSet.Unmarshal(m, b)
+}
+func (m *PatternSet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PatternSet.Marshal(b, m, deterministic)
+}
+func (m *PatternSet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PatternSet.Merge(m, src)
+}
+func (m *PatternSet) XXX_Size() int {
+	return xxx_messageInfo_PatternSet.Size(m)
+}
+func (m *PatternSet) XXX_DiscardUnknown() {
+	xxx_messageInfo_PatternSet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PatternSet proto.InternalMessageInfo
+
+func (m *PatternSet) GetPatterns() []string {
+	if m != nil {
+		return m.Patterns
+	}
+	return nil
+}
+
+// CIDRSet contains network addresses in CIDR notation, e.g. 10.20.0.0/16
+type CIDRSet struct {
+	CIDRs                []string `protobuf:"bytes,1,rep,name=CIDRs,proto3" json:"CIDRs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CIDRSet) Reset()         { *m = CIDRSet{} }
+func (m *CIDRSet) String() string { return proto.CompactTextString(m) }
+func (*CIDRSet) ProtoMessage()    {}
+func (*CIDRSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{7}
+}
+
+func (m *CIDRSet) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CIDRSet.Unmarshal(m, b)
+}
+func (m *CIDRSet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CIDRSet.Marshal(b, m, deterministic)
+}
+func (m *CIDRSet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CIDRSet.Merge(m, src)
+}
+func (m *CIDRSet) XXX_Size() int {
+	return xxx_messageInfo_CIDRSet.Size(m)
+}
+func (m *CIDRSet) XXX_DiscardUnknown() {
+	xxx_messageInfo_CIDRSet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CIDRSet proto.InternalMessageInfo
+
+func (m *CIDRSet) GetCIDRs() []string {
+	if m != nil {
+		return m.CIDRs
+	}
+	return nil
+}
+
+// PropertyRegex selects hosts whose property value matches the regex
+type PropertyRegex struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Regex                string   `protobuf:"bytes,2,opt,name=Regex,proto3" json:"Regex,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PropertyRegex) Reset()         { *m = PropertyRegex{} }
+func (m *PropertyRegex) String() string { return proto.CompactTextString(m) }
+func (*PropertyRegex) ProtoMessage()    {}
+func (*PropertyRegex) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{8}
+}
+
+func (m *PropertyRegex) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropertyRegex.Unmarshal(m, b)
+}
+func (m *PropertyRegex) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropertyRegex.Marshal(b, m, deterministic)
+}
+func (m *PropertyRegex) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropertyRegex.Merge(m, src)
+}
+func (m *PropertyRegex) XXX_Size() int {
+	return xxx_messageInfo_PropertyRegex.Size(m)
+}
+func (m *PropertyRegex) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropertyRegex.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropertyRegex proto.InternalMessageInfo
+
+func (m *PropertyRegex) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *PropertyRegex) GetRegex() string {
+	if m != nil {
+		return m.Regex
+	}
+	return ""
+}
+
+// PropertyKeys selects hosts having all of the property keys
+type PropertyKeys struct {
+	Keys                 []string `protobuf:"bytes,1,rep,name=Keys,proto3" json:"Keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PropertyKeys) Reset()         { *m = PropertyKeys{} }
+func (m *PropertyKeys) String() string { return proto.CompactTextString(m) }
+func (*PropertyKeys) ProtoMessage()    {}
+func (*PropertyKeys) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{9}
+}
+
+func (m *PropertyKeys) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PropertyKeys.Unmarshal(m, b)
+}
+func (m *PropertyKeys) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PropertyKeys.Marshal(b, m, deterministic)
+}
+func (m *PropertyKeys) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PropertyKeys.Merge(m, src)
+}
+func (m *PropertyKeys) XXX_Size() int {
+	return xxx_messageInfo_PropertyKeys.Size(m)
+}
+func (m *PropertyKeys) XXX_DiscardUnknown() {
+	xxx_messageInfo_PropertyKeys.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PropertyKeys proto.InternalMessageInfo
+
+func (m *PropertyKeys) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+// SelectorList contains the operands of a composite selector
+type SelectorList struct {
+	Selectors            []*Selector `protobuf:"bytes,1,rep,name=Selectors,proto3" json:"Selectors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *SelectorList) Reset()         { *m = SelectorList{} }
+func (m *SelectorList) String() string { return proto.CompactTextString(m) }
+func (*SelectorList) ProtoMessage()    {}
+func (*SelectorList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{10}
+}
+
+func (m *SelectorList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SelectorList.Unmarshal(m, b)
+}
+func (m *SelectorList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SelectorList.Marshal(b, m, deterministic)
+}
+func (m *SelectorList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SelectorList.Merge(m, src)
+}
+func (m *SelectorList) XXX_Size() int {
+	return xxx_messageInfo_SelectorList.Size(m)
+}
+func (m *SelectorList) XXX_DiscardUnknown() {
+	xxx_messageInfo_SelectorList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SelectorList proto.InternalMessageInfo
+
+func (m *SelectorList) GetSelectors() []*Selector {
+	if m != nil {
+		return m.Selectors
+	}
+	return nil
+}
+
 // Selector contains one of the host selection methods
 type Selector struct {
 	// Types that are valid to be assigned to Select:
@@ -350,6 +561,15 @@ type Selector struct {
 	//	*Selector_ByID
 	//	*Selector_HasAnyProperty
 	//	*Selector_HasAllProperty
+	//	*Selector_And
+	//	*Selector_Or
+	//	*Selector_Not
+	//	*Selector_Expr
+	//	*Selector_IDGlob
+	//	*Selector_IDRegex
+	//	*Selector_InCIDR
+	//	*Selector_HasPropertyRegex
+	//	*Selector_HasProperties
 	Select               isSelector_Select `protobuf_oneof:"select"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
@@ -360,7 +580,7 @@ func (m *Selector) Reset()         { *m = Selector{} }
 func (m *Selector) String() string { return proto.CompactTextString(m) }
 func (*Selector) ProtoMessage()    {}
 func (*Selector) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7173caedb7c6ae96, []int{6}
+	return fileDescriptor_7173caedb7c6ae96, []int{11}
 }
 
 func (m *Selector) XXX_Unmarshal(b []byte) error {
@@ -409,6 +629,42 @@ type Selector_HasAllProperty struct {
 	HasAllProperty *PropertySet `protobuf:"bytes,7,opt,name=HasAllProperty,proto3,oneof"`
 }
 
+type Selector_And struct {
+	And *SelectorList `protobuf:"bytes,8,opt,name=And,proto3,oneof"`
+}
+
+type Selector_Or struct {
+	Or *SelectorList `protobuf:"bytes,9,opt,name=Or,proto3,oneof"`
+}
+
+type Selector_Not struct {
+	Not *Selector `protobuf:"bytes,10,opt,name=Not,proto3,oneof"`
+}
+
+type Selector_Expr struct {
+	Expr string `protobuf:"bytes,11,opt,name=Expr,proto3,oneof"`
+}
+
+type Selector_IDGlob struct {
+	IDGlob *PatternSet `protobuf:"bytes,12,opt,name=IDGlob,proto3,oneof"`
+}
+
+type Selector_IDRegex struct {
+	IDRegex *PatternSet `protobuf:"bytes,13,opt,name=IDRegex,proto3,oneof"`
+}
+
+type Selector_InCIDR struct {
+	InCIDR *CIDRSet `protobuf:"bytes,14,opt,name=InCIDR,proto3,oneof"`
+}
+
+type Selector_HasPropertyRegex struct {
+	HasPropertyRegex *PropertyRegex `protobuf:"bytes,15,opt,name=HasPropertyRegex,proto3,oneof"`
+}
+
+type Selector_HasProperties struct {
+	HasProperties *PropertyKeys `protobuf:"bytes,16,opt,name=HasProperties,proto3,oneof"`
+}
+
 func (*Selector_HasAllLabels) isSelector_Select() {}
 
 func (*Selector_HasAnyLabel) isSelector_Select() {}
@@ -421,6 +677,24 @@ func (*Selector_HasAnyProperty) isSelector_Select() {}
 
 func (*Selector_HasAllProperty) isSelector_Select() {}
 
+func (*Selector_And) isSelector_Select() {}
+
+func (*Selector_Or) isSelector_Select() {}
+
+func (*Selector_Not) isSelector_Select() {}
+
+func (*Selector_Expr) isSelector_Select() {}
+
+func (*Selector_IDGlob) isSelector_Select() {}
+
+func (*Selector_IDRegex) isSelector_Select() {}
+
+func (*Selector_InCIDR) isSelector_Select() {}
+
+func (*Selector_HasPropertyRegex) isSelector_Select() {}
+
+func (*Selector_HasProperties) isSelector_Select() {}
+
 func (m *Selector) GetSelect() isSelector_Select {
 	if m != nil {
 		return m.Select
@@ -470,154 +744,88 @@ func (m *Selector) GetHasAllProperty() *PropertySet {
 	return nil
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*Selector) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _Selector_OneofMarshaler, _Selector_OneofUnmarshaler, _Selector_OneofSizer, []interface{}{
-		(*Selector_HasAllLabels)(nil),
-		(*Selector_HasAnyLabel)(nil),
-		(*Selector_HasNoneLabels)(nil),
-		(*Selector_ByID)(nil),
-		(*Selector_HasAnyProperty)(nil),
-		(*Selector_HasAllProperty)(nil),
+func (m *Selector) GetAnd() *SelectorList {
+	if x, ok := m.GetSelect().(*Selector_And); ok {
+		return x.And
+	}
+	return nil
+}
+
+func (m *Selector) GetOr() *SelectorList {
+	if x, ok := m.GetSelect().(*Selector_Or); ok {
+		return x.Or
+	}
+	return nil
+}
+
+func (m *Selector) GetNot() *Selector {
+	if x, ok := m.GetSelect().(*Selector_Not); ok {
+		return x.Not
+	}
+	return nil
+}
+
+func (m *Selector) GetExpr() string {
+	if x, ok := m.GetSelect().(*Selector_Expr); ok {
+		return x.Expr
 	}
+	return ""
 }
 
-func _Selector_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*Selector)
-	// select
-	switch x := m.Select.(type) {
-	case *Selector_HasAllLabels:
-		b.EncodeVarint(1<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.HasAllLabels); err != nil {
-			return err
-		}
-	case *Selector_HasAnyLabel:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.HasAnyLabel); err != nil {
-			return err
-		}
-	case *Selector_HasNoneLabels:
-		b.EncodeVarint(3<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.HasNoneLabels); err != nil {
-			return err
-		}
-	case *Selector_ByID:
-		b.EncodeVarint(4<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.ByID); err != nil {
-			return err
-		}
-	case *Selector_HasAnyProperty:
-		b.EncodeVarint(6<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.HasAnyProperty); err != nil {
-			return err
-		}
-	case *Selector_HasAllProperty:
-		b.EncodeVarint(7<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.HasAllProperty); err != nil {
-			return err
-		}
-	case nil:
-	default:
-		return fmt.Errorf("Selector.Select has unexpected type %T", x)
+func (m *Selector) GetIDGlob() *PatternSet {
+	if x, ok := m.GetSelect().(*Selector_IDGlob); ok {
+		return x.IDGlob
 	}
 	return nil
 }
 
-func _Selector_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*Selector)
-	switch tag {
-	case 1: // select.HasAllLabels
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(LabelSet)
-		err := b.DecodeMessage(msg)
-		m.Select = &Selector_HasAllLabels{msg}
-		return true, err
-	case 2: // select.HasAnyLabel
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(LabelSet)
-		err := b.DecodeMessage(msg)
-		m.Select = &Selector_HasAnyLabel{msg}
-		return true, err
-	case 3: // select.HasNoneLabels
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(LabelSet)
-		err := b.DecodeMessage(msg)
-		m.Select = &Selector_HasNoneLabels{msg}
-		return true, err
-	case 4: // select.ByID
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(HostIdSet)
-		err := b.DecodeMessage(msg)
-		m.Select = &Selector_ByID{msg}
-		return true, err
-	case 6: // select.HasAnyProperty
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(PropertySet)
-		err := b.DecodeMessage(msg)
-		m.Select = &Selector_HasAnyProperty{msg}
-		return true, err
-	case 7: // select.HasAllProperty
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(PropertySet)
-		err := b.DecodeMessage(msg)
-		m.Select = &Selector_HasAllProperty{msg}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _Selector_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*Selector)
-	// select
-	switch x := m.Select.(type) {
-	case *Selector_HasAllLabels:
-		s := proto.Size(x.HasAllLabels)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Selector_HasAnyLabel:
-		s := proto.Size(x.HasAnyLabel)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Selector_HasNoneLabels:
-		s := proto.Size(x.HasNoneLabels)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Selector_ByID:
-		s := proto.Size(x.ByID)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Selector_HasAnyProperty:
-		s := proto.Size(x.HasAnyProperty)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Selector_HasAllProperty:
-		s := proto.Size(x.HasAllProperty)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
+func (m *Selector) GetIDRegex() *PatternSet {
+	if x, ok := m.GetSelect().(*Selector_IDRegex); ok {
+		return x.IDRegex
+	}
+	return nil
+}
+
+func (m *Selector) GetInCIDR() *CIDRSet {
+	if x, ok := m.GetSelect().(*Selector_InCIDR); ok {
+		return x.InCIDR
+	}
+	return nil
+}
+
+func (m *Selector) GetHasPropertyRegex() *PropertyRegex {
+	if x, ok := m.GetSelect().(*Selector_HasPropertyRegex); ok {
+		return x.HasPropertyRegex
+	}
+	return nil
+}
+
+func (m *Selector) GetHasProperties() *PropertyKeys {
+	if x, ok := m.GetSelect().(*Selector_HasProperties); ok {
+		return x.HasProperties
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Selector) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Selector_HasAllLabels)(nil),
+		(*Selector_HasAnyLabel)(nil),
+		(*Selector_HasNoneLabels)(nil),
+		(*Selector_ByID)(nil),
+		(*Selector_HasAnyProperty)(nil),
+		(*Selector_HasAllProperty)(nil),
+		(*Selector_And)(nil),
+		(*Selector_Or)(nil),
+		(*Selector_Not)(nil),
+		(*Selector_Expr)(nil),
+		(*Selector_IDGlob)(nil),
+		(*Selector_IDRegex)(nil),
+		(*Selector_InCIDR)(nil),
+		(*Selector_HasPropertyRegex)(nil),
+		(*Selector_HasProperties)(nil),
+	}
 }
 
 type InvUnionRequest struct {
@@ -633,7 +841,7 @@ func (m *InvUnionRequest) Reset()         { *m = InvUnionRequest{} }
 func (m *InvUnionRequest) String() string { return proto.CompactTextString(m) }
 func (*InvUnionRequest) ProtoMessage()    {}
 func (*InvUnionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7173caedb7c6ae96, []int{7}
+	return fileDescriptor_7173caedb7c6ae96, []int{12}
 }
 
 func (m *InvUnionRequest) XXX_Unmarshal(b []byte) error {
@@ -682,7 +890,7 @@ func (m *InvAddRequest) Reset()         { *m = InvAddRequest{} }
 func (m *InvAddRequest) String() string { return proto.CompactTextString(m) }
 func (*InvAddRequest) ProtoMessage()    {}
 func (*InvAddRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7173caedb7c6ae96, []int{8}
+	return fileDescriptor_7173caedb7c6ae96, []int{13}
 }
 
 func (m *InvAddRequest) XXX_Unmarshal(b []byte) error {
@@ -737,7 +945,7 @@ func (m *HostIds) Reset()         { *m = HostIds{} }
 func (m *HostIds) String() string { return proto.CompactTextString(m) }
 func (*HostIds) ProtoMessage()    {}
 func (*HostIds) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7173caedb7c6ae96, []int{9}
+	return fileDescriptor_7173caedb7c6ae96, []int{14}
 }
 
 func (m *HostIds) XXX_Unmarshal(b []byte) error {
@@ -785,7 +993,7 @@ func (m *HostInfos) Reset()         { *m = HostInfos{} }
 func (m *HostInfos) String() string { return proto.CompactTextString(m) }
 func (*HostInfos) ProtoMessage()    {}
 func (*HostInfos) Descriptor() ([]byte, []int) {
-	return fileDescriptor_7173caedb7c6ae96, []int{10}
+	return fileDescriptor_7173caedb7c6ae96, []int{15}
 }
 
 func (m *HostInfos) XXX_Unmarshal(b []byte) error {
@@ -813,6 +1021,408 @@ func (m *HostInfos) GetHostInfos() []*HostInfo {
 	return nil
 }
 
+// SSHConfig contains the ssh settings of a new host
+type SSHConfig struct {
+	Hostname string `protobuf:"bytes,1,opt,name=Hostname,proto3" json:"Hostname,omitempty"`
+	Network  string `protobuf:"bytes,2,opt,name=Network,proto3" json:"Network,omitempty"`
+	Port     int32  `protobuf:"varint,3,opt,name=Port,proto3" json:"Port,omitempty"`
+	User     string `protobuf:"bytes,4,opt,name=User,proto3" json:"User,omitempty"`
+	Password string `protobuf:"bytes,5,opt,name=Password,proto3" json:"Password,omitempty"`
+	Become   string `protobuf:"bytes,6,opt,name=Become,proto3" json:"Become,omitempty"`
+	// Bastion is the ID of the host used to reach this host
+	Bastion              string   `protobuf:"bytes,7,opt,name=Bastion,proto3" json:"Bastion,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SSHConfig) Reset()         { *m = SSHConfig{} }
+func (m *SSHConfig) String() string { return proto.CompactTextString(m) }
+func (*SSHConfig) ProtoMessage()    {}
+func (*SSHConfig) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{16}
+}
+
+func (m *SSHConfig) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SSHConfig.Unmarshal(m, b)
+}
+func (m *SSHConfig) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SSHConfig.Marshal(b, m, deterministic)
+}
+func (m *SSHConfig) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SSHConfig.Merge(m, src)
+}
+func (m *SSHConfig) XXX_Size() int {
+	return xxx_messageInfo_SSHConfig.Size(m)
+}
+func (m *SSHConfig) XXX_DiscardUnknown() {
+	xxx_messageInfo_SSHConfig.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SSHConfig proto.InternalMessageInfo
+
+func (m *SSHConfig) GetHostname() string {
+	if m != nil {
+		return m.Hostname
+	}
+	return ""
+}
+
+func (m *SSHConfig) GetNetwork() string {
+	if m != nil {
+		return m.Network
+	}
+	return ""
+}
+
+func (m *SSHConfig) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *SSHConfig) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *SSHConfig) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+func (m *SSHConfig) GetBecome() string {
+	if m != nil {
+		return m.Become
+	}
+	return ""
+}
+
+func (m *SSHConfig) GetBastion() string {
+	if m != nil {
+		return m.Bastion
+	}
+	return ""
+}
+
+// NewHost describes a host to add to the inventory
+type NewHost struct {
+	ID         string            `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Addresses  []*Address        `protobuf:"bytes,2,rep,name=Addresses,proto3" json:"Addresses,omitempty"`
+	Labels     []string          `protobuf:"bytes,3,rep,name=Labels,proto3" json:"Labels,omitempty"`
+	Properties map[string]string `protobuf:"bytes,4,rep,name=Properties,proto3" json:"Properties,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// The backend used to reach the host. Default is linux
+	Backend string `protobuf:"bytes,5,opt,name=Backend,proto3" json:"Backend,omitempty"`
+	// The wrapper command template for the exec backend
+	Wrapper string     `protobuf:"bytes,6,opt,name=Wrapper,proto3" json:"Wrapper,omitempty"`
+	SSH     *SSHConfig `protobuf:"bytes,7,opt,name=SSH,proto3" json:"SSH,omitempty"`
+	// Host configuration as JSON
+	Configuration        []byte   `protobuf:"bytes,8,opt,name=Configuration,proto3" json:"Configuration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NewHost) Reset()         { *m = NewHost{} }
+func (m *NewHost) String() string { return proto.CompactTextString(m) }
+func (*NewHost) ProtoMessage()    {}
+func (*NewHost) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{17}
+}
+
+func (m *NewHost) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NewHost.Unmarshal(m, b)
+}
+func (m *NewHost) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NewHost.Marshal(b, m, deterministic)
+}
+func (m *NewHost) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NewHost.Merge(m, src)
+}
+func (m *NewHost) XXX_Size() int {
+	return xxx_messageInfo_NewHost.Size(m)
+}
+func (m *NewHost) XXX_DiscardUnknown() {
+	xxx_messageInfo_NewHost.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NewHost proto.InternalMessageInfo
+
+func (m *NewHost) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *NewHost) GetAddresses() []*Address {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+func (m *NewHost) GetLabels() []string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *NewHost) GetProperties() map[string]string {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *NewHost) GetBackend() string {
+	if m != nil {
+		return m.Backend
+	}
+	return ""
+}
+
+func (m *NewHost) GetWrapper() string {
+	if m != nil {
+		return m.Wrapper
+	}
+	return ""
+}
+
+func (m *NewHost) GetSSH() *SSHConfig {
+	if m != nil {
+		return m.SSH
+	}
+	return nil
+}
+
+func (m *NewHost) GetConfiguration() []byte {
+	if m != nil {
+		return m.Configuration
+	}
+	return nil
+}
+
+type InvAddHostRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=Session,proto3" json:"Session,omitempty"`
+	Host                 *NewHost `protobuf:"bytes,2,opt,name=Host,proto3" json:"Host,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvAddHostRequest) Reset()         { *m = InvAddHostRequest{} }
+func (m *InvAddHostRequest) String() string { return proto.CompactTextString(m) }
+func (*InvAddHostRequest) ProtoMessage()    {}
+func (*InvAddHostRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{18}
+}
+
+func (m *InvAddHostRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvAddHostRequest.Unmarshal(m, b)
+}
+func (m *InvAddHostRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvAddHostRequest.Marshal(b, m, deterministic)
+}
+func (m *InvAddHostRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvAddHostRequest.Merge(m, src)
+}
+func (m *InvAddHostRequest) XXX_Size() int {
+	return xxx_messageInfo_InvAddHostRequest.Size(m)
+}
+func (m *InvAddHostRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvAddHostRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvAddHostRequest proto.InternalMessageInfo
+
+func (m *InvAddHostRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *InvAddHostRequest) GetHost() *NewHost {
+	if m != nil {
+		return m.Host
+	}
+	return nil
+}
+
+type InvRemoveHostRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=Session,proto3" json:"Session,omitempty"`
+	HostId               string   `protobuf:"bytes,2,opt,name=HostId,proto3" json:"HostId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvRemoveHostRequest) Reset()         { *m = InvRemoveHostRequest{} }
+func (m *InvRemoveHostRequest) String() string { return proto.CompactTextString(m) }
+func (*InvRemoveHostRequest) ProtoMessage()    {}
+func (*InvRemoveHostRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{19}
+}
+
+func (m *InvRemoveHostRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvRemoveHostRequest.Unmarshal(m, b)
+}
+func (m *InvRemoveHostRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvRemoveHostRequest.Marshal(b, m, deterministic)
+}
+func (m *InvRemoveHostRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvRemoveHostRequest.Merge(m, src)
+}
+func (m *InvRemoveHostRequest) XXX_Size() int {
+	return xxx_messageInfo_InvRemoveHostRequest.Size(m)
+}
+func (m *InvRemoveHostRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvRemoveHostRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvRemoveHostRequest proto.InternalMessageInfo
+
+func (m *InvRemoveHostRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *InvRemoveHostRequest) GetHostId() string {
+	if m != nil {
+		return m.HostId
+	}
+	return ""
+}
+
+type InvSetLabelsRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=Session,proto3" json:"Session,omitempty"`
+	HostId               string   `protobuf:"bytes,2,opt,name=HostId,proto3" json:"HostId,omitempty"`
+	Labels               []string `protobuf:"bytes,3,rep,name=Labels,proto3" json:"Labels,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvSetLabelsRequest) Reset()         { *m = InvSetLabelsRequest{} }
+func (m *InvSetLabelsRequest) String() string { return proto.CompactTextString(m) }
+func (*InvSetLabelsRequest) ProtoMessage()    {}
+func (*InvSetLabelsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{20}
+}
+
+func (m *InvSetLabelsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvSetLabelsRequest.Unmarshal(m, b)
+}
+func (m *InvSetLabelsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvSetLabelsRequest.Marshal(b, m, deterministic)
+}
+func (m *InvSetLabelsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvSetLabelsRequest.Merge(m, src)
+}
+func (m *InvSetLabelsRequest) XXX_Size() int {
+	return xxx_messageInfo_InvSetLabelsRequest.Size(m)
+}
+func (m *InvSetLabelsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvSetLabelsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvSetLabelsRequest proto.InternalMessageInfo
+
+func (m *InvSetLabelsRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *InvSetLabelsRequest) GetHostId() string {
+	if m != nil {
+		return m.HostId
+	}
+	return ""
+}
+
+func (m *InvSetLabelsRequest) GetLabels() []string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+type InvSetPropertiesRequest struct {
+	Session    string            `protobuf:"bytes,1,opt,name=Session,proto3" json:"Session,omitempty"`
+	HostId     string            `protobuf:"bytes,2,opt,name=HostId,proto3" json:"HostId,omitempty"`
+	Properties map[string]string `protobuf:"bytes,3,rep,name=Properties,proto3" json:"Properties,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// If true, the properties of the host are replaced. Otherwise the
+	// given properties are added to the existing ones
+	Replace              bool     `protobuf:"varint,4,opt,name=Replace,proto3" json:"Replace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InvSetPropertiesRequest) Reset()         { *m = InvSetPropertiesRequest{} }
+func (m *InvSetPropertiesRequest) String() string { return proto.CompactTextString(m) }
+func (*InvSetPropertiesRequest) ProtoMessage()    {}
+func (*InvSetPropertiesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7173caedb7c6ae96, []int{21}
+}
+
+func (m *InvSetPropertiesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InvSetPropertiesRequest.Unmarshal(m, b)
+}
+func (m *InvSetPropertiesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InvSetPropertiesRequest.Marshal(b, m, deterministic)
+}
+func (m *InvSetPropertiesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InvSetPropertiesRequest.Merge(m, src)
+}
+func (m *InvSetPropertiesRequest) XXX_Size() int {
+	return xxx_messageInfo_InvSetPropertiesRequest.Size(m)
+}
+func (m *InvSetPropertiesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InvSetPropertiesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InvSetPropertiesRequest proto.InternalMessageInfo
+
+func (m *InvSetPropertiesRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *InvSetPropertiesRequest) GetHostId() string {
+	if m != nil {
+		return m.HostId
+	}
+	return ""
+}
+
+func (m *InvSetPropertiesRequest) GetProperties() map[string]string {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *InvSetPropertiesRequest) GetReplace() bool {
+	if m != nil {
+		return m.Replace
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*InvId)(nil), "pb.InvId")
 	proto.RegisterType((*InvIdRequest)(nil), "pb.InvIdRequest")
@@ -821,57 +1431,108 @@ func init() {
 	proto.RegisterType((*HostIdSet)(nil), "pb.HostIdSet")
 	proto.RegisterType((*PropertySet)(nil), "pb.PropertySet")
 	proto.RegisterType((*PropertySet_KVS)(nil), "pb.PropertySet.KVS")
+	proto.RegisterType((*PatternSet)(nil), "pb.PatternSet")
+	proto.RegisterType((*CIDRSet)(nil), "pb.CIDRSet")
+	proto.RegisterType((*PropertyRegex)(nil), "pb.PropertyRegex")
+	proto.RegisterType((*PropertyKeys)(nil), "pb.PropertyKeys")
+	proto.RegisterType((*SelectorList)(nil), "pb.SelectorList")
 	proto.RegisterType((*Selector)(nil), "pb.Selector")
 	proto.RegisterType((*InvUnionRequest)(nil), "pb.InvUnionRequest")
 	proto.RegisterType((*InvAddRequest)(nil), "pb.InvAddRequest")
 	proto.RegisterType((*HostIds)(nil), "pb.HostIds")
 	proto.RegisterType((*HostInfos)(nil), "pb.HostInfos")
+	proto.RegisterType((*SSHConfig)(nil), "pb.SSHConfig")
+	proto.RegisterType((*NewHost)(nil), "pb.NewHost")
+	proto.RegisterMapType((map[string]string)(nil), "pb.NewHost.PropertiesEntry")
+	proto.RegisterType((*InvAddHostRequest)(nil), "pb.InvAddHostRequest")
+	proto.RegisterType((*InvRemoveHostRequest)(nil), "pb.InvRemoveHostRequest")
+	proto.RegisterType((*InvSetLabelsRequest)(nil), "pb.InvSetLabelsRequest")
+	proto.RegisterType((*InvSetPropertiesRequest)(nil), "pb.InvSetPropertiesRequest")
+	proto.RegisterMapType((map[string]string)(nil), "pb.InvSetPropertiesRequest.PropertiesEntry")
 }
 
 func init() { proto.RegisterFile("inventory.proto", fileDescriptor_7173caedb7c6ae96) }
 
 var fileDescriptor_7173caedb7c6ae96 = []byte{
-	// 635 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x94, 0x5f, 0x6f, 0xd3, 0x3c,
-	0x14, 0xc6, 0xd7, 0xa4, 0xeb, 0x9f, 0x93, 0x76, 0xdb, 0xeb, 0xbd, 0x82, 0xa8, 0x12, 0xd3, 0x94,
-	0x49, 0x6c, 0x63, 0x52, 0x8b, 0x3a, 0x24, 0xe0, 0x82, 0x8b, 0x4d, 0x1d, 0xd4, 0x1a, 0x20, 0x94,
-	0x8a, 0x5d, 0x70, 0x45, 0xb2, 0x9c, 0xb1, 0x8a, 0xd4, 0x2e, 0x71, 0x1a, 0xd4, 0xcf, 0xc8, 0x15,
-	0xdf, 0x08, 0xd9, 0xb1, 0x5b, 0xb7, 0x94, 0xdd, 0xf9, 0xf8, 0xf9, 0x1d, 0x9f, 0xe3, 0x27, 0x27,
-	0x86, 0xdd, 0x31, 0x2b, 0x90, 0xe5, 0x3c, 0x9b, 0x77, 0xa7, 0x19, 0xcf, 0x39, 0x71, 0xa6, 0x71,
-	0x07, 0xee, 0xb9, 0xc8, 0xcb, 0xb8, 0xe3, 0xe1, 0x64, 0x9a, 0x6b, 0x31, 0x78, 0x0c, 0xdb, 0x94,
-	0x15, 0x34, 0x21, 0x3b, 0xe0, 0xd0, 0x81, 0x5f, 0x39, 0xac, 0x9c, 0x34, 0x43, 0x87, 0x0e, 0x82,
-	0x57, 0xd0, 0x52, 0x42, 0x88, 0x3f, 0x66, 0x28, 0xf2, 0x75, 0x9d, 0xf8, 0x50, 0x1f, 0xa1, 0x10,
-	0x63, 0xce, 0x7c, 0x47, 0x6d, 0x9a, 0x30, 0xf8, 0x0a, 0x7b, 0x94, 0x15, 0x23, 0x4c, 0xf1, 0x36,
-	0x37, 0xd9, 0x04, 0xaa, 0x6f, 0x33, 0x3e, 0xd1, 0xf9, 0x6a, 0x4d, 0x0e, 0xc0, 0x1d, 0x61, 0xea,
-	0xbb, 0x87, 0xee, 0x89, 0xd7, 0x6f, 0x75, 0xa7, 0x71, 0xb7, 0xcc, 0xe1, 0x59, 0x28, 0x05, 0xbb,
-	0x42, 0x75, 0xb5, 0x42, 0x00, 0x8d, 0xf7, 0x51, 0x8c, 0xe9, 0x08, 0x73, 0xf2, 0x08, 0x6a, 0x6a,
-	0x2d, 0xfc, 0xca, 0xa1, 0x7b, 0xd2, 0x0c, 0x75, 0x14, 0x3c, 0x81, 0xe6, 0x90, 0x8b, 0x9c, 0x26,
-	0x12, 0xda, 0x03, 0x97, 0x0e, 0x0c, 0x21, 0x97, 0x81, 0x00, 0xef, 0x53, 0xc6, 0xa7, 0x98, 0xe5,
-	0x73, 0x09, 0x9c, 0x03, 0xe8, 0x70, 0x8c, 0x25, 0xe7, 0xf5, 0xf7, 0x65, 0x4b, 0x16, 0xd4, 0xbd,
-	0xbe, 0x19, 0x85, 0x16, 0xd6, 0xe9, 0x81, 0x7b, 0x7d, 0x33, 0x92, 0x87, 0x5f, 0xe3, 0x5c, 0x5f,
-	0x4d, 0x2e, 0x65, 0x4f, 0x37, 0x51, 0x3a, 0x43, 0xe1, 0x3b, 0x65, 0x4f, 0x65, 0x14, 0xfc, 0x72,
-	0xa0, 0x61, 0xee, 0x48, 0xfa, 0xd0, 0x1a, 0x46, 0xe2, 0x22, 0x4d, 0x17, 0xed, 0x57, 0x8c, 0x0f,
-	0xe6, 0x72, 0xc3, 0xad, 0x70, 0x85, 0x21, 0xcf, 0xc1, 0x93, 0x31, 0x9b, 0xab, 0x58, 0x19, 0xff,
-	0x77, 0x8a, 0x8d, 0x90, 0x17, 0xd0, 0x1e, 0x46, 0xe2, 0x23, 0x67, 0xa8, 0xcb, 0xb8, 0x1b, 0x73,
-	0x56, 0x21, 0x72, 0x04, 0xd5, 0xcb, 0x39, 0x1d, 0x28, 0xdf, 0xbd, 0x7e, 0x5b, 0xc2, 0x0b, 0x33,
-	0x87, 0x5b, 0xa1, 0x12, 0xc9, 0x6b, 0xd8, 0x29, 0x2b, 0x19, 0x8f, 0xfc, 0x9a, 0xc2, 0x77, 0xd7,
-	0x7c, 0x1b, 0x6e, 0x85, 0x6b, 0xa0, 0x49, 0x4d, 0xd3, 0x45, 0x6a, 0xfd, 0xc1, 0xd4, 0x25, 0x78,
-	0xd9, 0x80, 0x9a, 0x50, 0x16, 0x06, 0x57, 0xb0, 0x4b, 0x59, 0xf1, 0x99, 0x8d, 0x39, 0x33, 0x63,
-	0x26, 0x47, 0x86, 0xcf, 0xb2, 0x5b, 0x34, 0xdf, 0xda, 0x84, 0x0f, 0x8e, 0x6b, 0x9b, 0xb2, 0xe2,
-	0x22, 0x59, 0x4c, 0xba, 0x1c, 0x16, 0x56, 0x98, 0xef, 0x49, 0x59, 0x41, 0x8e, 0x60, 0x5b, 0x5e,
-	0x5f, 0x68, 0xc3, 0x57, 0xfd, 0x08, 0x4b, 0xcd, 0xae, 0xe0, 0xae, 0x56, 0x78, 0x03, 0xf5, 0x92,
-	0x56, 0x90, 0x5e, 0x9a, 0x06, 0x2d, 0xe5, 0x1f, 0x0d, 0xbe, 0xd4, 0x93, 0xcc, 0xee, 0xb8, 0x20,
-	0xcf, 0xac, 0x40, 0xcf, 0x69, 0x6b, 0xd1, 0x0e, 0xbb, 0xe3, 0xe1, 0x52, 0xee, 0xff, 0x76, 0xa0,
-	0x49, 0xcd, 0x63, 0x40, 0x4e, 0xa1, 0x56, 0xce, 0x1e, 0xf9, 0x5f, 0x26, 0xac, 0xff, 0xa2, 0x9d,
-	0xa6, 0xde, 0xa5, 0x09, 0x39, 0x86, 0x6d, 0x65, 0x2b, 0xd9, 0xd7, 0x7b, 0xb6, 0xc9, 0x36, 0x78,
-	0x00, 0xd5, 0x0f, 0xd1, 0x77, 0x24, 0xde, 0xd2, 0x11, 0x61, 0xeb, 0x47, 0xe0, 0x5e, 0x24, 0x09,
-	0xf9, 0x4f, 0xef, 0x2c, 0x4d, 0xb6, 0xa1, 0x33, 0x80, 0x77, 0x98, 0x1b, 0x1f, 0xf6, 0x16, 0x82,
-	0x41, 0xed, 0xc3, 0xc9, 0x29, 0x78, 0x06, 0x66, 0x77, 0x7c, 0xb5, 0x70, 0xdb, 0x36, 0x42, 0x90,
-	0x33, 0x68, 0x68, 0x74, 0xd3, 0xa9, 0x6b, 0xf0, 0x53, 0xa8, 0x87, 0x98, 0x62, 0x24, 0x70, 0x03,
-	0xab, 0x9a, 0xbd, 0x92, 0xaf, 0xe6, 0xe5, 0xe9, 0x97, 0xe3, 0x6f, 0xe3, 0xfc, 0x7e, 0x16, 0x77,
-	0x6f, 0xf9, 0xa4, 0x17, 0x0b, 0xcc, 0x92, 0x28, 0xeb, 0xfd, 0x8c, 0x72, 0xcc, 0x26, 0x98, 0x72,
-	0xd6, 0x13, 0x98, 0x15, 0x98, 0xf5, 0xa6, 0x71, 0x5c, 0x53, 0x2f, 0xec, 0xf9, 0x9f, 0x00, 0x00,
-	0x00, 0xff, 0xff, 0x34, 0xa3, 0x20, 0x98, 0x91, 0x05, 0x00, 0x00,
+	// 1239 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x57, 0xff, 0x72, 0x13, 0x37,
+	0x10, 0x8e, 0x7d, 0xfe, 0xb9, 0xb6, 0x93, 0x20, 0x52, 0xb8, 0x31, 0x53, 0xc8, 0x1c, 0xb4, 0x24,
+	0x30, 0xe3, 0xb4, 0xa1, 0x33, 0x50, 0x3a, 0x4c, 0x27, 0xc1, 0x29, 0x77, 0x13, 0x1a, 0x18, 0x79,
+	0xa0, 0x33, 0xfd, 0xa7, 0x3d, 0xc7, 0x02, 0x3c, 0x39, 0x4b, 0xae, 0x74, 0x71, 0xf0, 0xfb, 0xf4,
+	0x1d, 0xfa, 0x04, 0x7d, 0x82, 0x3e, 0x49, 0xdf, 0xa0, 0xb3, 0x3a, 0xc9, 0xa7, 0x33, 0x0e, 0xed,
+	0xd0, 0xbf, 0xac, 0x4f, 0xfb, 0xad, 0x76, 0x25, 0x7d, 0xbb, 0x3a, 0xc3, 0xc6, 0x98, 0xcf, 0x18,
+	0x4f, 0x85, 0x9c, 0xf7, 0xa6, 0x52, 0xa4, 0x82, 0x94, 0xa7, 0xc3, 0x2e, 0xbc, 0x13, 0x2a, 0xcd,
+	0x70, 0xb7, 0xc5, 0x26, 0xd3, 0xd4, 0x18, 0x83, 0xeb, 0x50, 0x8d, 0xf8, 0x2c, 0x1a, 0x91, 0x75,
+	0x28, 0x47, 0x7d, 0xbf, 0xb4, 0x5d, 0xda, 0x69, 0xd2, 0x72, 0xd4, 0x0f, 0x1e, 0x41, 0x5b, 0x1b,
+	0x28, 0xfb, 0xed, 0x9c, 0xa9, 0x74, 0xd9, 0x4e, 0x7c, 0xa8, 0x0f, 0x98, 0x52, 0x63, 0xc1, 0xfd,
+	0xb2, 0x9e, 0xb4, 0x30, 0xf8, 0x15, 0x36, 0x23, 0x3e, 0x1b, 0xb0, 0x84, 0x9d, 0xa6, 0xd6, 0x9b,
+	0x40, 0xe5, 0x07, 0x29, 0x26, 0xc6, 0x5f, 0x8f, 0xc9, 0x4d, 0xf0, 0x06, 0x2c, 0xf1, 0xbd, 0x6d,
+	0x6f, 0xa7, 0xb5, 0xdf, 0xee, 0x4d, 0x87, 0xbd, 0xcc, 0x47, 0x48, 0x8a, 0x06, 0x37, 0x42, 0xa5,
+	0x18, 0x21, 0x80, 0xc6, 0xf3, 0x78, 0xc8, 0x92, 0x01, 0x4b, 0xc9, 0x35, 0xa8, 0xe9, 0xb1, 0xf2,
+	0x4b, 0xdb, 0xde, 0x4e, 0x93, 0x1a, 0x14, 0x7c, 0x0e, 0xcd, 0x50, 0xa8, 0x34, 0x1a, 0x21, 0x69,
+	0x13, 0xbc, 0xa8, 0x6f, 0x19, 0x38, 0x0c, 0x14, 0xb4, 0x5e, 0x4a, 0x31, 0x65, 0x32, 0x9d, 0x23,
+	0xe1, 0x01, 0x80, 0x81, 0x63, 0x96, 0xf1, 0x5a, 0xfb, 0x57, 0x31, 0x25, 0x87, 0xd4, 0x3b, 0x7e,
+	0x3d, 0xa0, 0x0e, 0xad, 0xbb, 0x07, 0xde, 0xf1, 0xeb, 0x01, 0x2e, 0x7e, 0xcc, 0xe6, 0x66, 0x6b,
+	0x38, 0xc4, 0x9c, 0x5e, 0xc7, 0xc9, 0x39, 0x53, 0x7e, 0x39, 0xcb, 0x29, 0x43, 0xc1, 0x0e, 0xc0,
+	0xcb, 0x38, 0x4d, 0x99, 0xe4, 0x18, 0xb3, 0x0b, 0x0d, 0x83, 0x6c, 0x66, 0x0b, 0x1c, 0xdc, 0x82,
+	0xfa, 0xd3, 0xa8, 0x4f, 0x91, 0xb6, 0x05, 0x55, 0x1c, 0x5a, 0x4e, 0x06, 0x82, 0x87, 0xd0, 0xb1,
+	0xa9, 0x51, 0xf6, 0x96, 0xbd, 0x5f, 0x91, 0xc5, 0x16, 0x54, 0xb5, 0xc9, 0xdc, 0x4f, 0x06, 0x82,
+	0x00, 0xda, 0xd6, 0xf1, 0x98, 0xcd, 0x15, 0xde, 0x0c, 0xfe, 0x9a, 0xd5, 0xf5, 0x38, 0x78, 0x0c,
+	0x6d, 0x7b, 0x15, 0xcf, 0xc7, 0x2a, 0x25, 0xf7, 0xa0, 0x69, 0xb1, 0x3d, 0x9c, 0xe2, 0x7d, 0xe5,
+	0xe6, 0xe0, 0xcf, 0x2a, 0x34, 0x2c, 0x22, 0xfb, 0xd0, 0x0e, 0x63, 0x75, 0x90, 0x24, 0x8b, 0x2b,
+	0x2a, 0x59, 0x5f, 0x7b, 0x81, 0xe1, 0x1a, 0x2d, 0x70, 0xc8, 0x57, 0xd0, 0x42, 0xcc, 0xe7, 0x1a,
+	0xfb, 0xe5, 0x95, 0x2e, 0x2e, 0x85, 0x7c, 0x03, 0x9d, 0x30, 0x56, 0x27, 0x82, 0x33, 0x13, 0xc6,
+	0x5b, 0xe9, 0x53, 0x24, 0x91, 0xdb, 0x50, 0x39, 0x9c, 0x47, 0x7d, 0xad, 0xad, 0xd6, 0x7e, 0x07,
+	0xc9, 0x0b, 0xc1, 0x84, 0x6b, 0x54, 0x1b, 0xc9, 0xb7, 0xb0, 0x9e, 0x45, 0xb2, 0x67, 0xe6, 0xd7,
+	0x34, 0x7d, 0x63, 0x49, 0x1b, 0xe1, 0x1a, 0x5d, 0x22, 0x5a, 0xd7, 0x24, 0x59, 0xb8, 0xd6, 0x3f,
+	0xea, 0x9a, 0x13, 0xc9, 0x1d, 0xf0, 0x0e, 0xf8, 0xc8, 0x6f, 0x68, 0xfe, 0xa6, 0x7b, 0xd2, 0x78,
+	0x1d, 0xe1, 0x1a, 0x45, 0x33, 0x09, 0xa0, 0xfc, 0x42, 0xfa, 0xcd, 0x4b, 0x49, 0xe5, 0x17, 0x92,
+	0x6c, 0x83, 0x77, 0x22, 0x52, 0x1f, 0xf2, 0x03, 0xb1, 0x24, 0x5c, 0xe5, 0x44, 0xa0, 0xbc, 0x2a,
+	0x47, 0xef, 0xa7, 0xd2, 0x6f, 0xa1, 0x48, 0x70, 0xdf, 0x88, 0xc8, 0x0e, 0xd4, 0xa2, 0xfe, 0xb3,
+	0x44, 0x0c, 0xfd, 0xb6, 0x76, 0x5d, 0xd7, 0x49, 0x2f, 0xb4, 0x1b, 0xae, 0x51, 0x63, 0x27, 0xf7,
+	0xa0, 0x1e, 0xf5, 0x33, 0x9d, 0x75, 0x2e, 0xa1, 0x5a, 0x02, 0xf9, 0x02, 0x6a, 0x11, 0x47, 0xfd,
+	0xfa, 0xeb, 0x9a, 0xda, 0x42, 0xaa, 0xd1, 0xb9, 0x5e, 0x52, 0x1b, 0xc9, 0xf7, 0xb0, 0x19, 0xc6,
+	0xaa, 0x20, 0x6f, 0x7f, 0x43, 0x3b, 0x5c, 0x71, 0xcf, 0x4e, 0x1b, 0xc2, 0x35, 0xfa, 0x01, 0x99,
+	0x3c, 0x82, 0x4e, 0x3e, 0x87, 0x05, 0xbd, 0x99, 0x1f, 0x92, 0x2b, 0x7e, 0x23, 0x8a, 0x9c, 0x78,
+	0xd8, 0x80, 0x9a, 0xd2, 0x07, 0x14, 0x1c, 0xc1, 0x46, 0xc4, 0x67, 0xaf, 0xf8, 0x58, 0x70, 0xdb,
+	0xc4, 0xb0, 0x21, 0x89, 0x73, 0x79, 0xca, 0x6c, 0xb5, 0x58, 0xf8, 0xd1, 0x66, 0xd8, 0x89, 0xf8,
+	0xec, 0x60, 0xb4, 0xe8, 0xa3, 0xd8, 0x8a, 0xf8, 0xcc, 0xd6, 0x69, 0xc4, 0x67, 0xe4, 0x36, 0x54,
+	0x51, 0x78, 0xca, 0x2f, 0xaf, 0x50, 0x22, 0xcd, 0x6c, 0x6e, 0x04, 0xaf, 0x18, 0xe1, 0x09, 0xd4,
+	0x33, 0xb6, 0x26, 0x99, 0xa1, 0x4d, 0xd0, 0xb1, 0x5c, 0x92, 0xe0, 0x43, 0xd3, 0x27, 0xf9, 0x1b,
+	0xa1, 0xc8, 0x3d, 0x07, 0xb8, 0x85, 0x6e, 0x27, 0x69, 0x6e, 0x0e, 0xfe, 0x28, 0x41, 0x73, 0x30,
+	0x08, 0x9f, 0x0a, 0xfe, 0x66, 0xfc, 0x16, 0x9b, 0x19, 0x9a, 0x78, 0x3c, 0x61, 0x66, 0x6f, 0x0b,
+	0x8c, 0xc1, 0x4f, 0x58, 0x7a, 0x21, 0xe4, 0x99, 0x0d, 0x6e, 0x20, 0x36, 0x9f, 0x97, 0x42, 0xa6,
+	0x7a, 0x4b, 0x55, 0xaa, 0xc7, 0x38, 0xf7, 0x4a, 0x31, 0x69, 0x7a, 0xbe, 0x1e, 0x67, 0xad, 0x52,
+	0xa9, 0x0b, 0x21, 0x47, 0x7e, 0x35, 0x5b, 0xdd, 0x62, 0x6c, 0xb6, 0x87, 0xec, 0x54, 0x4c, 0x98,
+	0x2e, 0xcd, 0x26, 0x35, 0x08, 0xa3, 0x1e, 0xc6, 0x2a, 0xc5, 0x2d, 0xd7, 0xb3, 0xa8, 0x06, 0x06,
+	0x7f, 0x95, 0x31, 0xa1, 0x8b, 0x50, 0xac, 0x78, 0xd6, 0x76, 0xa1, 0x79, 0x30, 0x1a, 0x49, 0xa6,
+	0x94, 0xe9, 0xde, 0x46, 0xa5, 0x66, 0x92, 0xe6, 0x56, 0xe7, 0xe5, 0xf1, 0xdc, 0x97, 0x87, 0x7c,
+	0x57, 0x78, 0x4b, 0x2a, 0x7a, 0x8d, 0x1b, 0xb8, 0x86, 0x89, 0xd9, 0xcb, 0xad, 0x47, 0x3c, 0x95,
+	0x73, 0xf7, 0x4d, 0xc9, 0xb2, 0x3e, 0x3d, 0x63, 0xdc, 0x6e, 0xd4, 0x42, 0xb4, 0xfc, 0x24, 0xe3,
+	0xe9, 0x94, 0x49, 0xb3, 0x51, 0x0b, 0xc9, 0x2d, 0xf0, 0x06, 0x83, 0xd0, 0xaf, 0xe7, 0xf2, 0x59,
+	0xdc, 0x0b, 0x45, 0x0b, 0xb9, 0x03, 0x9d, 0x0c, 0x9e, 0xcb, 0x58, 0x1f, 0x08, 0x76, 0x96, 0x36,
+	0x2d, 0x4e, 0x76, 0x9f, 0xc0, 0xc6, 0x52, 0x66, 0x28, 0xd6, 0xb3, 0xfc, 0x51, 0x39, 0xcb, 0x1e,
+	0x95, 0x19, 0x3e, 0x66, 0xf6, 0x51, 0xd1, 0xe0, 0x71, 0xf9, 0x51, 0x29, 0x38, 0x81, 0x2b, 0x99,
+	0xd2, 0x71, 0x8f, 0x6e, 0xc9, 0x18, 0xdd, 0x95, 0x0a, 0xba, 0x23, 0xb7, 0xa0, 0x82, 0x44, 0x23,
+	0xfa, 0x96, 0x73, 0x3e, 0x54, 0x1b, 0x82, 0x10, 0xb6, 0x22, 0x3e, 0xa3, 0x6c, 0x22, 0x66, 0xec,
+	0xbf, 0x2d, 0x79, 0x0d, 0x6a, 0x99, 0xde, 0x4d, 0x72, 0x06, 0x05, 0xbf, 0xc0, 0x55, 0xfd, 0x41,
+	0x92, 0x66, 0x17, 0xf4, 0xc9, 0x0b, 0x5d, 0x76, 0xe3, 0xc1, 0xdf, 0x25, 0xb8, 0x9e, 0x45, 0xc8,
+	0x0f, 0xf0, 0xd3, 0xa3, 0x1c, 0x17, 0xf4, 0x93, 0x7d, 0x1e, 0xdd, 0xc7, 0xf3, 0xb9, 0x24, 0xc4,
+	0xbf, 0xe9, 0x89, 0xb2, 0x69, 0x12, 0x9f, 0x32, 0x5d, 0x50, 0x0d, 0x6a, 0xe1, 0xff, 0xbc, 0xee,
+	0xfd, 0xdf, 0x2b, 0xd0, 0x8c, 0xec, 0x97, 0x26, 0xd9, 0x85, 0x5a, 0xf6, 0xb0, 0x90, 0xad, 0x45,
+	0xa6, 0xce, 0xf7, 0x5f, 0xb7, 0x69, 0x66, 0xa3, 0x11, 0xb9, 0x0b, 0x55, 0xdd, 0x55, 0xc9, 0x55,
+	0x33, 0xe7, 0xf6, 0x58, 0x97, 0x78, 0x13, 0x2a, 0x3f, 0xc6, 0x67, 0x8c, 0xb4, 0xf2, 0x86, 0xa8,
+	0x5c, 0xfb, 0x6d, 0xf0, 0x0e, 0x46, 0x23, 0x72, 0xc5, 0xcc, 0xe4, 0x3d, 0xd6, 0x25, 0xdd, 0x07,
+	0x78, 0xc6, 0x52, 0xe3, 0x4d, 0x36, 0x17, 0x06, 0x4b, 0x75, 0x17, 0x27, 0xbb, 0xd0, 0xb2, 0x64,
+	0xfe, 0x46, 0x14, 0x03, 0x77, 0xdc, 0x3e, 0xa8, 0xc8, 0x7d, 0x68, 0x18, 0xea, 0xaa, 0x55, 0x97,
+	0xc8, 0x5f, 0xe2, 0x25, 0x24, 0x2c, 0x56, 0x6c, 0x05, 0x57, 0x27, 0x7b, 0x84, 0x9f, 0xe4, 0xa4,
+	0x07, 0x75, 0x53, 0x3f, 0xe4, 0xb3, 0x7c, 0x57, 0x8e, 0xf8, 0xbb, 0x85, 0x6e, 0x4c, 0xbe, 0x06,
+	0xc8, 0xeb, 0x83, 0xf8, 0xc6, 0xe5, 0x83, 0x92, 0x71, 0x43, 0xec, 0x43, 0x73, 0x51, 0x08, 0xe4,
+	0x7a, 0xae, 0xaa, 0x42, 0x69, 0x2c, 0x85, 0x79, 0x0c, 0x9d, 0x82, 0xee, 0xc8, 0x8d, 0x8f, 0xa8,
+	0xb1, 0xe8, 0x7b, 0xb8, 0xfb, 0xf3, 0xdd, 0xb7, 0xe3, 0xf4, 0xdd, 0xf9, 0xb0, 0x77, 0x2a, 0x26,
+	0x7b, 0x43, 0xc5, 0xe4, 0x28, 0x96, 0x7b, 0x17, 0x71, 0xca, 0xe4, 0x84, 0x25, 0x82, 0xef, 0x29,
+	0x26, 0x67, 0x4c, 0xee, 0x4d, 0x87, 0xc3, 0x9a, 0xfe, 0x47, 0xf2, 0xe0, 0x9f, 0x01, 0x00, 0x5b,
+	0x66, 0x7e, 0x92, 0xc1, 0x0c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -904,6 +1565,14 @@ type InventoryClient interface {
 	GetHosts(ctx context.Context, in *InvIdRequest, opts ...grpc.CallOption) (*HostInfos, error)
 	// Release notifies the server that this inventory is no longer needed
 	Release(ctx context.Context, in *InvIdRequest, opts ...grpc.CallOption) (*Empty, error)
+	// AddHost adds a new host to the inventory of all hosts
+	AddHost(ctx context.Context, in *InvAddHostRequest, opts ...grpc.CallOption) (*HostInfo, error)
+	// RemoveHost removes a host from all inventories
+	RemoveHost(ctx context.Context, in *InvRemoveHostRequest, opts ...grpc.CallOption) (*Empty, error)
+	// SetLabels replaces the labels of a host
+	SetLabels(ctx context.Context, in *InvSetLabelsRequest, opts ...grpc.CallOption) (*HostInfo, error)
+	// SetProperties sets the properties of a host
+	SetProperties(ctx context.Context, in *InvSetPropertiesRequest, opts ...grpc.CallOption) (*HostInfo, error)
 }
 
 type inventoryClient struct {
@@ -986,6 +1655,42 @@ func (c *inventoryClient) Release(ctx context.Context, in *InvIdRequest, opts ..
 	return out, nil
 }
 
+func (c *inventoryClient) AddHost(ctx context.Context, in *InvAddHostRequest, opts ...grpc.CallOption) (*HostInfo, error) {
+	out := new(HostInfo)
+	err := c.cc.Invoke(ctx, "/pb.Inventory/AddHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryClient) RemoveHost(ctx context.Context, in *InvRemoveHostRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/pb.Inventory/RemoveHost", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryClient) SetLabels(ctx context.Context, in *InvSetLabelsRequest, opts ...grpc.CallOption) (*HostInfo, error) {
+	out := new(HostInfo)
+	err := c.cc.Invoke(ctx, "/pb.Inventory/SetLabels", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryClient) SetProperties(ctx context.Context, in *InvSetPropertiesRequest, opts ...grpc.CallOption) (*HostInfo, error) {
+	out := new(HostInfo)
+	err := c.cc.Invoke(ctx, "/pb.Inventory/SetProperties", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // InventoryServer is the server API for Inventory service.
 type InventoryServer interface {
 	// Selects a subset of an inventory based on the given criteria, and
@@ -1006,6 +1711,55 @@ type InventoryServer interface {
 	GetHosts(context.Context, *InvIdRequest) (*HostInfos, error)
 	// Release notifies the server that this inventory is no longer needed
 	Release(context.Context, *InvIdRequest) (*Empty, error)
+	// AddHost adds a new host to the inventory of all hosts
+	AddHost(context.Context, *InvAddHostRequest) (*HostInfo, error)
+	// RemoveHost removes a host from all inventories
+	RemoveHost(context.Context, *InvRemoveHostRequest) (*Empty, error)
+	// SetLabels replaces the labels of a host
+	SetLabels(context.Context, *InvSetLabelsRequest) (*HostInfo, error)
+	// SetProperties sets the properties of a host
+	SetProperties(context.Context, *InvSetPropertiesRequest) (*HostInfo, error)
+}
+
+// UnimplementedInventoryServer can be embedded to have forward compatible implementations.
+type UnimplementedInventoryServer struct {
+}
+
+func (*UnimplementedInventoryServer) Select(ctx context.Context, req *InvSelectRequest) (*InvId, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Select not implemented")
+}
+func (*UnimplementedInventoryServer) Union(ctx context.Context, req *InvUnionRequest) (*InvId, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Union not implemented")
+}
+func (*UnimplementedInventoryServer) Make(ctx context.Context, req *HostIds) (*InvId, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Make not implemented")
+}
+func (*UnimplementedInventoryServer) Add(ctx context.Context, req *InvAddRequest) (*InvId, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (*UnimplementedInventoryServer) GetHostIds(ctx context.Context, req *InvIdRequest) (*HostIds, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHostIds not implemented")
+}
+func (*UnimplementedInventoryServer) GetHostInfo(ctx context.Context, req *HostIds) (*HostInfos, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHostInfo not implemented")
+}
+func (*UnimplementedInventoryServer) GetHosts(ctx context.Context, req *InvIdRequest) (*HostInfos, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHosts not implemented")
+}
+func (*UnimplementedInventoryServer) Release(ctx context.Context, req *InvIdRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Release not implemented")
+}
+func (*UnimplementedInventoryServer) AddHost(ctx context.Context, req *InvAddHostRequest) (*HostInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddHost not implemented")
+}
+func (*UnimplementedInventoryServer) RemoveHost(ctx context.Context, req *InvRemoveHostRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveHost not implemented")
+}
+func (*UnimplementedInventoryServer) SetLabels(ctx context.Context, req *InvSetLabelsRequest) (*HostInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLabels not implemented")
+}
+func (*UnimplementedInventoryServer) SetProperties(ctx context.Context, req *InvSetPropertiesRequest) (*HostInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProperties not implemented")
 }
 
 func RegisterInventoryServer(s *grpc.Server, srv InventoryServer) {
@@ -1156,6 +1910,78 @@ func _Inventory_Release_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Inventory_AddHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvAddHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServer).AddHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Inventory/AddHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServer).AddHost(ctx, req.(*InvAddHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inventory_RemoveHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvRemoveHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServer).RemoveHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Inventory/RemoveHost",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServer).RemoveHost(ctx, req.(*InvRemoveHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inventory_SetLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvSetLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServer).SetLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Inventory/SetLabels",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServer).SetLabels(ctx, req.(*InvSetLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inventory_SetProperties_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvSetPropertiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServer).SetProperties(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Inventory/SetProperties",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServer).SetProperties(ctx, req.(*InvSetPropertiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Inventory_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Inventory",
 	HandlerType: (*InventoryServer)(nil),
@@ -1192,6 +2018,22 @@ var _Inventory_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Release",
 			Handler:    _Inventory_Release_Handler,
 		},
+		{
+			MethodName: "AddHost",
+			Handler:    _Inventory_AddHost_Handler,
+		},
+		{
+			MethodName: "RemoveHost",
+			Handler:    _Inventory_RemoveHost_Handler,
+		},
+		{
+			MethodName: "SetLabels",
+			Handler:    _Inventory_SetLabels_Handler,
+		},
+		{
+			MethodName: "SetProperties",
+			Handler:    _Inventory_SetProperties_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "inventory.proto",
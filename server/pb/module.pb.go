@@ -4,10 +4,12 @@
 package pb
 
 import (
+	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
-	context "golang.org/x/net/context"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -20,7 +22,7 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type LifecycleRequest_Req int32
 
@@ -29,6 +31,9 @@ const (
 	LifecycleRequest_TERM    LifecycleRequest_Req = 1
 	LifecycleRequest_PING    LifecycleRequest_Req = 2
 	LifecycleRequest_PONG    LifecycleRequest_Req = 3
+	// The server sends DESCRIBE to ask the module its exported
+	// functions. The module responds with DESCRIBE and a description
+	LifecycleRequest_DESCRIBE LifecycleRequest_Req = 4
 )
 
 var LifecycleRequest_Req_name = map[int32]string{
@@ -36,13 +41,15 @@ var LifecycleRequest_Req_name = map[int32]string{
 	1: "TERM",
 	2: "PING",
 	3: "PONG",
+	4: "DESCRIBE",
 }
 
 var LifecycleRequest_Req_value = map[string]int32{
-	"CONNECT": 0,
-	"TERM":    1,
-	"PING":    2,
-	"PONG":    3,
+	"CONNECT":  0,
+	"TERM":     1,
+	"PING":     2,
+	"PONG":     3,
+	"DESCRIBE": 4,
 }
 
 func (x LifecycleRequest_Req) String() string {
@@ -57,6 +64,7 @@ type LifecycleRequest struct {
 	RequestType LifecycleRequest_Req `protobuf:"varint,1,opt,name=requestType,proto3,enum=pb.LifecycleRequest_Req" json:"requestType,omitempty"`
 	// Types that are valid to be assigned to Msg:
 	//	*LifecycleRequest_ConnectMsg
+	//	*LifecycleRequest_Description
 	Msg                  isLifecycleRequest_Msg `protobuf_oneof:"msg"`
 	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
 	XXX_unrecognized     []byte                 `json:"-"`
@@ -103,8 +111,14 @@ type LifecycleRequest_ConnectMsg struct {
 	ConnectMsg *Connect `protobuf:"bytes,2,opt,name=connectMsg,proto3,oneof"`
 }
 
+type LifecycleRequest_Description struct {
+	Description *ModuleDescription `protobuf:"bytes,3,opt,name=description,proto3,oneof"`
+}
+
 func (*LifecycleRequest_ConnectMsg) isLifecycleRequest_Msg() {}
 
+func (*LifecycleRequest_Description) isLifecycleRequest_Msg() {}
+
 func (m *LifecycleRequest) GetMsg() isLifecycleRequest_Msg {
 	if m != nil {
 		return m.Msg
@@ -119,66 +133,144 @@ func (m *LifecycleRequest) GetConnectMsg() *Connect {
 	return nil
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*LifecycleRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _LifecycleRequest_OneofMarshaler, _LifecycleRequest_OneofUnmarshaler, _LifecycleRequest_OneofSizer, []interface{}{
+func (m *LifecycleRequest) GetDescription() *ModuleDescription {
+	if x, ok := m.GetMsg().(*LifecycleRequest_Description); ok {
+		return x.Description
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*LifecycleRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
 		(*LifecycleRequest_ConnectMsg)(nil),
+		(*LifecycleRequest_Description)(nil),
+	}
+}
+
+// FunctionDescription describes a function exported by a module
+type FunctionDescription struct {
+	// Name of the function, as called by other modules
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Doc  string `protobuf:"bytes,2,opt,name=doc,proto3" json:"doc,omitempty"`
+	// JSON schemas of the argument and the return value. Empty if the
+	// function has no argument or return value, or they are not JSON
+	Args                 []byte   `protobuf:"bytes,3,opt,name=args,proto3" json:"args,omitempty"`
+	Returns              []byte   `protobuf:"bytes,4,opt,name=returns,proto3" json:"returns,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FunctionDescription) Reset()         { *m = FunctionDescription{} }
+func (m *FunctionDescription) String() string { return proto.CompactTextString(m) }
+func (*FunctionDescription) ProtoMessage()    {}
+func (*FunctionDescription) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae7704718fb7daeb, []int{1}
+}
+
+func (m *FunctionDescription) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FunctionDescription.Unmarshal(m, b)
+}
+func (m *FunctionDescription) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FunctionDescription.Marshal(b, m, deterministic)
+}
+func (m *FunctionDescription) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FunctionDescription.Merge(m, src)
+}
+func (m *FunctionDescription) XXX_Size() int {
+	return xxx_messageInfo_FunctionDescription.Size(m)
+}
+func (m *FunctionDescription) XXX_DiscardUnknown() {
+	xxx_messageInfo_FunctionDescription.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FunctionDescription proto.InternalMessageInfo
+
+func (m *FunctionDescription) GetName() string {
+	if m != nil {
+		return m.Name
 	}
+	return ""
 }
 
-func _LifecycleRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*LifecycleRequest)
-	// msg
-	switch x := m.Msg.(type) {
-	case *LifecycleRequest_ConnectMsg:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.ConnectMsg); err != nil {
-			return err
-		}
-	case nil:
-	default:
-		return fmt.Errorf("LifecycleRequest.Msg has unexpected type %T", x)
+func (m *FunctionDescription) GetDoc() string {
+	if m != nil {
+		return m.Doc
+	}
+	return ""
+}
+
+func (m *FunctionDescription) GetArgs() []byte {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
+func (m *FunctionDescription) GetReturns() []byte {
+	if m != nil {
+		return m.Returns
 	}
 	return nil
 }
 
-func _LifecycleRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*LifecycleRequest)
-	switch tag {
-	case 2: // msg.connectMsg
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(Connect)
-		err := b.DecodeMessage(msg)
-		m.Msg = &LifecycleRequest_ConnectMsg{msg}
-		return true, err
-	default:
-		return false, nil
+// ModuleDescription describes a module and its exported functions
+type ModuleDescription struct {
+	Doc                  string                 `protobuf:"bytes,1,opt,name=doc,proto3" json:"doc,omitempty"`
+	Functions            []*FunctionDescription `protobuf:"bytes,2,rep,name=functions,proto3" json:"functions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *ModuleDescription) Reset()         { *m = ModuleDescription{} }
+func (m *ModuleDescription) String() string { return proto.CompactTextString(m) }
+func (*ModuleDescription) ProtoMessage()    {}
+func (*ModuleDescription) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae7704718fb7daeb, []int{2}
+}
+
+func (m *ModuleDescription) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ModuleDescription.Unmarshal(m, b)
+}
+func (m *ModuleDescription) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ModuleDescription.Marshal(b, m, deterministic)
+}
+func (m *ModuleDescription) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ModuleDescription.Merge(m, src)
+}
+func (m *ModuleDescription) XXX_Size() int {
+	return xxx_messageInfo_ModuleDescription.Size(m)
+}
+func (m *ModuleDescription) XXX_DiscardUnknown() {
+	xxx_messageInfo_ModuleDescription.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ModuleDescription proto.InternalMessageInfo
+
+func (m *ModuleDescription) GetDoc() string {
+	if m != nil {
+		return m.Doc
 	}
+	return ""
 }
 
-func _LifecycleRequest_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*LifecycleRequest)
-	// msg
-	switch x := m.Msg.(type) {
-	case *LifecycleRequest_ConnectMsg:
-		s := proto.Size(x.ConnectMsg)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+func (m *ModuleDescription) GetFunctions() []*FunctionDescription {
+	if m != nil {
+		return m.Functions
 	}
-	return n
+	return nil
 }
 
 // Connect message sent from the module to the server to notify the
 // grpc port listening on the module to receive requests along with the
 // module name.
 type Connect struct {
-	Port                 int32    `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	Port int32 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	// JSON module schema describing the configuration and the function
+	// arguments the module expects. Empty if the module declares none
+	Schema               []byte   `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -188,7 +280,7 @@ func (m *Connect) Reset()         { *m = Connect{} }
 func (m *Connect) String() string { return proto.CompactTextString(m) }
 func (*Connect) ProtoMessage()    {}
 func (*Connect) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{1}
+	return fileDescriptor_ae7704718fb7daeb, []int{3}
 }
 
 func (m *Connect) XXX_Unmarshal(b []byte) error {
@@ -216,6 +308,13 @@ func (m *Connect) GetPort() int32 {
 	return 0
 }
 
+func (m *Connect) GetSchema() []byte {
+	if m != nil {
+		return m.Schema
+	}
+	return nil
+}
+
 // ModuleWorkRequest selects a module to call, and sends a request to that module.
 type ModuleWorkRequest struct {
 	// Name of the module to call
@@ -231,7 +330,7 @@ func (m *ModuleWorkRequest) Reset()         { *m = ModuleWorkRequest{} }
 func (m *ModuleWorkRequest) String() string { return proto.CompactTextString(m) }
 func (*ModuleWorkRequest) ProtoMessage()    {}
 func (*ModuleWorkRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{2}
+	return fileDescriptor_ae7704718fb7daeb, []int{4}
 }
 
 func (m *ModuleWorkRequest) XXX_Unmarshal(b []byte) error {
@@ -280,7 +379,7 @@ func (m *LogRequest) Reset()         { *m = LogRequest{} }
 func (m *LogRequest) String() string { return proto.CompactTextString(m) }
 func (*LogRequest) ProtoMessage()    {}
 func (*LogRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{3}
+	return fileDescriptor_ae7704718fb7daeb, []int{5}
 }
 
 func (m *LogRequest) XXX_Unmarshal(b []byte) error {
@@ -324,9 +423,12 @@ func (m *LogRequest) GetMsg() string {
 
 // Configuration request, using a json pointer
 type CfgRequest struct {
-	Session              string   `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
-	HostId               string   `protobuf:"bytes,2,opt,name=hostId,proto3" json:"hostId,omitempty"`
-	Path                 string   `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Session string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	HostId  string `protobuf:"bytes,2,opt,name=hostId,proto3" json:"hostId,omitempty"`
+	Path    string `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	// The port of the calling module, as sent in Connect. It identifies
+	// the module, so the defaults of its configuration schema are seen
+	Port                 int32    `protobuf:"varint,4,opt,name=port,proto3" json:"port,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -336,7 +438,7 @@ func (m *CfgRequest) Reset()         { *m = CfgRequest{} }
 func (m *CfgRequest) String() string { return proto.CompactTextString(m) }
 func (*CfgRequest) ProtoMessage()    {}
 func (*CfgRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{4}
+	return fileDescriptor_ae7704718fb7daeb, []int{6}
 }
 
 func (m *CfgRequest) XXX_Unmarshal(b []byte) error {
@@ -378,6 +480,13 @@ func (m *CfgRequest) GetPath() string {
 	return ""
 }
 
+func (m *CfgRequest) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
 // Configuration response, contains JSON document. If the variable is not found, contains
 // nil (empty array)
 type CfgResponse struct {
@@ -391,7 +500,7 @@ func (m *CfgResponse) Reset()         { *m = CfgResponse{} }
 func (m *CfgResponse) String() string { return proto.CompactTextString(m) }
 func (*CfgResponse) ProtoMessage()    {}
 func (*CfgResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{5}
+	return fileDescriptor_ae7704718fb7daeb, []int{7}
 }
 
 func (m *CfgResponse) XXX_Unmarshal(b []byte) error {
@@ -430,7 +539,7 @@ func (m *LoadModuleRequest) Reset()         { *m = LoadModuleRequest{} }
 func (m *LoadModuleRequest) String() string { return proto.CompactTextString(m) }
 func (*LoadModuleRequest) ProtoMessage()    {}
 func (*LoadModuleRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{6}
+	return fileDescriptor_ae7704718fb7daeb, []int{8}
 }
 
 func (m *LoadModuleRequest) XXX_Unmarshal(b []byte) error {
@@ -469,7 +578,7 @@ func (m *LoadModuleResponse) Reset()         { *m = LoadModuleResponse{} }
 func (m *LoadModuleResponse) String() string { return proto.CompactTextString(m) }
 func (*LoadModuleResponse) ProtoMessage()    {}
 func (*LoadModuleResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{7}
+	return fileDescriptor_ae7704718fb7daeb, []int{9}
 }
 
 func (m *LoadModuleResponse) XXX_Unmarshal(b []byte) error {
@@ -508,7 +617,7 @@ func (m *Session) Reset()         { *m = Session{} }
 func (m *Session) String() string { return proto.CompactTextString(m) }
 func (*Session) ProtoMessage()    {}
 func (*Session) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{8}
+	return fileDescriptor_ae7704718fb7daeb, []int{10}
 }
 
 func (m *Session) XXX_Unmarshal(b []byte) error {
@@ -547,7 +656,7 @@ func (m *Args) Reset()         { *m = Args{} }
 func (m *Args) String() string { return proto.CompactTextString(m) }
 func (*Args) ProtoMessage()    {}
 func (*Args) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{9}
+	return fileDescriptor_ae7704718fb7daeb, []int{11}
 }
 
 func (m *Args) XXX_Unmarshal(b []byte) error {
@@ -575,6 +684,246 @@ func (m *Args) GetArgs() []string {
 	return nil
 }
 
+// HandlerRequest declares a handler. A handler is a module function
+// that is called once for each host it is notified for. Handlers run
+// in declaration order at the end of the run, or at the end of the
+// module call that notified them if afterCall is set.
+type HandlerRequest struct {
+	Session string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// Name of the handler, e.g. "restart nginx"
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The module and the function to call
+	Module               string   `protobuf:"bytes,3,opt,name=module,proto3" json:"module,omitempty"`
+	FuncName             string   `protobuf:"bytes,4,opt,name=funcName,proto3" json:"funcName,omitempty"`
+	AfterCall            bool     `protobuf:"varint,5,opt,name=afterCall,proto3" json:"afterCall,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandlerRequest) Reset()         { *m = HandlerRequest{} }
+func (m *HandlerRequest) String() string { return proto.CompactTextString(m) }
+func (*HandlerRequest) ProtoMessage()    {}
+func (*HandlerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae7704718fb7daeb, []int{12}
+}
+
+func (m *HandlerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandlerRequest.Unmarshal(m, b)
+}
+func (m *HandlerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandlerRequest.Marshal(b, m, deterministic)
+}
+func (m *HandlerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandlerRequest.Merge(m, src)
+}
+func (m *HandlerRequest) XXX_Size() int {
+	return xxx_messageInfo_HandlerRequest.Size(m)
+}
+func (m *HandlerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandlerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandlerRequest proto.InternalMessageInfo
+
+func (m *HandlerRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *HandlerRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *HandlerRequest) GetModule() string {
+	if m != nil {
+		return m.Module
+	}
+	return ""
+}
+
+func (m *HandlerRequest) GetFuncName() string {
+	if m != nil {
+		return m.FuncName
+	}
+	return ""
+}
+
+func (m *HandlerRequest) GetAfterCall() bool {
+	if m != nil {
+		return m.AfterCall
+	}
+	return false
+}
+
+// NotifyRequest queues a handler for a host
+type NotifyRequest struct {
+	Session string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	// The call that notified the handler
+	CallId               string   `protobuf:"bytes,2,opt,name=callId,proto3" json:"callId,omitempty"`
+	HostId               string   `protobuf:"bytes,3,opt,name=hostId,proto3" json:"hostId,omitempty"`
+	Handler              string   `protobuf:"bytes,4,opt,name=handler,proto3" json:"handler,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NotifyRequest) Reset()         { *m = NotifyRequest{} }
+func (m *NotifyRequest) String() string { return proto.CompactTextString(m) }
+func (*NotifyRequest) ProtoMessage()    {}
+func (*NotifyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae7704718fb7daeb, []int{13}
+}
+
+func (m *NotifyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NotifyRequest.Unmarshal(m, b)
+}
+func (m *NotifyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NotifyRequest.Marshal(b, m, deterministic)
+}
+func (m *NotifyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NotifyRequest.Merge(m, src)
+}
+func (m *NotifyRequest) XXX_Size() int {
+	return xxx_messageInfo_NotifyRequest.Size(m)
+}
+func (m *NotifyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NotifyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NotifyRequest proto.InternalMessageInfo
+
+func (m *NotifyRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetCallId() string {
+	if m != nil {
+		return m.CallId
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetHostId() string {
+	if m != nil {
+		return m.HostId
+	}
+	return ""
+}
+
+func (m *NotifyRequest) GetHandler() string {
+	if m != nil {
+		return m.Handler
+	}
+	return ""
+}
+
+// FlushHandlersRequest runs the queued handlers for the given
+// hosts. If no hosts are given, all queued handlers run.
+type FlushHandlersRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	HostIds              []string `protobuf:"bytes,2,rep,name=hostIds,proto3" json:"hostIds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlushHandlersRequest) Reset()         { *m = FlushHandlersRequest{} }
+func (m *FlushHandlersRequest) String() string { return proto.CompactTextString(m) }
+func (*FlushHandlersRequest) ProtoMessage()    {}
+func (*FlushHandlersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae7704718fb7daeb, []int{14}
+}
+
+func (m *FlushHandlersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FlushHandlersRequest.Unmarshal(m, b)
+}
+func (m *FlushHandlersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FlushHandlersRequest.Marshal(b, m, deterministic)
+}
+func (m *FlushHandlersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlushHandlersRequest.Merge(m, src)
+}
+func (m *FlushHandlersRequest) XXX_Size() int {
+	return xxx_messageInfo_FlushHandlersRequest.Size(m)
+}
+func (m *FlushHandlersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlushHandlersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlushHandlersRequest proto.InternalMessageInfo
+
+func (m *FlushHandlersRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *FlushHandlersRequest) GetHostIds() []string {
+	if m != nil {
+		return m.HostIds
+	}
+	return nil
+}
+
+// SecretsRequest marks values as secrets. They are masked in logs
+// and outputs.
+type SecretsRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Values               []string `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SecretsRequest) Reset()         { *m = SecretsRequest{} }
+func (m *SecretsRequest) String() string { return proto.CompactTextString(m) }
+func (*SecretsRequest) ProtoMessage()    {}
+func (*SecretsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae7704718fb7daeb, []int{15}
+}
+
+func (m *SecretsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SecretsRequest.Unmarshal(m, b)
+}
+func (m *SecretsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SecretsRequest.Marshal(b, m, deterministic)
+}
+func (m *SecretsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SecretsRequest.Merge(m, src)
+}
+func (m *SecretsRequest) XXX_Size() int {
+	return xxx_messageInfo_SecretsRequest.Size(m)
+}
+func (m *SecretsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SecretsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SecretsRequest proto.InternalMessageInfo
+
+func (m *SecretsRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *SecretsRequest) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
 // Request is a request sent to a function implemented by a module.
 type Request struct {
 	// The current session ID
@@ -583,7 +932,10 @@ type Request struct {
 	FuncName string `protobuf:"bytes,2,opt,name=funcName,proto3" json:"funcName,omitempty"`
 	// A JSON document describing the parameters to the function. The structure
 	// of the JSON document depends on the function.
-	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// Identifies this call. Handlers notified during the call are
+	// tagged with it
+	CallId               string   `protobuf:"bytes,4,opt,name=callId,proto3" json:"callId,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -593,7 +945,7 @@ func (m *Request) Reset()         { *m = Request{} }
 func (m *Request) String() string { return proto.CompactTextString(m) }
 func (*Request) ProtoMessage()    {}
 func (*Request) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{10}
+	return fileDescriptor_ae7704718fb7daeb, []int{16}
 }
 
 func (m *Request) XXX_Unmarshal(b []byte) error {
@@ -635,6 +987,13 @@ func (m *Request) GetData() []byte {
 	return nil
 }
 
+func (m *Request) GetCallId() string {
+	if m != nil {
+		return m.CallId
+	}
+	return ""
+}
+
 // Response is returned from a module function. If the module function
 // executes but the result is an error, then the errorMsg field contains
 // that error, but the call returns success. If somehow the module
@@ -655,7 +1014,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae7704718fb7daeb, []int{11}
+	return fileDescriptor_ae7704718fb7daeb, []int{17}
 }
 
 func (m *Response) XXX_Unmarshal(b []byte) error {
@@ -714,6 +1073,8 @@ func (m *Response) GetData() []byte {
 func init() {
 	proto.RegisterEnum("pb.LifecycleRequest_Req", LifecycleRequest_Req_name, LifecycleRequest_Req_value)
 	proto.RegisterType((*LifecycleRequest)(nil), "pb.LifecycleRequest")
+	proto.RegisterType((*FunctionDescription)(nil), "pb.FunctionDescription")
+	proto.RegisterType((*ModuleDescription)(nil), "pb.ModuleDescription")
 	proto.RegisterType((*Connect)(nil), "pb.Connect")
 	proto.RegisterType((*ModuleWorkRequest)(nil), "pb.ModuleWorkRequest")
 	proto.RegisterType((*LogRequest)(nil), "pb.LogRequest")
@@ -723,6 +1084,10 @@ func init() {
 	proto.RegisterType((*LoadModuleResponse)(nil), "pb.LoadModuleResponse")
 	proto.RegisterType((*Session)(nil), "pb.Session")
 	proto.RegisterType((*Args)(nil), "pb.Args")
+	proto.RegisterType((*HandlerRequest)(nil), "pb.HandlerRequest")
+	proto.RegisterType((*NotifyRequest)(nil), "pb.NotifyRequest")
+	proto.RegisterType((*FlushHandlersRequest)(nil), "pb.FlushHandlersRequest")
+	proto.RegisterType((*SecretsRequest)(nil), "pb.SecretsRequest")
 	proto.RegisterType((*Request)(nil), "pb.Request")
 	proto.RegisterType((*Response)(nil), "pb.Response")
 }
@@ -730,47 +1095,66 @@ func init() {
 func init() { proto.RegisterFile("module.proto", fileDescriptor_ae7704718fb7daeb) }
 
 var fileDescriptor_ae7704718fb7daeb = []byte{
-	// 632 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xdd, 0x6e, 0xd3, 0x4c,
-	0x10, 0xad, 0xe3, 0x24, 0x4e, 0x26, 0x55, 0x3f, 0x77, 0xf5, 0x51, 0x59, 0x96, 0x8a, 0x8a, 0x41,
-	0xa2, 0x15, 0x22, 0x29, 0xe1, 0x86, 0x9f, 0x2b, 0x1a, 0x55, 0xa1, 0x52, 0x92, 0x46, 0xdb, 0x4a,
-	0x48, 0xdc, 0x39, 0xf6, 0xc6, 0xb5, 0x88, 0xbd, 0xce, 0xee, 0x06, 0xd4, 0x77, 0xe0, 0x79, 0x78,
-	0x07, 0xde, 0x0a, 0xed, 0x8f, 0x5d, 0xd3, 0x96, 0x72, 0xc1, 0xdd, 0x9c, 0xf1, 0xec, 0xd9, 0x73,
-	0x66, 0x67, 0x0c, 0xdb, 0x19, 0x8d, 0x37, 0x2b, 0xd2, 0x2f, 0x18, 0x15, 0x14, 0x35, 0x8a, 0x85,
-	0xdf, 0x23, 0x59, 0x21, 0xae, 0x75, 0x22, 0xf8, 0x61, 0x81, 0x3b, 0x49, 0x97, 0x24, 0xba, 0x8e,
-	0x56, 0x04, 0x93, 0xf5, 0x86, 0x70, 0x81, 0xde, 0x41, 0x8f, 0xe9, 0xf0, 0xf2, 0xba, 0x20, 0x9e,
-	0x75, 0x60, 0x1d, 0xee, 0x0c, 0xbd, 0x7e, 0xb1, 0xe8, 0xdf, 0x2e, 0xed, 0x63, 0xb2, 0xc6, 0xf5,
-	0x62, 0xf4, 0x12, 0x20, 0xa2, 0x79, 0x4e, 0x22, 0x31, 0xe5, 0x89, 0xd7, 0x38, 0xb0, 0x0e, 0x7b,
-	0xc3, 0x9e, 0x3c, 0x3a, 0xd2, 0xd9, 0x8f, 0x5b, 0xb8, 0x56, 0x10, 0x1c, 0x83, 0x8d, 0xc9, 0x1a,
-	0xf5, 0xc0, 0x19, 0x9d, 0xcf, 0x66, 0xa7, 0xa3, 0x4b, 0x77, 0x0b, 0x75, 0xa0, 0x79, 0x79, 0x8a,
-	0xa7, 0xae, 0x25, 0xa3, 0xf9, 0xd9, 0x6c, 0xec, 0x36, 0x54, 0x74, 0x3e, 0x1b, 0xbb, 0xf6, 0x49,
-	0x0b, 0xec, 0x8c, 0x27, 0xc1, 0x3e, 0x38, 0x86, 0x11, 0x21, 0x68, 0x16, 0x94, 0x09, 0xa5, 0xb3,
-	0x85, 0x55, 0x1c, 0x60, 0xd8, 0x9d, 0x2a, 0xe3, 0x9f, 0x28, 0xfb, 0x52, 0xfa, 0x7a, 0x0c, 0xa0,
-	0xbb, 0x31, 0x0b, 0x33, 0x6d, 0xab, 0x8b, 0x6b, 0x19, 0xb4, 0x0f, 0x36, 0x23, 0xeb, 0xba, 0x68,
-	0x73, 0x12, 0xcb, 0x7c, 0x30, 0x07, 0x98, 0xd0, 0xa4, 0x24, 0xf3, 0xc0, 0xe1, 0x84, 0xf3, 0x94,
-	0xe6, 0x86, 0xa9, 0x84, 0x68, 0x0f, 0xda, 0x57, 0x94, 0x8b, 0xb3, 0x58, 0x31, 0x75, 0xb1, 0x41,
-	0xc8, 0x55, 0xca, 0x3d, 0x5b, 0x25, 0x95, 0x09, 0x0c, 0x30, 0x5a, 0xfe, 0x03, 0xa3, 0x74, 0x1e,
-	0x8a, 0x2b, 0x43, 0xa9, 0xe2, 0xe0, 0x09, 0xf4, 0x14, 0x27, 0x2f, 0x68, 0xce, 0x89, 0x2c, 0x89,
-	0x43, 0x11, 0xaa, 0x83, 0xdb, 0x58, 0xc5, 0xc1, 0x0b, 0xd8, 0x9d, 0xd0, 0x30, 0xd6, 0x0d, 0x2a,
-	0x6f, 0xdf, 0x83, 0xb6, 0x6e, 0x85, 0xb9, 0xdc, 0xa0, 0xa0, 0x0f, 0xa8, 0x5e, 0x6c, 0x68, 0x3d,
-	0x70, 0xc2, 0x38, 0x66, 0x84, 0xf3, 0x52, 0xab, 0x81, 0xc1, 0x53, 0x70, 0x2e, 0x8c, 0xec, 0x3f,
-	0x1a, 0x0a, 0x7c, 0x68, 0x7e, 0x60, 0x09, 0x97, 0xea, 0x42, 0x96, 0x48, 0x0e, 0x5b, 0x1a, 0x90,
-	0x71, 0x70, 0x01, 0xce, 0xdf, 0x3b, 0xe2, 0x43, 0x67, 0xb9, 0xc9, 0x23, 0xf5, 0x90, 0xba, 0x27,
-	0x15, 0xae, 0x2c, 0xdb, 0x35, 0xcb, 0xdf, 0x2d, 0xe8, 0xd4, 0xc5, 0xf3, 0x4d, 0x14, 0x95, 0xe2,
-	0x3b, 0xb8, 0x84, 0x0f, 0xd2, 0xfa, 0xd0, 0x21, 0x8c, 0x51, 0x36, 0xad, 0xde, 0xb0, 0xc2, 0xf2,
-	0x5b, 0x46, 0xe3, 0x74, 0x99, 0x92, 0xd8, 0x6b, 0x2a, 0xca, 0x0a, 0x57, 0x72, 0x5a, 0x37, 0x72,
-	0x86, 0x3f, 0x1b, 0xd0, 0xad, 0x76, 0x09, 0xbd, 0xbd, 0x99, 0xe5, 0xff, 0xef, 0xdb, 0x32, 0xff,
-	0xde, 0xec, 0xa1, 0x75, 0x6c, 0xa1, 0x57, 0x00, 0xfa, 0x65, 0x46, 0xe1, 0x6a, 0x85, 0x1e, 0xc9,
-	0xba, 0x3b, 0x73, 0xef, 0x6f, 0xeb, 0x51, 0x36, 0xee, 0x0f, 0xc0, 0x9e, 0xd0, 0x04, 0xed, 0x28,
-	0xce, 0x6a, 0x9e, 0xfd, 0xae, 0xc4, 0xa7, 0xf2, 0xd7, 0x80, 0x02, 0x68, 0xcd, 0x59, 0x9a, 0x8b,
-	0x87, 0x6a, 0x8e, 0xa0, 0x3d, 0x26, 0x62, 0xb4, 0x34, 0x44, 0x37, 0x63, 0xec, 0xff, 0x57, 0xe1,
-	0xea, 0x42, 0x67, 0x4c, 0x84, 0x7a, 0x6f, 0xb5, 0x54, 0x66, 0x3c, 0xfc, 0x8e, 0x04, 0x2a, 0xfd,
-	0x5e, 0x6e, 0x56, 0x39, 0x63, 0xda, 0xc5, 0x9d, 0x01, 0xf5, 0xf7, 0x6e, 0xa7, 0x35, 0xfd, 0xf0,
-	0x0d, 0xb8, 0xa6, 0x64, 0xce, 0xa8, 0x7c, 0x45, 0xca, 0xd0, 0x33, 0x70, 0x0c, 0x40, 0xf5, 0x3d,
-	0xfe, 0xbd, 0x13, 0x27, 0x47, 0x9f, 0x9f, 0x27, 0xa9, 0xb8, 0xda, 0x2c, 0xfa, 0x11, 0xcd, 0x06,
-	0x0b, 0x4e, 0x58, 0x1c, 0xb2, 0xc1, 0xb7, 0x50, 0x10, 0x96, 0x91, 0x15, 0xcd, 0x07, 0x9c, 0xb0,
-	0xaf, 0x84, 0x0d, 0x8a, 0xc5, 0xa2, 0xad, 0x7e, 0x97, 0xaf, 0x7f, 0x05, 0x00, 0x00, 0xff, 0xff,
-	0xbd, 0xfd, 0x12, 0x94, 0x4f, 0x05, 0x00, 0x00,
+	// 944 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xdf, 0x6f, 0xe3, 0x44,
+	0x10, 0xae, 0xe3, 0x24, 0x4e, 0x26, 0xb9, 0x90, 0x2e, 0x47, 0xb1, 0x2c, 0x40, 0x61, 0x41, 0x90,
+	0xd3, 0x89, 0x04, 0x82, 0x4e, 0x70, 0x20, 0x21, 0x5d, 0xd3, 0x5c, 0x5b, 0xd4, 0xe6, 0xaa, 0x6d,
+	0x25, 0x24, 0xc4, 0x8b, 0x63, 0x6f, 0x7e, 0xe8, 0x1c, 0x6f, 0xba, 0xbb, 0x39, 0xd4, 0xff, 0x80,
+	0x07, 0x1e, 0xf8, 0x8f, 0x41, 0xbb, 0x5e, 0xdb, 0x9b, 0x5e, 0xb9, 0x56, 0xba, 0xb7, 0xf9, 0x26,
+	0xe3, 0xd9, 0x6f, 0xbe, 0x9d, 0x99, 0x0d, 0xb4, 0xd7, 0x2c, 0xde, 0x26, 0x74, 0xb0, 0xe1, 0x4c,
+	0x32, 0x54, 0xd9, 0xcc, 0x82, 0x16, 0x5d, 0x6f, 0xe4, 0x4d, 0xe6, 0xc0, 0xff, 0x3a, 0xd0, 0x3d,
+	0x5b, 0xcd, 0x69, 0x74, 0x13, 0x25, 0x94, 0xd0, 0xeb, 0x2d, 0x15, 0x12, 0xfd, 0x04, 0x2d, 0x9e,
+	0x99, 0x57, 0x37, 0x1b, 0xea, 0x3b, 0x3d, 0xa7, 0xdf, 0x19, 0xf9, 0x83, 0xcd, 0x6c, 0x70, 0x3b,
+	0x74, 0x40, 0xe8, 0x35, 0xb1, 0x83, 0xd1, 0x37, 0x00, 0x11, 0x4b, 0x53, 0x1a, 0xc9, 0x73, 0xb1,
+	0xf0, 0x2b, 0x3d, 0xa7, 0xdf, 0x1a, 0xb5, 0xd4, 0xa7, 0xe3, 0xcc, 0x7b, 0xb2, 0x47, 0xac, 0x00,
+	0xf4, 0x1c, 0x5a, 0x31, 0x15, 0x11, 0x5f, 0x6d, 0xe4, 0x8a, 0xa5, 0xbe, 0xab, 0xe3, 0x3f, 0x52,
+	0xf1, 0xe7, 0x9a, 0xf7, 0x51, 0xf9, 0xe3, 0xc9, 0x1e, 0xb1, 0x63, 0xf1, 0x2f, 0xe0, 0x12, 0x7a,
+	0x8d, 0x5a, 0xe0, 0x8d, 0x5f, 0x4d, 0xa7, 0x93, 0xf1, 0x55, 0x77, 0x0f, 0x35, 0xa0, 0x7a, 0x35,
+	0x21, 0xe7, 0x5d, 0x47, 0x59, 0x17, 0xa7, 0xd3, 0xe3, 0x6e, 0x45, 0x5b, 0xaf, 0xa6, 0xc7, 0x5d,
+	0x17, 0xb5, 0xa1, 0x71, 0x34, 0xb9, 0x1c, 0x93, 0xd3, 0xc3, 0x49, 0xb7, 0x7a, 0x58, 0x03, 0x77,
+	0x2d, 0x16, 0x78, 0x05, 0x1f, 0xbe, 0xdc, 0xa6, 0x91, 0x4a, 0x69, 0x1d, 0x86, 0x10, 0x54, 0xd3,
+	0x70, 0x9d, 0x15, 0xdf, 0x24, 0xda, 0x46, 0x5d, 0x70, 0x63, 0x16, 0xe9, 0xa2, 0x9a, 0x44, 0x99,
+	0x2a, 0x2a, 0xe4, 0x0b, 0xa1, 0x79, 0xb7, 0x89, 0xb6, 0x91, 0x0f, 0x1e, 0xa7, 0x72, 0xcb, 0x53,
+	0xe1, 0x57, 0xb5, 0x3b, 0x87, 0xf8, 0x0f, 0xd8, 0x7f, 0xab, 0xaa, 0x3c, 0xa9, 0x53, 0x26, 0x7d,
+	0x06, 0xcd, 0xb9, 0x61, 0x24, 0xfc, 0x4a, 0xcf, 0xed, 0xb7, 0x46, 0x1f, 0x2b, 0x45, 0xee, 0xa0,
+	0x49, 0xca, 0x48, 0xfc, 0x0c, 0x3c, 0xa3, 0xb1, 0xa2, 0xb5, 0x61, 0x5c, 0xea, 0xa4, 0x35, 0xa2,
+	0x6d, 0x74, 0x00, 0x75, 0x11, 0x2d, 0xe9, 0x3a, 0xd4, 0xfc, 0xdb, 0xc4, 0x20, 0x4c, 0x72, 0x52,
+	0xbf, 0x31, 0xfe, 0x3a, 0xef, 0x80, 0xcf, 0x00, 0xb2, 0xbe, 0x99, 0x96, 0x1a, 0x58, 0x1e, 0xf4,
+	0x29, 0xb8, 0x9c, 0x5e, 0xdb, 0xd7, 0x6b, 0xbe, 0x24, 0xca, 0x8f, 0x2f, 0x00, 0xce, 0xd8, 0x22,
+	0x4f, 0xe6, 0x83, 0x27, 0xa8, 0x10, 0xea, 0x7e, 0xb3, 0x4c, 0x39, 0x54, 0x9c, 0x96, 0x4c, 0xc8,
+	0xd3, 0xd8, 0x68, 0x6a, 0x90, 0xd2, 0x64, 0x2d, 0x16, 0x5a, 0xd5, 0x26, 0xd1, 0xb7, 0x34, 0x07,
+	0x18, 0xcf, 0xdf, 0x23, 0xa3, 0x52, 0x24, 0x94, 0x4b, 0x93, 0x52, 0xdb, 0x85, 0x4a, 0xd5, 0x52,
+	0x25, 0xfc, 0x39, 0xb4, 0xf4, 0x39, 0x62, 0xc3, 0x52, 0x41, 0x55, 0x48, 0x1c, 0xca, 0x5c, 0x32,
+	0x6d, 0xe3, 0xa7, 0xb0, 0x7f, 0xc6, 0xc2, 0x38, 0x13, 0x2d, 0x67, 0x74, 0x00, 0xf5, 0x4c, 0x1e,
+	0x43, 0xc8, 0x20, 0x3c, 0x00, 0x64, 0x07, 0x9b, 0xb4, 0x3e, 0x78, 0x61, 0x1c, 0x73, 0x2a, 0x44,
+	0xce, 0xdf, 0x40, 0xfc, 0x05, 0x78, 0x97, 0xa6, 0x94, 0xff, 0x2d, 0x12, 0x07, 0x50, 0x7d, 0xa1,
+	0x3a, 0x2d, 0xef, 0x3e, 0xa7, 0xe7, 0xaa, 0xa2, 0x94, 0x8d, 0xff, 0x71, 0xa0, 0x73, 0x12, 0xa6,
+	0x71, 0x42, 0xf9, 0xfd, 0x6a, 0xe5, 0x4d, 0x5e, 0xb1, 0x9a, 0xbc, 0xac, 0xc4, 0xb5, 0x2b, 0x41,
+	0x01, 0x34, 0x54, 0xaf, 0xe9, 0x86, 0xa8, 0xea, 0x5f, 0x0a, 0x8c, 0x3e, 0x81, 0x66, 0x38, 0x97,
+	0x94, 0x8f, 0xc3, 0x24, 0xf1, 0x6b, 0x3d, 0xa7, 0xdf, 0x20, 0xa5, 0x03, 0x0b, 0x78, 0x34, 0x65,
+	0x72, 0x35, 0xbf, 0x79, 0xd0, 0xf5, 0x45, 0x61, 0x92, 0x94, 0xd7, 0x97, 0x21, 0xeb, 0x5a, 0xdd,
+	0x9d, 0x6b, 0xf5, 0xc1, 0x5b, 0x66, 0xc5, 0x1a, 0x4e, 0x39, 0xc4, 0xbf, 0xc2, 0xe3, 0x97, 0xc9,
+	0x56, 0x2c, 0x8d, 0x16, 0xe2, 0xfe, 0xb3, 0x55, 0x2e, 0x9d, 0x35, 0x1b, 0xba, 0x26, 0xc9, 0x21,
+	0x3e, 0x84, 0xce, 0x25, 0x8d, 0x38, 0x95, 0xe2, 0x41, 0x15, 0xbc, 0x09, 0x93, 0x2d, 0xcd, 0x93,
+	0x18, 0x84, 0x5f, 0x83, 0x77, 0xff, 0xc7, 0xb6, 0xc6, 0x95, 0x5b, 0x1a, 0xe7, 0xad, 0xe8, 0x96,
+	0xad, 0x68, 0xc9, 0x55, 0xb5, 0xe5, 0xc2, 0x7f, 0x3b, 0xd0, 0xb0, 0x9b, 0x4d, 0x6c, 0xa3, 0x28,
+	0x6f, 0xb6, 0x06, 0xc9, 0xe1, 0x3b, 0x8f, 0x0b, 0xa0, 0x41, 0x39, 0x67, 0xfc, 0xbc, 0x98, 0xc3,
+	0x02, 0xab, 0xdf, 0xd6, 0x2c, 0x5e, 0xcd, 0x57, 0x34, 0x3b, 0xb8, 0x41, 0x0a, 0x5c, 0xd0, 0xac,
+	0x95, 0x34, 0x47, 0x7f, 0x55, 0xa1, 0x59, 0xbc, 0x1c, 0xe8, 0x79, 0xb9, 0xa7, 0x1e, 0xdf, 0xf5,
+	0xa6, 0x04, 0x77, 0x7a, 0xfb, 0xce, 0xb7, 0x0e, 0xfa, 0x0e, 0x20, 0x9b, 0x24, 0xd5, 0x57, 0xc8,
+	0x7a, 0x26, 0xac, 0xdd, 0x15, 0xb4, 0xb3, 0x75, 0x64, 0xaa, 0xef, 0x81, 0x7b, 0xc6, 0x16, 0xa8,
+	0xa3, 0x73, 0x16, 0x3b, 0x29, 0x68, 0x2a, 0x3c, 0x51, 0x0f, 0x21, 0xc2, 0x50, 0xbb, 0xe0, 0xab,
+	0x54, 0xbe, 0x2b, 0xe6, 0x09, 0xd4, 0x8f, 0xa9, 0x1c, 0xcf, 0x4d, 0xa2, 0x72, 0x15, 0x05, 0x1f,
+	0x14, 0xb8, 0x38, 0xd0, 0x3b, 0xa6, 0x52, 0xcf, 0xa7, 0x5e, 0x8c, 0x66, 0x9c, 0x83, 0x86, 0x02,
+	0xda, 0xfd, 0x33, 0x40, 0xb9, 0x13, 0xb2, 0x2a, 0xde, 0x5a, 0x28, 0xc1, 0xc1, 0x6d, 0xb7, 0x49,
+	0x3f, 0x84, 0xce, 0x11, 0x8d, 0x92, 0x90, 0x53, 0xd3, 0xd9, 0x08, 0xa9, 0xc8, 0xdd, 0x91, 0xb7,
+	0xa9, 0x7f, 0x05, 0xf5, 0x6c, 0xfa, 0xd0, 0xbe, 0x72, 0xee, 0x4c, 0xa2, 0x1d, 0xf7, 0x03, 0x3c,
+	0xda, 0x19, 0x18, 0xa4, 0x1f, 0xfc, 0xbb, 0x66, 0xe8, 0x96, 0xc2, 0x4f, 0x01, 0x5e, 0xc4, 0xb1,
+	0x19, 0x90, 0x8c, 0xcd, 0xee, 0xb4, 0x58, 0xa7, 0x8c, 0x7e, 0x84, 0xae, 0xf1, 0x5e, 0x70, 0xa6,
+	0x9a, 0x90, 0x71, 0xf4, 0x25, 0x78, 0x06, 0x20, 0xfb, 0x29, 0xd9, 0x3d, 0xe6, 0xf0, 0xc9, 0xef,
+	0x5f, 0x2f, 0x56, 0x72, 0xb9, 0x9d, 0x0d, 0x22, 0xb6, 0x1e, 0xce, 0x04, 0xe5, 0x71, 0xc8, 0x87,
+	0x7f, 0x86, 0x92, 0xf2, 0x35, 0x4d, 0x58, 0x3a, 0x14, 0x94, 0xbf, 0xa1, 0x7c, 0xb8, 0x99, 0xcd,
+	0xea, 0xfa, 0xbf, 0xcd, 0xf7, 0xff, 0x0d, 0x00, 0xa0, 0xa7, 0x7c, 0x5a, 0xfc, 0x08, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -805,6 +1189,14 @@ type LifecycleClient interface {
 	// Loads a module by its name, and returns the GRPC location for the module
 	// Use this to setup a direct GRPC link to the module
 	LoadModule(ctx context.Context, in *LoadModuleRequest, opts ...grpc.CallOption) (*LoadModuleResponse, error)
+	// Declare a handler
+	DeclareHandler(ctx context.Context, in *HandlerRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Queue a handler for a host
+	Notify(ctx context.Context, in *NotifyRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Run queued handlers now
+	FlushHandlers(ctx context.Context, in *FlushHandlersRequest, opts ...grpc.CallOption) (*Response, error)
+	// Mark values as secrets
+	AddSecrets(ctx context.Context, in *SecretsRequest, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type lifecycleClient struct {
@@ -900,6 +1292,42 @@ func (c *lifecycleClient) LoadModule(ctx context.Context, in *LoadModuleRequest,
 	return out, nil
 }
 
+func (c *lifecycleClient) DeclareHandler(ctx context.Context, in *HandlerRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/pb.Lifecycle/DeclareHandler", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifecycleClient) Notify(ctx context.Context, in *NotifyRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/pb.Lifecycle/Notify", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifecycleClient) FlushHandlers(ctx context.Context, in *FlushHandlersRequest, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/pb.Lifecycle/FlushHandlers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lifecycleClient) AddSecrets(ctx context.Context, in *SecretsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/pb.Lifecycle/AddSecrets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LifecycleServer is the server API for Lifecycle service.
 type LifecycleServer interface {
 	// Connect sends the initial request to the server to connect. Then
@@ -922,6 +1350,52 @@ type LifecycleServer interface {
 	// Loads a module by its name, and returns the GRPC location for the module
 	// Use this to setup a direct GRPC link to the module
 	LoadModule(context.Context, *LoadModuleRequest) (*LoadModuleResponse, error)
+	// Declare a handler
+	DeclareHandler(context.Context, *HandlerRequest) (*Empty, error)
+	// Queue a handler for a host
+	Notify(context.Context, *NotifyRequest) (*Empty, error)
+	// Run queued handlers now
+	FlushHandlers(context.Context, *FlushHandlersRequest) (*Response, error)
+	// Mark values as secrets
+	AddSecrets(context.Context, *SecretsRequest) (*Empty, error)
+}
+
+// UnimplementedLifecycleServer can be embedded to have forward compatible implementations.
+type UnimplementedLifecycleServer struct {
+}
+
+func (*UnimplementedLifecycleServer) Connect(srv Lifecycle_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (*UnimplementedLifecycleServer) ModuleCall(ctx context.Context, req *ModuleWorkRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModuleCall not implemented")
+}
+func (*UnimplementedLifecycleServer) Log(ctx context.Context, req *LogRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Log not implemented")
+}
+func (*UnimplementedLifecycleServer) Print(ctx context.Context, req *LogRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Print not implemented")
+}
+func (*UnimplementedLifecycleServer) GetCfg(ctx context.Context, req *CfgRequest) (*CfgResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCfg not implemented")
+}
+func (*UnimplementedLifecycleServer) GetArgs(ctx context.Context, req *Session) (*Args, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetArgs not implemented")
+}
+func (*UnimplementedLifecycleServer) LoadModule(ctx context.Context, req *LoadModuleRequest) (*LoadModuleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadModule not implemented")
+}
+func (*UnimplementedLifecycleServer) DeclareHandler(ctx context.Context, req *HandlerRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeclareHandler not implemented")
+}
+func (*UnimplementedLifecycleServer) Notify(ctx context.Context, req *NotifyRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Notify not implemented")
+}
+func (*UnimplementedLifecycleServer) FlushHandlers(ctx context.Context, req *FlushHandlersRequest) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushHandlers not implemented")
+}
+func (*UnimplementedLifecycleServer) AddSecrets(ctx context.Context, req *SecretsRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddSecrets not implemented")
 }
 
 func RegisterLifecycleServer(s *grpc.Server, srv LifecycleServer) {
@@ -1062,6 +1536,78 @@ func _Lifecycle_LoadModule_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Lifecycle_DeclareHandler_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandlerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).DeclareHandler(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Lifecycle/DeclareHandler",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).DeclareHandler(ctx, req.(*HandlerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifecycle_Notify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NotifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).Notify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Lifecycle/Notify",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).Notify(ctx, req.(*NotifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifecycle_FlushHandlers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushHandlersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).FlushHandlers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Lifecycle/FlushHandlers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).FlushHandlers(ctx, req.(*FlushHandlersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lifecycle_AddSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecretsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LifecycleServer).AddSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Lifecycle/AddSecrets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LifecycleServer).AddSecrets(ctx, req.(*SecretsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Lifecycle_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Lifecycle",
 	HandlerType: (*LifecycleServer)(nil),
@@ -1090,6 +1636,22 @@ var _Lifecycle_serviceDesc = grpc.ServiceDesc{
 			MethodName: "LoadModule",
 			Handler:    _Lifecycle_LoadModule_Handler,
 		},
+		{
+			MethodName: "DeclareHandler",
+			Handler:    _Lifecycle_DeclareHandler_Handler,
+		},
+		{
+			MethodName: "Notify",
+			Handler:    _Lifecycle_Notify_Handler,
+		},
+		{
+			MethodName: "FlushHandlers",
+			Handler:    _Lifecycle_FlushHandlers_Handler,
+		},
+		{
+			MethodName: "AddSecrets",
+			Handler:    _Lifecycle_AddSecrets_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1131,6 +1693,14 @@ type RequestProcessorServer interface {
 	Process(context.Context, *Request) (*Response, error)
 }
 
+// UnimplementedRequestProcessorServer can be embedded to have forward compatible implementations.
+type UnimplementedRequestProcessorServer struct {
+}
+
+func (*UnimplementedRequestProcessorServer) Process(ctx context.Context, req *Request) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Process not implemented")
+}
+
 func RegisterRequestProcessorServer(s *grpc.Server, srv RequestProcessorServer) {
 	s.RegisterService(&_RequestProcessor_serviceDesc, srv)
 }
@@ -4,10 +4,12 @@
 package pb
 
 import (
+	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
-	context "golang.org/x/net/context"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -20,7 +22,7 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 // Execute a command on a remote host
 type CommandRequest struct {
@@ -264,9 +266,13 @@ func (m *ReadResponse) GetError() *CommandError {
 
 // WaitHostRequest is used to wait until host becomes available.
 type WaitHostRequest struct {
-	Session              string   `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
-	HostId               string   `protobuf:"bytes,2,opt,name=hostId,proto3" json:"hostId,omitempty"`
-	Timeout              int64    `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Session string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	HostId  string `protobuf:"bytes,2,opt,name=hostId,proto3" json:"hostId,omitempty"`
+	Timeout int64  `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Initial poll interval. The interval doubles after every failed
+	// attempt up to maxInterval. If zero, defaults are used
+	PollInterval         int64    `protobuf:"varint,4,opt,name=pollInterval,proto3" json:"pollInterval,omitempty"`
+	MaxInterval          int64    `protobuf:"varint,5,opt,name=maxInterval,proto3" json:"maxInterval,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -318,6 +324,147 @@ func (m *WaitHostRequest) GetTimeout() int64 {
 	return 0
 }
 
+func (m *WaitHostRequest) GetPollInterval() int64 {
+	if m != nil {
+		return m.PollInterval
+	}
+	return 0
+}
+
+func (m *WaitHostRequest) GetMaxInterval() int64 {
+	if m != nil {
+		return m.MaxInterval
+	}
+	return 0
+}
+
+// RebootRequest reboots a host, and waits until it comes back
+type RebootRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	HostId               string   `protobuf:"bytes,2,opt,name=hostId,proto3" json:"hostId,omitempty"`
+	Timeout              int64    `protobuf:"varint,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	PollInterval         int64    `protobuf:"varint,4,opt,name=pollInterval,proto3" json:"pollInterval,omitempty"`
+	MaxInterval          int64    `protobuf:"varint,5,opt,name=maxInterval,proto3" json:"maxInterval,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RebootRequest) Reset()         { *m = RebootRequest{} }
+func (m *RebootRequest) String() string { return proto.CompactTextString(m) }
+func (*RebootRequest) ProtoMessage()    {}
+func (*RebootRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eefc82927d57d89b, []int{5}
+}
+
+func (m *RebootRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RebootRequest.Unmarshal(m, b)
+}
+func (m *RebootRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RebootRequest.Marshal(b, m, deterministic)
+}
+func (m *RebootRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RebootRequest.Merge(m, src)
+}
+func (m *RebootRequest) XXX_Size() int {
+	return xxx_messageInfo_RebootRequest.Size(m)
+}
+func (m *RebootRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RebootRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RebootRequest proto.InternalMessageInfo
+
+func (m *RebootRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *RebootRequest) GetHostId() string {
+	if m != nil {
+		return m.HostId
+	}
+	return ""
+}
+
+func (m *RebootRequest) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *RebootRequest) GetPollInterval() int64 {
+	if m != nil {
+		return m.PollInterval
+	}
+	return 0
+}
+
+func (m *RebootRequest) GetMaxInterval() int64 {
+	if m != nil {
+		return m.MaxInterval
+	}
+	return 0
+}
+
+type RebootResponse struct {
+	OldBootId            string        `protobuf:"bytes,1,opt,name=oldBootId,proto3" json:"oldBootId,omitempty"`
+	NewBootId            string        `protobuf:"bytes,2,opt,name=newBootId,proto3" json:"newBootId,omitempty"`
+	Error                *CommandError `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *RebootResponse) Reset()         { *m = RebootResponse{} }
+func (m *RebootResponse) String() string { return proto.CompactTextString(m) }
+func (*RebootResponse) ProtoMessage()    {}
+func (*RebootResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eefc82927d57d89b, []int{6}
+}
+
+func (m *RebootResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RebootResponse.Unmarshal(m, b)
+}
+func (m *RebootResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RebootResponse.Marshal(b, m, deterministic)
+}
+func (m *RebootResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RebootResponse.Merge(m, src)
+}
+func (m *RebootResponse) XXX_Size() int {
+	return xxx_messageInfo_RebootResponse.Size(m)
+}
+func (m *RebootResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RebootResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RebootResponse proto.InternalMessageInfo
+
+func (m *RebootResponse) GetOldBootId() string {
+	if m != nil {
+		return m.OldBootId
+	}
+	return ""
+}
+
+func (m *RebootResponse) GetNewBootId() string {
+	if m != nil {
+		return m.NewBootId
+	}
+	return ""
+}
+
+func (m *RebootResponse) GetError() *CommandError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
 // WriteRequest
 type WriteRequest struct {
 	Session         string `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
@@ -338,7 +485,7 @@ func (m *WriteRequest) Reset()         { *m = WriteRequest{} }
 func (m *WriteRequest) String() string { return proto.CompactTextString(m) }
 func (*WriteRequest) ProtoMessage()    {}
 func (*WriteRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{5}
+	return fileDescriptor_eefc82927d57d89b, []int{7}
 }
 
 func (m *WriteRequest) XXX_Unmarshal(b []byte) error {
@@ -431,76 +578,14 @@ func (m *WriteRequest) GetTemplate() *TemplateRequest {
 	return nil
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*WriteRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _WriteRequest_OneofMarshaler, _WriteRequest_OneofUnmarshaler, _WriteRequest_OneofSizer, []interface{}{
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*WriteRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
 		(*WriteRequest_Data)(nil),
 		(*WriteRequest_Template)(nil),
 	}
 }
 
-func _WriteRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*WriteRequest)
-	// source
-	switch x := m.Source.(type) {
-	case *WriteRequest_Data:
-		b.EncodeVarint(8<<3 | proto.WireBytes)
-		b.EncodeRawBytes(x.Data)
-	case *WriteRequest_Template:
-		b.EncodeVarint(9<<3 | proto.WireBytes)
-		if err := b.EncodeMessage(x.Template); err != nil {
-			return err
-		}
-	case nil:
-	default:
-		return fmt.Errorf("WriteRequest.Source has unexpected type %T", x)
-	}
-	return nil
-}
-
-func _WriteRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*WriteRequest)
-	switch tag {
-	case 8: // source.data
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeRawBytes(true)
-		m.Source = &WriteRequest_Data{x}
-		return true, err
-	case 9: // source.template
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(TemplateRequest)
-		err := b.DecodeMessage(msg)
-		m.Source = &WriteRequest_Template{msg}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _WriteRequest_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*WriteRequest)
-	// source
-	switch x := m.Source.(type) {
-	case *WriteRequest_Data:
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(len(x.Data)))
-		n += len(x.Data)
-	case *WriteRequest_Template:
-		s := proto.Size(x.Template)
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
-}
-
 type WriteResponse struct {
 	Modified             bool          `protobuf:"varint,1,opt,name=modified,proto3" json:"modified,omitempty"`
 	Error                *CommandError `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
@@ -513,7 +598,7 @@ func (m *WriteResponse) Reset()         { *m = WriteResponse{} }
 func (m *WriteResponse) String() string { return proto.CompactTextString(m) }
 func (*WriteResponse) ProtoMessage()    {}
 func (*WriteResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{6}
+	return fileDescriptor_eefc82927d57d89b, []int{8}
 }
 
 func (m *WriteResponse) XXX_Unmarshal(b []byte) error {
@@ -560,7 +645,7 @@ func (m *TemplateRequest) Reset()         { *m = TemplateRequest{} }
 func (m *TemplateRequest) String() string { return proto.CompactTextString(m) }
 func (*TemplateRequest) ProtoMessage()    {}
 func (*TemplateRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{7}
+	return fileDescriptor_eefc82927d57d89b, []int{9}
 }
 
 func (m *TemplateRequest) XXX_Unmarshal(b []byte) error {
@@ -607,7 +692,7 @@ func (m *TemplateResponse) Reset()         { *m = TemplateResponse{} }
 func (m *TemplateResponse) String() string { return proto.CompactTextString(m) }
 func (*TemplateResponse) ProtoMessage()    {}
 func (*TemplateResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{8}
+	return fileDescriptor_eefc82927d57d89b, []int{10}
 }
 
 func (m *TemplateResponse) XXX_Unmarshal(b []byte) error {
@@ -667,7 +752,7 @@ func (m *EnsureRequest) Reset()         { *m = EnsureRequest{} }
 func (m *EnsureRequest) String() string { return proto.CompactTextString(m) }
 func (*EnsureRequest) ProtoMessage()    {}
 func (*EnsureRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{9}
+	return fileDescriptor_eefc82927d57d89b, []int{11}
 }
 
 func (m *EnsureRequest) XXX_Unmarshal(b []byte) error {
@@ -805,7 +890,7 @@ func (m *EnsureResponse) Reset()         { *m = EnsureResponse{} }
 func (m *EnsureResponse) String() string { return proto.CompactTextString(m) }
 func (*EnsureResponse) ProtoMessage()    {}
 func (*EnsureResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{10}
+	return fileDescriptor_eefc82927d57d89b, []int{12}
 }
 
 func (m *EnsureResponse) XXX_Unmarshal(b []byte) error {
@@ -853,7 +938,7 @@ func (m *PathRequest) Reset()         { *m = PathRequest{} }
 func (m *PathRequest) String() string { return proto.CompactTextString(m) }
 func (*PathRequest) ProtoMessage()    {}
 func (*PathRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{11}
+	return fileDescriptor_eefc82927d57d89b, []int{13}
 }
 
 func (m *PathRequest) XXX_Unmarshal(b []byte) error {
@@ -909,7 +994,7 @@ func (m *ChmodRequest) Reset()         { *m = ChmodRequest{} }
 func (m *ChmodRequest) String() string { return proto.CompactTextString(m) }
 func (*ChmodRequest) ProtoMessage()    {}
 func (*ChmodRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{12}
+	return fileDescriptor_eefc82927d57d89b, []int{14}
 }
 
 func (m *ChmodRequest) XXX_Unmarshal(b []byte) error {
@@ -973,7 +1058,7 @@ func (m *ChownRequest) Reset()         { *m = ChownRequest{} }
 func (m *ChownRequest) String() string { return proto.CompactTextString(m) }
 func (*ChownRequest) ProtoMessage()    {}
 func (*ChownRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{13}
+	return fileDescriptor_eefc82927d57d89b, []int{15}
 }
 
 func (m *ChownRequest) XXX_Unmarshal(b []byte) error {
@@ -1042,7 +1127,7 @@ func (m *GetFileInfoResponse) Reset()         { *m = GetFileInfoResponse{} }
 func (m *GetFileInfoResponse) String() string { return proto.CompactTextString(m) }
 func (*GetFileInfoResponse) ProtoMessage()    {}
 func (*GetFileInfoResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{14}
+	return fileDescriptor_eefc82927d57d89b, []int{16}
 }
 
 func (m *GetFileInfoResponse) XXX_Unmarshal(b []byte) error {
@@ -1095,7 +1180,7 @@ func (m *OSResponse) Reset()         { *m = OSResponse{} }
 func (m *OSResponse) String() string { return proto.CompactTextString(m) }
 func (*OSResponse) ProtoMessage()    {}
 func (*OSResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{15}
+	return fileDescriptor_eefc82927d57d89b, []int{17}
 }
 
 func (m *OSResponse) XXX_Unmarshal(b []byte) error {
@@ -1137,7 +1222,7 @@ func (m *FileOwner) Reset()         { *m = FileOwner{} }
 func (m *FileOwner) String() string { return proto.CompactTextString(m) }
 func (*FileOwner) ProtoMessage()    {}
 func (*FileOwner) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{16}
+	return fileDescriptor_eefc82927d57d89b, []int{18}
 }
 
 func (m *FileOwner) XXX_Unmarshal(b []byte) error {
@@ -1201,7 +1286,7 @@ func (m *FileInfo) Reset()         { *m = FileInfo{} }
 func (m *FileInfo) String() string { return proto.CompactTextString(m) }
 func (*FileInfo) ProtoMessage()    {}
 func (*FileInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{17}
+	return fileDescriptor_eefc82927d57d89b, []int{19}
 }
 
 func (m *FileInfo) XXX_Unmarshal(b []byte) error {
@@ -1273,7 +1358,7 @@ func (m *CopyRequest) Reset()         { *m = CopyRequest{} }
 func (m *CopyRequest) String() string { return proto.CompactTextString(m) }
 func (*CopyRequest) ProtoMessage()    {}
 func (*CopyRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{18}
+	return fileDescriptor_eefc82927d57d89b, []int{20}
 }
 
 func (m *CopyRequest) XXX_Unmarshal(b []byte) error {
@@ -1348,7 +1433,7 @@ func (m *CopyResponse) Reset()         { *m = CopyResponse{} }
 func (m *CopyResponse) String() string { return proto.CompactTextString(m) }
 func (*CopyResponse) ProtoMessage()    {}
 func (*CopyResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_eefc82927d57d89b, []int{19}
+	return fileDescriptor_eefc82927d57d89b, []int{21}
 }
 
 func (m *CopyResponse) XXX_Unmarshal(b []byte) error {
@@ -1383,12 +1468,226 @@ func (m *CopyResponse) GetError() *CommandError {
 	return nil
 }
 
+type HostRequest struct {
+	Session              string   `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	HostId               string   `protobuf:"bytes,2,opt,name=hostId,proto3" json:"hostId,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HostRequest) Reset()         { *m = HostRequest{} }
+func (m *HostRequest) String() string { return proto.CompactTextString(m) }
+func (*HostRequest) ProtoMessage()    {}
+func (*HostRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eefc82927d57d89b, []int{22}
+}
+
+func (m *HostRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HostRequest.Unmarshal(m, b)
+}
+func (m *HostRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HostRequest.Marshal(b, m, deterministic)
+}
+func (m *HostRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HostRequest.Merge(m, src)
+}
+func (m *HostRequest) XXX_Size() int {
+	return xxx_messageInfo_HostRequest.Size(m)
+}
+func (m *HostRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HostRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HostRequest proto.InternalMessageInfo
+
+func (m *HostRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *HostRequest) GetHostId() string {
+	if m != nil {
+		return m.HostId
+	}
+	return ""
+}
+
+// Capabilities of the backend of a host
+type Capabilities struct {
+	Backend string `protobuf:"bytes,1,opt,name=backend,proto3" json:"backend,omitempty"`
+	// Output of commands can be streamed
+	Streaming bool `protobuf:"varint,2,opt,name=streaming,proto3" json:"streaming,omitempty"`
+	// Supported become methods
+	Become   []string `protobuf:"bytes,3,rep,name=become,proto3" json:"become,omitempty"`
+	Checksum bool     `protobuf:"varint,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Symlinks bool     `protobuf:"varint,5,opt,name=symlinks,proto3" json:"symlinks,omitempty"`
+	// Files are replaced atomically
+	AtomicRename bool `protobuf:"varint,6,opt,name=atomicRename,proto3" json:"atomicRename,omitempty"`
+	// File ownership can be changed
+	Ownership bool `protobuf:"varint,7,opt,name=ownership,proto3" json:"ownership,omitempty"`
+	// File transfer method: sftp, scp, stream, local, memory
+	FileTransfer         string        `protobuf:"bytes,8,opt,name=fileTransfer,proto3" json:"fileTransfer,omitempty"`
+	Error                *CommandError `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Capabilities) Reset()         { *m = Capabilities{} }
+func (m *Capabilities) String() string { return proto.CompactTextString(m) }
+func (*Capabilities) ProtoMessage()    {}
+func (*Capabilities) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eefc82927d57d89b, []int{23}
+}
+
+func (m *Capabilities) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Capabilities.Unmarshal(m, b)
+}
+func (m *Capabilities) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Capabilities.Marshal(b, m, deterministic)
+}
+func (m *Capabilities) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Capabilities.Merge(m, src)
+}
+func (m *Capabilities) XXX_Size() int {
+	return xxx_messageInfo_Capabilities.Size(m)
+}
+func (m *Capabilities) XXX_DiscardUnknown() {
+	xxx_messageInfo_Capabilities.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Capabilities proto.InternalMessageInfo
+
+func (m *Capabilities) GetBackend() string {
+	if m != nil {
+		return m.Backend
+	}
+	return ""
+}
+
+func (m *Capabilities) GetStreaming() bool {
+	if m != nil {
+		return m.Streaming
+	}
+	return false
+}
+
+func (m *Capabilities) GetBecome() []string {
+	if m != nil {
+		return m.Become
+	}
+	return nil
+}
+
+func (m *Capabilities) GetChecksum() bool {
+	if m != nil {
+		return m.Checksum
+	}
+	return false
+}
+
+func (m *Capabilities) GetSymlinks() bool {
+	if m != nil {
+		return m.Symlinks
+	}
+	return false
+}
+
+func (m *Capabilities) GetAtomicRename() bool {
+	if m != nil {
+		return m.AtomicRename
+	}
+	return false
+}
+
+func (m *Capabilities) GetOwnership() bool {
+	if m != nil {
+		return m.Ownership
+	}
+	return false
+}
+
+func (m *Capabilities) GetFileTransfer() string {
+	if m != nil {
+		return m.FileTransfer
+	}
+	return ""
+}
+
+func (m *Capabilities) GetError() *CommandError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+type ChecksumResponse struct {
+	// Hex encoded SHA256 checksum of the file
+	Sha256               string        `protobuf:"bytes,1,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Found                bool          `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Error                *CommandError `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ChecksumResponse) Reset()         { *m = ChecksumResponse{} }
+func (m *ChecksumResponse) String() string { return proto.CompactTextString(m) }
+func (*ChecksumResponse) ProtoMessage()    {}
+func (*ChecksumResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_eefc82927d57d89b, []int{24}
+}
+
+func (m *ChecksumResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChecksumResponse.Unmarshal(m, b)
+}
+func (m *ChecksumResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChecksumResponse.Marshal(b, m, deterministic)
+}
+func (m *ChecksumResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChecksumResponse.Merge(m, src)
+}
+func (m *ChecksumResponse) XXX_Size() int {
+	return xxx_messageInfo_ChecksumResponse.Size(m)
+}
+func (m *ChecksumResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChecksumResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChecksumResponse proto.InternalMessageInfo
+
+func (m *ChecksumResponse) GetSha256() string {
+	if m != nil {
+		return m.Sha256
+	}
+	return ""
+}
+
+func (m *ChecksumResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *ChecksumResponse) GetError() *CommandError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*CommandRequest)(nil), "pb.CommandRequest")
 	proto.RegisterType((*CommandResponse)(nil), "pb.CommandResponse")
 	proto.RegisterType((*ReadRequest)(nil), "pb.ReadRequest")
 	proto.RegisterType((*ReadResponse)(nil), "pb.ReadResponse")
 	proto.RegisterType((*WaitHostRequest)(nil), "pb.WaitHostRequest")
+	proto.RegisterType((*RebootRequest)(nil), "pb.RebootRequest")
+	proto.RegisterType((*RebootResponse)(nil), "pb.RebootResponse")
 	proto.RegisterType((*WriteRequest)(nil), "pb.WriteRequest")
 	proto.RegisterType((*WriteResponse)(nil), "pb.WriteResponse")
 	proto.RegisterType((*TemplateRequest)(nil), "pb.TemplateRequest")
@@ -1404,79 +1703,99 @@ func init() {
 	proto.RegisterType((*FileInfo)(nil), "pb.FileInfo")
 	proto.RegisterType((*CopyRequest)(nil), "pb.CopyRequest")
 	proto.RegisterType((*CopyResponse)(nil), "pb.CopyResponse")
+	proto.RegisterType((*HostRequest)(nil), "pb.HostRequest")
+	proto.RegisterType((*Capabilities)(nil), "pb.Capabilities")
+	proto.RegisterType((*ChecksumResponse)(nil), "pb.ChecksumResponse")
 }
 
 func init() { proto.RegisterFile("remote.proto", fileDescriptor_eefc82927d57d89b) }
 
 var fileDescriptor_eefc82927d57d89b = []byte{
-	// 1070 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xdb, 0x6e, 0xe4, 0x44,
-	0x13, 0xde, 0xc9, 0x8c, 0x27, 0x9e, 0x9a, 0xc9, 0xe1, 0xef, 0xe4, 0x07, 0xcb, 0xe2, 0x22, 0x32,
-	0x12, 0x64, 0x91, 0x48, 0x20, 0x80, 0xb8, 0x66, 0x93, 0x90, 0x44, 0x62, 0xd9, 0xa8, 0xc3, 0x6a,
-	0x25, 0x04, 0x17, 0x9e, 0x71, 0x4f, 0xc6, 0xda, 0xb1, 0xdb, 0xb4, 0x7b, 0x08, 0x01, 0x89, 0x2b,
-	0x9e, 0x01, 0x89, 0x47, 0xe1, 0x81, 0x10, 0xaf, 0x81, 0xaa, 0xfa, 0x60, 0x27, 0x3b, 0x1b, 0x56,
-	0x24, 0x77, 0xf5, 0x55, 0x57, 0x95, 0xcb, 0x5f, 0x55, 0x57, 0x35, 0x8c, 0x94, 0x28, 0xa4, 0x16,
-	0x7b, 0x95, 0x92, 0x5a, 0xb2, 0x95, 0x6a, 0x1c, 0xc3, 0x4c, 0xd6, 0xda, 0xe0, 0x78, 0x28, 0x8a,
-	0x4a, 0x5f, 0x1b, 0x90, 0x7c, 0x07, 0xeb, 0x87, 0xb2, 0x28, 0xd2, 0x32, 0xe3, 0xe2, 0x87, 0x85,
-	0xa8, 0x35, 0x8b, 0x60, 0xb5, 0x16, 0x75, 0x9d, 0xcb, 0x32, 0xea, 0xec, 0x74, 0x76, 0x07, 0xdc,
-	0x41, 0xf6, 0x16, 0xf4, 0x31, 0xcc, 0x59, 0x16, 0xad, 0xd0, 0x81, 0x45, 0xe8, 0x31, 0x31, 0x31,
-	0xa2, 0xae, 0xf1, 0xb0, 0x30, 0xf9, 0x1e, 0x36, 0x7c, 0xf4, 0xba, 0x92, 0x65, 0x2d, 0x30, 0x48,
-	0xad, 0x33, 0xb9, 0xd0, 0x14, 0x7d, 0xc4, 0x2d, 0xb2, 0x7a, 0xa1, 0x14, 0x05, 0x37, 0x7a, 0xa1,
-	0x14, 0x8b, 0x21, 0x14, 0x3f, 0xe5, 0xfa, 0x50, 0x66, 0x82, 0xa2, 0x77, 0xb9, 0xc7, 0xc9, 0x05,
-	0x0c, 0xb9, 0x48, 0xef, 0x91, 0x39, 0x83, 0xde, 0x34, 0x9f, 0x0b, 0x9b, 0x36, 0xc9, 0xc9, 0xef,
-	0x1d, 0x18, 0x99, 0xa8, 0x36, 0x63, 0x06, 0xbd, 0x2c, 0xd5, 0xa9, 0xcd, 0x97, 0x64, 0xd4, 0xd5,
-	0xf9, 0xcf, 0x82, 0xc2, 0x75, 0x39, 0xc9, 0x6c, 0x07, 0x7a, 0x79, 0x39, 0x95, 0x14, 0x6c, 0x78,
-	0x30, 0xda, 0xab, 0xc6, 0x7b, 0x5f, 0xe6, 0x73, 0x71, 0x56, 0x4e, 0x25, 0xa7, 0x13, 0xb6, 0x0d,
-	0xc1, 0x54, 0x2e, 0xca, 0x2c, 0xea, 0xed, 0x74, 0x76, 0x43, 0x6e, 0x00, 0x7b, 0x0f, 0x02, 0xa1,
-	0x94, 0x54, 0x51, 0x40, 0x8e, 0x9b, 0xe8, 0x68, 0x59, 0x3b, 0x46, 0x3d, 0x37, 0xc7, 0x48, 0xe6,
-	0x8b, 0x34, 0xd7, 0xa7, 0xb2, 0xd6, 0xf7, 0xaa, 0x95, 0xce, 0x0b, 0x81, 0xfc, 0x1b, 0x36, 0x1d,
-	0x4c, 0xfe, 0xee, 0xc0, 0xe8, 0x85, 0xca, 0xb5, 0xf8, 0xef, 0xc1, 0xb7, 0x21, 0xa8, 0x84, 0x2a,
-	0x6a, 0xfa, 0xbf, 0x2e, 0x37, 0x00, 0xb9, 0x2a, 0xd3, 0x42, 0x44, 0x7d, 0x43, 0x32, 0xca, 0x6c,
-	0x17, 0x36, 0x64, 0x39, 0xbf, 0x3e, 0x9b, 0x1e, 0xe5, 0xd3, 0xa9, 0x50, 0xa2, 0xd4, 0xd1, 0x2a,
-	0x71, 0x72, 0x5b, 0xcd, 0xb6, 0x2d, 0xfb, 0x21, 0xb2, 0x7f, 0xfa, 0xc8, 0xf2, 0xff, 0x31, 0x84,
-	0x5a, 0x14, 0xd5, 0x3c, 0xd5, 0x22, 0x1a, 0x10, 0x6d, 0x5b, 0x48, 0xdb, 0x37, 0x56, 0x67, 0x7f,
-	0xe1, 0xf4, 0x11, 0xf7, 0x66, 0x4f, 0x42, 0xe8, 0xd7, 0x72, 0xa1, 0x26, 0xd8, 0x36, 0x6b, 0xf6,
-	0x47, 0x6d, 0x85, 0x63, 0x08, 0x0b, 0x99, 0xe5, 0xd3, 0x5c, 0x64, 0xf4, 0xab, 0x21, 0xf7, 0xb8,
-	0xa9, 0xce, 0xca, 0xdd, 0xd5, 0xf9, 0x02, 0x36, 0x6e, 0x7d, 0x1d, 0xc3, 0xfa, 0x24, 0x0d, 0x51,
-	0x1e, 0xfb, 0xa6, 0xea, 0x36, 0x4d, 0x95, 0x7c, 0x05, 0x9b, 0x4d, 0x08, 0x9b, 0xda, 0x26, 0x74,
-	0xdd, 0x5d, 0x19, 0x70, 0x14, 0xdf, 0x38, 0xa1, 0xbf, 0x56, 0x60, 0xed, 0xb8, 0xac, 0x17, 0xca,
-	0xe7, 0xc3, 0xa0, 0x57, 0xa5, 0x7a, 0x66, 0x83, 0x91, 0x8c, 0xba, 0xc2, 0x5d, 0xad, 0x80, 0x93,
-	0x6c, 0x0a, 0xaf, 0x9f, 0xa2, 0xda, 0x34, 0xaa, 0x83, 0x98, 0xcd, 0x22, 0xcf, 0xa8, 0x51, 0x07,
-	0x1c, 0x45, 0xba, 0xb6, 0x42, 0x3f, 0xcf, 0x33, 0x2a, 0x6f, 0xc8, 0x2d, 0x42, 0xcb, 0xcb, 0x3c,
-	0xa3, 0xa2, 0x0e, 0x38, 0x8a, 0xd6, 0xf2, 0x24, 0xcf, 0xa8, 0x94, 0xc6, 0xf2, 0x24, 0xa7, 0x3b,
-	0xb8, 0xa8, 0x85, 0xa2, 0x32, 0x0e, 0x38, 0xc9, 0x36, 0x83, 0xe7, 0xa8, 0x06, 0x9f, 0x01, 0x42,
-	0x6c, 0xb1, 0x4b, 0x25, 0x17, 0x55, 0x34, 0x24, 0x73, 0x03, 0x90, 0x69, 0x8c, 0x46, 0x07, 0x23,
-	0x53, 0x40, 0x87, 0x31, 0x93, 0x2c, 0x57, 0xd1, 0x1a, 0xa9, 0x51, 0x44, 0xeb, 0xc9, 0x4c, 0x4c,
-	0x5e, 0x1e, 0xe5, 0x2a, 0x5a, 0x37, 0xd6, 0x0e, 0xb7, 0x9b, 0x7e, 0xfb, 0x75, 0x4d, 0xff, 0xff,
-	0x76, 0xd3, 0x27, 0x1c, 0xd6, 0x1d, 0xcd, 0xb6, 0x66, 0x38, 0x0f, 0x67, 0x69, 0x79, 0xe9, 0xbb,
-	0xc9, 0xc1, 0x37, 0xae, 0xdd, 0x05, 0x0c, 0xcf, 0x53, 0x3d, 0xbb, 0xd7, 0x60, 0xa3, 0x52, 0x77,
-	0x9b, 0x52, 0x27, 0x33, 0x18, 0x1d, 0xce, 0x0a, 0x99, 0x3d, 0x68, 0x54, 0xdf, 0x40, 0xbd, 0xa6,
-	0x81, 0x92, 0x5f, 0xf1, 0x4b, 0xf2, 0xaa, 0x7c, 0xf0, 0x2f, 0x51, 0xa3, 0xf4, 0x5a, 0x8d, 0xe2,
-	0xdb, 0x21, 0x68, 0xb5, 0x43, 0xf2, 0x5b, 0x07, 0xb6, 0x4e, 0x84, 0xf6, 0xd3, 0xd7, 0x15, 0xe6,
-	0x5d, 0x08, 0xe4, 0x55, 0x29, 0x14, 0x65, 0x31, 0x3c, 0x58, 0x73, 0x23, 0xfa, 0x19, 0x2a, 0xb9,
-	0x39, 0xf3, 0x63, 0x7c, 0xe5, 0xb5, 0x63, 0xdc, 0x57, 0xb1, 0x7b, 0x77, 0x15, 0x3f, 0x05, 0x78,
-	0x76, 0xe1, 0x3f, 0xee, 0xbd, 0x3a, 0x77, 0x7b, 0xfd, 0x02, 0x03, 0x9f, 0x13, 0x7b, 0x07, 0x06,
-	0x24, 0x7c, 0x8d, 0x03, 0xd4, 0x70, 0xd7, 0x28, 0x90, 0x57, 0x02, 0x67, 0x47, 0x96, 0x3e, 0x07,
-	0xd1, 0x8f, 0xba, 0x9f, 0xfc, 0x0c, 0x89, 0x8d, 0x02, 0xfd, 0x08, 0x9c, 0x1d, 0x59, 0x32, 0x1d,
-	0x4c, 0xe6, 0x10, 0xba, 0x9f, 0xf5, 0x73, 0xbb, 0xd3, 0x9a, 0xdb, 0xcb, 0xf6, 0xde, 0xb2, 0x11,
-	0xc2, 0xa0, 0x87, 0x7b, 0xc5, 0x2e, 0x02, 0x92, 0xdd, 0x45, 0x0c, 0xfc, 0x45, 0x4c, 0xfe, 0xec,
-	0xc0, 0xf0, 0x50, 0x56, 0xd7, 0xff, 0xde, 0x27, 0x31, 0x84, 0x53, 0x25, 0x0b, 0xdc, 0x7d, 0x6e,
-	0x94, 0x3a, 0xec, 0xce, 0xce, 0x9b, 0x7e, 0xf1, 0x18, 0xfb, 0x4b, 0x4b, 0xf2, 0x32, 0x3f, 0x6a,
-	0x91, 0xd1, 0x93, 0x47, 0xe0, 0xf4, 0x64, 0xbf, 0x64, 0x2f, 0xf5, 0x97, 0xee, 0xa5, 0xe4, 0x1c,
-	0x46, 0x26, 0xf5, 0x87, 0xba, 0xf4, 0x07, 0x7f, 0xf4, 0xa0, 0xcf, 0xe9, 0xe1, 0xc6, 0x0e, 0x60,
-	0xd5, 0x5a, 0x30, 0xd6, 0x32, 0xb7, 0x3c, 0xc5, 0x5b, 0x37, 0x74, 0x36, 0x81, 0x0f, 0x21, 0xc4,
-	0x67, 0x0b, 0x96, 0x8f, 0x6d, 0xa0, 0x41, 0xeb, 0x69, 0x14, 0x6f, 0x36, 0x0a, 0x6b, 0xfe, 0x11,
-	0x0c, 0x68, 0x09, 0x92, 0x3d, 0x1d, 0xb7, 0x97, 0x7f, 0xfc, 0xbf, 0x96, 0xc6, 0x7a, 0x7c, 0x06,
-	0xa1, 0x5b, 0x4f, 0x6c, 0xd9, 0xb6, 0x8d, 0xb7, 0x6f, 0x2a, 0x9b, 0xbc, 0x90, 0xa8, 0x26, 0xaf,
-	0x56, 0xc5, 0xe3, 0xcd, 0x46, 0x61, 0xcd, 0x3f, 0x80, 0xd0, 0xbd, 0x72, 0xcc, 0x57, 0x6e, 0xbd,
-	0x79, 0xe2, 0x01, 0x2a, 0x8f, 0xf1, 0x09, 0xcb, 0x3e, 0x87, 0x61, 0xeb, 0x9a, 0x9b, 0xe8, 0xad,
-	0xb9, 0x19, 0xbf, 0x8d, 0x8a, 0x65, 0x83, 0x60, 0x17, 0x82, 0xa7, 0x2f, 0x71, 0x15, 0xbc, 0xe2,
-	0xb2, 0x8e, 0x8a, 0xd6, 0xad, 0x7d, 0x0c, 0x01, 0x0d, 0x4d, 0x43, 0x51, 0x7b, 0x7e, 0x2e, 0x37,
-	0x95, 0x57, 0xa5, 0x33, 0x6d, 0x06, 0xe0, 0x2b, 0xa6, 0xfb, 0xd0, 0x37, 0x3b, 0x83, 0x11, 0xcf,
-	0x37, 0xd6, 0x74, 0xcc, 0xda, 0x2a, 0xe3, 0xf0, 0xe4, 0xf1, 0xb7, 0xef, 0x5f, 0xe6, 0x7a, 0xb6,
-	0x18, 0xef, 0x4d, 0x64, 0xb1, 0x3f, 0xae, 0x85, 0xca, 0x52, 0xb5, 0x7f, 0x95, 0x6a, 0xa1, 0x0a,
-	0x31, 0x97, 0xe5, 0x7e, 0x2d, 0xd4, 0x8f, 0x42, 0xed, 0x57, 0xe3, 0x71, 0x9f, 0x1e, 0xf6, 0x9f,
-	0xfc, 0x13, 0x00, 0x00, 0xff, 0xff, 0x7d, 0x8a, 0xf8, 0x1c, 0x05, 0x0c, 0x00, 0x00,
+	// 1334 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x57, 0xcd, 0x6e, 0x1c, 0x45,
+	0x10, 0xce, 0xfe, 0x66, 0xb6, 0x76, 0xfd, 0x93, 0x89, 0x81, 0xd1, 0x88, 0x83, 0x35, 0x48, 0xe0,
+	0x20, 0x61, 0x13, 0x93, 0xc0, 0x11, 0x11, 0x3b, 0x38, 0x96, 0x08, 0x89, 0x3a, 0x89, 0x22, 0x21,
+	0x38, 0xcc, 0xee, 0xf4, 0x7a, 0x5b, 0xde, 0x99, 0x1e, 0x7a, 0x7a, 0xe3, 0x18, 0x24, 0x4e, 0x3c,
+	0x03, 0x77, 0x04, 0x6f, 0xc0, 0x89, 0x07, 0x42, 0xbc, 0x06, 0xaa, 0xea, 0x9f, 0x99, 0x4d, 0x36,
+	0x26, 0xc2, 0x39, 0x70, 0xeb, 0xef, 0xeb, 0xea, 0x9a, 0xea, 0xaa, 0xea, 0xaa, 0x1a, 0x18, 0x29,
+	0x9e, 0x4b, 0xcd, 0x77, 0x4b, 0x25, 0xb5, 0x0c, 0xdb, 0xe5, 0x38, 0x86, 0x99, 0xac, 0xb4, 0xc1,
+	0xf1, 0x90, 0xe7, 0xa5, 0x3e, 0x37, 0x20, 0xf9, 0x16, 0xd6, 0x0f, 0x64, 0x9e, 0xa7, 0x45, 0xc6,
+	0xf8, 0xf7, 0x0b, 0x5e, 0xe9, 0x30, 0x82, 0xab, 0x15, 0xaf, 0x2a, 0x21, 0x8b, 0xa8, 0xb5, 0xdd,
+	0xda, 0x19, 0x30, 0x07, 0xc3, 0xb7, 0xa1, 0x8f, 0x6a, 0x8e, 0xb3, 0xa8, 0x4d, 0x1b, 0x16, 0xe1,
+	0x89, 0x89, 0xd1, 0x11, 0x75, 0xcc, 0x09, 0x0b, 0x93, 0xef, 0x60, 0xc3, 0x6b, 0xaf, 0x4a, 0x59,
+	0x54, 0x1c, 0x95, 0x54, 0x3a, 0x93, 0x0b, 0x4d, 0xda, 0x47, 0xcc, 0x22, 0xcb, 0x73, 0xa5, 0xa2,
+	0xb6, 0xe7, 0xb9, 0x52, 0x61, 0x0c, 0x01, 0x7f, 0x2e, 0xf4, 0x81, 0xcc, 0x38, 0x69, 0xef, 0x30,
+	0x8f, 0x93, 0x47, 0x30, 0x64, 0x3c, 0xbd, 0x84, 0xe5, 0x21, 0x74, 0xa7, 0x62, 0xce, 0xad, 0xd9,
+	0xb4, 0x4e, 0x7e, 0x69, 0xc1, 0xc8, 0x68, 0xb5, 0x16, 0x87, 0xd0, 0xcd, 0x52, 0x9d, 0x5a, 0x7b,
+	0x69, 0x8d, 0x5c, 0x25, 0x7e, 0xe0, 0xa4, 0xae, 0xc3, 0x68, 0x1d, 0x6e, 0x43, 0x57, 0x14, 0x53,
+	0x49, 0xca, 0x86, 0xfb, 0xa3, 0xdd, 0x72, 0xbc, 0xfb, 0xa5, 0x98, 0xf3, 0xe3, 0x62, 0x2a, 0x19,
+	0xed, 0x84, 0x5b, 0xd0, 0x9b, 0xca, 0x45, 0x91, 0x45, 0xdd, 0xed, 0xd6, 0x4e, 0xc0, 0x0c, 0x08,
+	0xdf, 0x87, 0x1e, 0x57, 0x4a, 0xaa, 0xa8, 0x47, 0x07, 0x37, 0xf1, 0xa0, 0xf5, 0xda, 0x5d, 0xe4,
+	0x99, 0xd9, 0x4e, 0x7e, 0x6b, 0xc1, 0xc6, 0xd3, 0x54, 0xe8, 0x7b, 0xb2, 0xd2, 0x97, 0x0a, 0x96,
+	0x16, 0x39, 0xc7, 0x00, 0x18, 0x77, 0x3a, 0x18, 0x26, 0x30, 0x2a, 0xe5, 0x7c, 0x7e, 0x5c, 0x68,
+	0xae, 0x9e, 0xa5, 0x73, 0x32, 0xb2, 0xc3, 0x96, 0xb8, 0x70, 0x1b, 0x86, 0x79, 0xfa, 0xdc, 0x8b,
+	0xf4, 0x48, 0xa4, 0x49, 0x25, 0xbf, 0xb6, 0x60, 0x8d, 0xf1, 0xb1, 0x94, 0xff, 0x63, 0x1b, 0x35,
+	0xac, 0x3b, 0x13, 0x6d, 0x8c, 0xdf, 0x85, 0x81, 0x9c, 0x67, 0x77, 0xa4, 0x44, 0x63, 0x8c, 0x95,
+	0x35, 0x81, 0xbb, 0x05, 0x3f, 0xb3, 0xbb, 0xc6, 0xd4, 0x9a, 0xa8, 0xe3, 0xd7, 0xb9, 0x38, 0x7e,
+	0x7f, 0xb7, 0x60, 0xf4, 0x54, 0x09, 0xcd, 0xff, 0xbb, 0x63, 0xb6, 0xa0, 0x57, 0x72, 0x95, 0x57,
+	0xf6, 0xde, 0x06, 0x60, 0x32, 0x16, 0x69, 0xce, 0xa3, 0xbe, 0xc9, 0x62, 0x5c, 0x87, 0x3b, 0xb0,
+	0x21, 0x8b, 0xf9, 0xf9, 0xf1, 0xf4, 0x50, 0x4c, 0xa7, 0x5c, 0xf1, 0x42, 0x47, 0x57, 0x29, 0xe9,
+	0x5e, 0xa4, 0xc3, 0x2d, 0x9b, 0xde, 0x01, 0xa6, 0xf7, 0xbd, 0x2b, 0x36, 0xc1, 0x6f, 0x42, 0xa0,
+	0x79, 0x5e, 0xce, 0x53, 0xcd, 0xa3, 0x01, 0xdd, 0xeb, 0x3a, 0xde, 0xeb, 0xb1, 0xe5, 0xec, 0x15,
+	0xee, 0x5d, 0x61, 0x5e, 0xec, 0x4e, 0x00, 0xfd, 0x4a, 0x2e, 0xd4, 0x04, 0xdf, 0xe5, 0x9a, 0xbd,
+	0xa8, 0x75, 0x6f, 0x0c, 0x41, 0x2e, 0x33, 0x31, 0x15, 0xdc, 0x78, 0x37, 0x60, 0x1e, 0xd7, 0xee,
+	0x6b, 0x5f, 0xec, 0xbe, 0x2f, 0x60, 0xe3, 0x85, 0xaf, 0xa3, 0x5a, 0x6f, 0xa4, 0x71, 0x94, 0xc7,
+	0xfe, 0xd5, 0x76, 0xea, 0x57, 0x9b, 0x7c, 0x05, 0x9b, 0xb5, 0x0a, 0x6b, 0xda, 0x26, 0x74, 0x5c,
+	0x31, 0x1a, 0x30, 0x5c, 0xbe, 0xb6, 0x41, 0x7f, 0xb5, 0x61, 0xed, 0x6e, 0x51, 0x2d, 0x94, 0xb7,
+	0x27, 0x84, 0x6e, 0x99, 0xea, 0x99, 0x55, 0x46, 0x6b, 0xe4, 0x72, 0x57, 0xbb, 0x7a, 0x8c, 0xd6,
+	0x26, 0xf0, 0xfa, 0x3e, 0xd2, 0xa6, 0x12, 0x38, 0x88, 0xd6, 0x2c, 0x44, 0x46, 0x39, 0x3b, 0x60,
+	0xb8, 0xa4, 0xba, 0xc8, 0xf5, 0x13, 0x91, 0x51, 0x78, 0x03, 0x66, 0x11, 0x4a, 0x9e, 0x88, 0x8c,
+	0x82, 0x3a, 0x60, 0x9d, 0x13, 0x2f, 0x79, 0x24, 0xb2, 0x28, 0xf0, 0x92, 0x47, 0x82, 0x8a, 0xdc,
+	0xa2, 0xe2, 0x8a, 0xc2, 0x38, 0x60, 0xb4, 0xb6, 0x16, 0x3c, 0x41, 0x1a, 0xbc, 0x05, 0x08, 0x31,
+	0xc5, 0x4e, 0x94, 0x5c, 0x94, 0xd1, 0x90, 0xc4, 0x0d, 0x40, 0x4f, 0xa3, 0x36, 0xda, 0x18, 0x99,
+	0x00, 0x3a, 0x8c, 0x96, 0x64, 0x42, 0x45, 0x6b, 0x44, 0xe3, 0x12, 0xa5, 0x27, 0x33, 0x3e, 0x39,
+	0x3d, 0x14, 0x2a, 0x5a, 0x37, 0xd2, 0x0e, 0x37, 0x93, 0x7e, 0xeb, 0x55, 0x49, 0xff, 0x56, 0x33,
+	0xe9, 0x13, 0x06, 0xeb, 0xce, 0xcd, 0x36, 0x66, 0xd8, 0x70, 0x66, 0x69, 0x71, 0xe2, 0xb3, 0xc9,
+	0xc1, 0xd7, 0x8e, 0xdd, 0x23, 0x18, 0x3e, 0x4c, 0xf5, 0xec, 0x52, 0x9d, 0x83, 0x42, 0xdd, 0xa9,
+	0x43, 0x9d, 0xcc, 0x60, 0x74, 0x30, 0xcb, 0x65, 0xf6, 0x46, 0xb5, 0xfa, 0x04, 0xea, 0xd6, 0x09,
+	0x94, 0xfc, 0x84, 0x5f, 0x92, 0x67, 0xc5, 0x1b, 0xff, 0x12, 0x25, 0x4a, 0xb7, 0x91, 0x28, 0x3e,
+	0x1d, 0x7a, 0x8d, 0x74, 0x48, 0x7e, 0x6e, 0xc1, 0xf5, 0x23, 0xae, 0x7d, 0x7b, 0x73, 0x81, 0x79,
+	0x0f, 0x7a, 0xf2, 0xac, 0xe0, 0x8a, 0xac, 0x18, 0xee, 0xaf, 0xb9, 0x1e, 0xf8, 0x00, 0x49, 0x66,
+	0xf6, 0x7c, 0x9f, 0x6c, 0xbf, 0xb2, 0x4f, 0xbe, 0x6e, 0x45, 0xbd, 0x05, 0xf0, 0xe0, 0x91, 0xff,
+	0xb8, 0x3f, 0xd5, 0xba, 0xf8, 0xd4, 0x8f, 0x30, 0xf0, 0x36, 0x61, 0x69, 0xa7, 0xc5, 0xd7, 0x58,
+	0x40, 0x6d, 0xe1, 0xf7, 0x04, 0xfa, 0x95, 0xc0, 0xf1, 0xa1, 0x75, 0x9f, 0x83, 0x78, 0x8e, 0xb2,
+	0x9f, 0xce, 0x19, 0x27, 0xd6, 0x04, 0x9e, 0x23, 0x70, 0x7c, 0x68, 0x9d, 0xe9, 0x60, 0x32, 0x87,
+	0xc0, 0x5d, 0xd6, 0xd7, 0xed, 0x56, 0xa3, 0x6e, 0xaf, 0x1a, 0x2c, 0x56, 0x95, 0x90, 0x10, 0xba,
+	0xd8, 0x13, 0x6d, 0x23, 0xa0, 0xb5, 0x7b, 0x88, 0x3d, 0xff, 0x10, 0x93, 0x3f, 0x5b, 0x30, 0x3c,
+	0x90, 0xe5, 0xf9, 0xbf, 0xe7, 0x49, 0x0c, 0xc1, 0x54, 0xc9, 0x1c, 0x67, 0x0b, 0x57, 0x4a, 0x1d,
+	0x76, 0x7b, 0x0f, 0xeb, 0x7c, 0xf1, 0x18, 0xf3, 0x4b, 0x4b, 0x3a, 0x65, 0x2e, 0x6a, 0x91, 0xe1,
+	0xe9, 0x44, 0xcf, 0xf1, 0x24, 0xbf, 0xa2, 0x2f, 0xf5, 0x57, 0xf6, 0xa5, 0xe4, 0x21, 0x8c, 0x8c,
+	0xe9, 0x6f, 0xec, 0xd1, 0x7f, 0x0e, 0xc3, 0x4b, 0xcd, 0x4e, 0xc9, 0xef, 0x6d, 0x18, 0x1d, 0xa4,
+	0x65, 0x3a, 0x16, 0x73, 0xa1, 0x05, 0xaf, 0x50, 0xc5, 0x38, 0x9d, 0x9c, 0xf2, 0xc2, 0x0d, 0x0d,
+	0x0e, 0x62, 0x7e, 0x54, 0x5a, 0xf1, 0x34, 0x17, 0xc5, 0x09, 0x69, 0x09, 0x58, 0x4d, 0xe0, 0x07,
+	0xc6, 0x7c, 0x22, 0x29, 0x75, 0x3a, 0xf8, 0x01, 0x83, 0x7c, 0xe1, 0xac, 0x16, 0xb9, 0xed, 0x0c,
+	0x1e, 0xe3, 0x5e, 0x75, 0x9e, 0xcf, 0x45, 0x71, 0x5a, 0xd9, 0x10, 0x7b, 0x8c, 0x63, 0x51, 0xaa,
+	0x65, 0x2e, 0x26, 0x8c, 0xfb, 0x49, 0x20, 0x60, 0x4b, 0x1c, 0x5a, 0x44, 0xef, 0xaf, 0x9a, 0x89,
+	0xd2, 0xce, 0x02, 0x35, 0x81, 0x1a, 0x70, 0xfa, 0x7d, 0xac, 0xd2, 0xa2, 0x9a, 0x72, 0x45, 0x2d,
+	0x64, 0xc0, 0x96, 0xb8, 0xda, 0xcf, 0x83, 0x8b, 0xfd, 0x3c, 0x83, 0xcd, 0x03, 0x6b, 0xf5, 0xd2,
+	0xd8, 0x3f, 0x4b, 0xf7, 0x6f, 0x7f, 0x6a, 0x1d, 0x65, 0x51, 0x3d, 0x12, 0xb7, 0x57, 0x8e, 0xc4,
+	0x17, 0x17, 0x80, 0xfd, 0x3f, 0x7a, 0xd0, 0x67, 0xf4, 0xaf, 0x13, 0xee, 0xc3, 0x55, 0x2b, 0x11,
+	0x86, 0x0d, 0x71, 0x1b, 0xec, 0xf8, 0xfa, 0x12, 0x67, 0x8d, 0xfa, 0x08, 0x02, 0x9c, 0xf4, 0xf1,
+	0x41, 0x86, 0x1b, 0x28, 0xd0, 0xf8, 0x9b, 0x88, 0x37, 0x6b, 0xc2, 0x8a, 0x7f, 0x0c, 0x03, 0x1a,
+	0x6b, 0x48, 0x9e, 0xb6, 0x9b, 0xe3, 0x5c, 0x7c, 0xad, 0xc1, 0xd8, 0x13, 0xb7, 0x21, 0x70, 0x03,
+	0x47, 0xb8, 0x6a, 0x7e, 0x8a, 0xb7, 0x96, 0xc9, 0xda, 0x2e, 0x4c, 0xfd, 0xda, 0xae, 0xc6, 0x1b,
+	0x8e, 0x37, 0x6b, 0xc2, 0x8a, 0x7f, 0x08, 0x81, 0xfb, 0x2f, 0x30, 0x5f, 0x79, 0xe1, 0x2f, 0x21,
+	0x1e, 0x20, 0x79, 0x17, 0xff, 0xfa, 0xc2, 0x3d, 0xe8, 0x9b, 0xd1, 0x37, 0xbc, 0x66, 0xee, 0xd7,
+	0x98, 0xd4, 0xe3, 0xb0, 0x49, 0x59, 0xe5, 0x9f, 0xc1, 0xb0, 0x51, 0xe9, 0x8d, 0x39, 0x8d, 0xd6,
+	0x19, 0xbf, 0x83, 0xc4, 0xaa, 0x5e, 0xb0, 0x03, 0xbd, 0xfb, 0xa7, 0x38, 0x0d, 0xbc, 0x74, 0x64,
+	0x1d, 0x89, 0x46, 0xe1, 0xbe, 0x01, 0x3d, 0xea, 0x9b, 0xc6, 0xa7, 0xcd, 0x16, 0xba, 0x5a, 0x54,
+	0x9e, 0x15, 0x4e, 0xb4, 0xee, 0x81, 0x2f, 0x89, 0xee, 0x41, 0xdf, 0x8c, 0x0d, 0xe6, 0xa6, 0x4b,
+	0x93, 0x5a, 0x1c, 0x36, 0x29, 0x7b, 0xe0, 0x16, 0x6c, 0x1c, 0x71, 0xbd, 0xf4, 0xbe, 0xc9, 0xf4,
+	0xa6, 0x27, 0xcd, 0x67, 0x9b, 0x22, 0x37, 0x21, 0x70, 0xc9, 0xfe, 0xf2, 0x4d, 0xb7, 0x8c, 0x95,
+	0xcb, 0x6f, 0xe1, 0xce, 0x8d, 0x6f, 0x3e, 0x38, 0x11, 0x7a, 0xb6, 0x18, 0xef, 0x4e, 0x64, 0xbe,
+	0x37, 0xae, 0xb8, 0xca, 0x52, 0xb5, 0x77, 0x96, 0x6a, 0xae, 0x72, 0x3e, 0x97, 0xc5, 0x5e, 0xc5,
+	0xd5, 0x33, 0xae, 0xf6, 0xca, 0xf1, 0xb8, 0x4f, 0x7f, 0xe9, 0x9f, 0xfc, 0x33, 0x00, 0x92, 0xcd,
+	0x75, 0x66, 0xd2, 0x0f, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1497,11 +1816,14 @@ type RemoteClient interface {
 	Template(ctx context.Context, in *TemplateRequest, opts ...grpc.CallOption) (*TemplateResponse, error)
 	CopyFile(ctx context.Context, in *CopyRequest, opts ...grpc.CallOption) (*CopyResponse, error)
 	WaitHost(ctx context.Context, in *WaitHostRequest, opts ...grpc.CallOption) (*Empty, error)
+	Reboot(ctx context.Context, in *RebootRequest, opts ...grpc.CallOption) (*RebootResponse, error)
 	GetFileInfo(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*GetFileInfoResponse, error)
 	Mkdir(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*OSResponse, error)
 	Chmod(ctx context.Context, in *ChmodRequest, opts ...grpc.CallOption) (*OSResponse, error)
 	Chown(ctx context.Context, in *ChownRequest, opts ...grpc.CallOption) (*OSResponse, error)
 	Ensure(ctx context.Context, in *EnsureRequest, opts ...grpc.CallOption) (*EnsureResponse, error)
+	GetCapabilities(ctx context.Context, in *HostRequest, opts ...grpc.CallOption) (*Capabilities, error)
+	Checksum(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*ChecksumResponse, error)
 }
 
 type remoteClient struct {
@@ -1566,6 +1888,15 @@ func (c *remoteClient) WaitHost(ctx context.Context, in *WaitHostRequest, opts .
 	return out, nil
 }
 
+func (c *remoteClient) Reboot(ctx context.Context, in *RebootRequest, opts ...grpc.CallOption) (*RebootResponse, error) {
+	out := new(RebootResponse)
+	err := c.cc.Invoke(ctx, "/pb.Remote/Reboot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *remoteClient) GetFileInfo(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*GetFileInfoResponse, error) {
 	out := new(GetFileInfoResponse)
 	err := c.cc.Invoke(ctx, "/pb.Remote/GetFileInfo", in, out, opts...)
@@ -1611,6 +1942,24 @@ func (c *remoteClient) Ensure(ctx context.Context, in *EnsureRequest, opts ...gr
 	return out, nil
 }
 
+func (c *remoteClient) GetCapabilities(ctx context.Context, in *HostRequest, opts ...grpc.CallOption) (*Capabilities, error) {
+	out := new(Capabilities)
+	err := c.cc.Invoke(ctx, "/pb.Remote/GetCapabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteClient) Checksum(ctx context.Context, in *PathRequest, opts ...grpc.CallOption) (*ChecksumResponse, error) {
+	out := new(ChecksumResponse)
+	err := c.cc.Invoke(ctx, "/pb.Remote/Checksum", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RemoteServer is the server API for Remote service.
 type RemoteServer interface {
 	Command(context.Context, *CommandRequest) (*CommandResponse, error)
@@ -1619,11 +1968,61 @@ type RemoteServer interface {
 	Template(context.Context, *TemplateRequest) (*TemplateResponse, error)
 	CopyFile(context.Context, *CopyRequest) (*CopyResponse, error)
 	WaitHost(context.Context, *WaitHostRequest) (*Empty, error)
+	Reboot(context.Context, *RebootRequest) (*RebootResponse, error)
 	GetFileInfo(context.Context, *PathRequest) (*GetFileInfoResponse, error)
 	Mkdir(context.Context, *PathRequest) (*OSResponse, error)
 	Chmod(context.Context, *ChmodRequest) (*OSResponse, error)
 	Chown(context.Context, *ChownRequest) (*OSResponse, error)
 	Ensure(context.Context, *EnsureRequest) (*EnsureResponse, error)
+	GetCapabilities(context.Context, *HostRequest) (*Capabilities, error)
+	Checksum(context.Context, *PathRequest) (*ChecksumResponse, error)
+}
+
+// UnimplementedRemoteServer can be embedded to have forward compatible implementations.
+type UnimplementedRemoteServer struct {
+}
+
+func (*UnimplementedRemoteServer) Command(ctx context.Context, req *CommandRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Command not implemented")
+}
+func (*UnimplementedRemoteServer) ReadFile(ctx context.Context, req *ReadRequest) (*ReadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadFile not implemented")
+}
+func (*UnimplementedRemoteServer) WriteFile(ctx context.Context, req *WriteRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteFile not implemented")
+}
+func (*UnimplementedRemoteServer) Template(ctx context.Context, req *TemplateRequest) (*TemplateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Template not implemented")
+}
+func (*UnimplementedRemoteServer) CopyFile(ctx context.Context, req *CopyRequest) (*CopyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CopyFile not implemented")
+}
+func (*UnimplementedRemoteServer) WaitHost(ctx context.Context, req *WaitHostRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitHost not implemented")
+}
+func (*UnimplementedRemoteServer) Reboot(ctx context.Context, req *RebootRequest) (*RebootResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reboot not implemented")
+}
+func (*UnimplementedRemoteServer) GetFileInfo(ctx context.Context, req *PathRequest) (*GetFileInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileInfo not implemented")
+}
+func (*UnimplementedRemoteServer) Mkdir(ctx context.Context, req *PathRequest) (*OSResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mkdir not implemented")
+}
+func (*UnimplementedRemoteServer) Chmod(ctx context.Context, req *ChmodRequest) (*OSResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chmod not implemented")
+}
+func (*UnimplementedRemoteServer) Chown(ctx context.Context, req *ChownRequest) (*OSResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chown not implemented")
+}
+func (*UnimplementedRemoteServer) Ensure(ctx context.Context, req *EnsureRequest) (*EnsureResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ensure not implemented")
+}
+func (*UnimplementedRemoteServer) GetCapabilities(ctx context.Context, req *HostRequest) (*Capabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
+func (*UnimplementedRemoteServer) Checksum(ctx context.Context, req *PathRequest) (*ChecksumResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Checksum not implemented")
 }
 
 func RegisterRemoteServer(s *grpc.Server, srv RemoteServer) {
@@ -1738,6 +2137,24 @@ func _Remote_WaitHost_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Remote_Reboot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteServer).Reboot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Remote/Reboot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteServer).Reboot(ctx, req.(*RebootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Remote_GetFileInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PathRequest)
 	if err := dec(in); err != nil {
@@ -1828,6 +2245,42 @@ func _Remote_Ensure_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Remote_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Remote/GetCapabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteServer).GetCapabilities(ctx, req.(*HostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Remote_Checksum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteServer).Checksum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.Remote/Checksum",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteServer).Checksum(ctx, req.(*PathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Remote_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.Remote",
 	HandlerType: (*RemoteServer)(nil),
@@ -1856,6 +2309,10 @@ var _Remote_serviceDesc = grpc.ServiceDesc{
 			MethodName: "WaitHost",
 			Handler:    _Remote_WaitHost_Handler,
 		},
+		{
+			MethodName: "Reboot",
+			Handler:    _Remote_Reboot_Handler,
+		},
 		{
 			MethodName: "GetFileInfo",
 			Handler:    _Remote_GetFileInfo_Handler,
@@ -1876,6 +2333,14 @@ var _Remote_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Ensure",
 			Handler:    _Remote_Ensure_Handler,
 		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _Remote_GetCapabilities_Handler,
+		},
+		{
+			MethodName: "Checksum",
+			Handler:    _Remote_Checksum_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "remote.proto",
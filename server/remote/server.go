@@ -3,6 +3,8 @@ package remote
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -106,20 +108,26 @@ func (s srv) WriteFile(ctx context.Context, req *pb.WriteRequest) (*pb.WriteResp
 		data = []byte(rsp.Out)
 	}
 
+	// Use the same host session for comparing and writing
+	hctx := h.NewCtx()
+	if _, err := hctx.New(session); err != nil {
+		return nil, err
+	}
+	defer hctx.Close()
 	if req.OnlyIfDifferent {
 		logger.Debugf("Checking if file changed")
-		_, oldData, _, err := h.ReadFile(h.NewCtx(), session, req.Name)
+		same, err := sameContent(h, hctx, session, req.Name, data)
 		if err != nil {
 			return nil, err
 		}
-		if bytes.Compare(oldData, data) == 0 {
+		if same {
 			logger.Debugf("File did not change")
 			return &pb.WriteResponse{}, nil
 		}
 	}
 
 	logger.Debugf("Writing %d bytes", len(data))
-	cerr, err := h.WriteFile(h.NewCtx(), session, req.Name, os.FileMode(req.Perms), data)
+	cerr, err := h.WriteFile(hctx, session, req.Name, os.FileMode(req.Perms), data)
 	if err != nil {
 		logger.Errorf("Write error: %v", err)
 		return nil, err
@@ -144,39 +152,56 @@ func (s srv) CopyFile(ctx context.Context, req *pb.CopyRequest) (*pb.CopyRespons
 		return nil, err
 	}
 	log.Debugf("Found fromHost, toHost")
-	fromCtx := fromHost.NewCtx()
-	fi, data, cerr, err := fromHost.ReadFile(fromCtx, session, req.FromPath)
+	// Only one host session is open at a time, so the copy does not
+	// wait for a fork while holding another one. The destination
+	// checksum is computed once, and used for both comparisons
+	var toSum string
+	toOk := false
+	if req.OnlyIfDifferent {
+		toSum, toOk, err = hostChecksum(toHost, toHost.NewCtx(), session, req.ToPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fi, data, same, cerr, err := readSource(fromHost, session, req.FromPath, toSum)
 	if err != nil {
 		log.Errorf("Cannot read file %s: %v", req.FromPath, err)
 		return nil, err
 	}
-	log.Debugf("Read source file")
-	if err != nil {
-		return &pb.CopyResponse{Error: cerr.ToPb()}, nil
+	if same {
+		log.Debugf("File will not change")
+		return &pb.CopyResponse{Changed: false}, nil
 	}
+	log.Debugf("Read source file")
 	if fi == nil {
 		s := fmt.Sprintf("File does not exist: %s:%s", req.FromHost, req.FromPath)
 		log.Error(s)
 		return &pb.CopyResponse{Changed: false, Error: server.NewCmdErr(fromHost, s).ToPb()}, nil
 	}
+	if cerr != nil {
+		return &pb.CopyResponse{Error: cerr.ToPb()}, nil
+	}
 
+	toCtx := toHost.NewCtx()
+	if _, err := toCtx.New(session); err != nil {
+		return nil, err
+	}
+	defer toCtx.Close()
 	if req.OnlyIfDifferent {
 		log.Debugf("Checking if file changed")
-		_, oldData, cerr, err := toHost.ReadFile(toHost.NewCtx(), session, req.ToPath)
+		same, err := sameAs(toHost, toCtx, session, req.ToPath, toSum, toOk, data)
 		if err != nil {
 			log.Debugf("Read dest file failed: %v", err)
 			return nil, err
 		}
-		if cerr != nil {
-			log.Debugf("Error reading dest file: %v", cerr)
-		} else if bytes.Compare(oldData, data) == 0 {
+		if same {
 			log.Debugf("File will not change")
 			return &pb.CopyResponse{Changed: false}, nil
 		}
 	}
 	log.Debugf("Writing dest file")
 
-	cerr, err = toHost.WriteFile(toHost.NewCtx(), session, req.ToPath, fi.Mode(), data)
+	cerr, err = toHost.WriteFile(toCtx, session, req.ToPath, fi.Mode(), data)
 	if err != nil {
 		log.Errorf("Cannot write %s: %v", req.ToPath, err)
 		return nil, err
@@ -194,28 +219,32 @@ func (s srv) WaitHost(ctx context.Context, req *pb.WaitHostRequest) (*pb.Empty,
 	if err != nil {
 		return nil, err
 	}
-	start := time.Now()
-	end := start.Add(time.Duration(req.Timeout))
-
-	c := h.NewCtx()
-
-	for {
-		log.Debugf("Waiting for host: %v", h)
-		if time.Now().After(end) {
-			return &pb.Empty{}, fmt.Errorf("Timeout while waiting for %s to become available", h.ID)
-		}
-
-		_, err := c.New(session)
-		if err == nil {
-			c.Close()
-			break
-		}
-		log.Debugf("Err: %v", err)
-		time.Sleep(time.Second * 10)
+	err = h.WaitUp(session, time.Duration(req.Timeout), server.WaitOptions{PollInterval: time.Duration(req.PollInterval),
+		MaxInterval: time.Duration(req.MaxInterval)})
+	if err != nil {
+		return nil, err
 	}
 	return &pb.Empty{}, nil
 }
 
+// Reboot reboots a host and waits until it comes back
+func (s srv) Reboot(ctx context.Context, req *pb.RebootRequest) (*pb.RebootResponse, error) {
+	session, h, err := server.GetHostAndSession(req.Session, req.HostId)
+	if err != nil {
+		return nil, err
+	}
+	oldID, newID, cerr, err := h.Reboot(session, time.Duration(req.Timeout), server.WaitOptions{PollInterval: time.Duration(req.PollInterval),
+		MaxInterval: time.Duration(req.MaxInterval)})
+	if err != nil {
+		return nil, err
+	}
+	ret := &pb.RebootResponse{OldBootId: oldID, NewBootId: newID}
+	if cerr != nil {
+		ret.Error = cerr.ToPb()
+	}
+	return ret, nil
+}
+
 func (s srv) GetFileInfo(ctx context.Context, req *pb.PathRequest) (*pb.GetFileInfoResponse, error) {
 	session, h, err := server.GetHostAndSession(req.Session, req.HostId)
 	if err != nil {
@@ -326,6 +355,125 @@ func (s srv) Ensure(ctx context.Context, req *pb.EnsureRequest) (*pb.EnsureRespo
 	return &pb.EnsureResponse{Changed: b}, nil
 }
 
+// hostChecksum returns the checksum of a file on the host. Returns
+// false if the backend of the host cannot compute checksums. The host
+// session is kept open until the checksum is computed
+func hostChecksum(h *server.Host, ctx server.Ctx, session server.Session, path string) (string, bool, error) {
+	if _, err := ctx.New(session); err != nil {
+		return "", false, err
+	}
+	defer ctx.Close()
+	caps, err := h.Capabilities(ctx, session)
+	if err != nil || !caps.Checksum {
+		return "", false, err
+	}
+	sum, cerr, err := h.Checksum(ctx, session, path)
+	if err != nil {
+		return "", false, err
+	}
+	if cerr != nil {
+		log.Debugf("Cannot get checksum of %s: %v", path, cerr)
+		return "", false, nil
+	}
+	return sum, true, nil
+}
+
+// readSource reads the source file of a copy. If sum is nonempty and
+// the source file has the same checksum, the file is not read, and
+// same is true
+func readSource(h *server.Host, session server.Session, path, sum string) (fi os.FileInfo, data []byte, same bool, cerr server.CmdErr, err error) {
+	ctx := h.NewCtx()
+	if _, err = ctx.New(session); err != nil {
+		return
+	}
+	defer ctx.Close()
+	if len(sum) > 0 {
+		var fromSum string
+		var ok bool
+		fromSum, ok, err = hostChecksum(h, ctx, session, path)
+		if err != nil {
+			return
+		}
+		if ok && fromSum == sum {
+			same = true
+			return
+		}
+	}
+	fi, data, cerr, err = h.ReadFile(ctx, session, path)
+	return
+}
+
+// sameContent returns true if the file on the host has the given
+// content. Compares checksums if the backend supports them, otherwise
+// reads the file
+func sameContent(h *server.Host, ctx server.Ctx, session server.Session, path string, data []byte) (bool, error) {
+	sum, ok, err := hostChecksum(h, ctx, session, path)
+	if err != nil {
+		return false, err
+	}
+	return sameAs(h, ctx, session, path, sum, ok, data)
+}
+
+// sameAs returns true if the file on the host has the given
+// content. If ok is true, sum is the checksum of the file on the
+// host. Otherwise the file is read
+func sameAs(h *server.Host, ctx server.Ctx, session server.Session, path, sum string, ok bool, data []byte) (bool, error) {
+	if ok {
+		if len(sum) == 0 {
+			return false, nil
+		}
+		local := sha256.Sum256(data)
+		return sum == hex.EncodeToString(local[:]), nil
+	}
+	fi, oldData, cerr, err := h.ReadFile(ctx, session, path)
+	if err != nil {
+		return false, err
+	}
+	if cerr != nil || fi == nil {
+		return false, nil
+	}
+	return bytes.Equal(oldData, data), nil
+}
+
+// GetCapabilities returns the capabilities of the host backend
+func (s srv) GetCapabilities(ctx context.Context, req *pb.HostRequest) (*pb.Capabilities, error) {
+	session, h, err := server.GetHostAndSession(req.Session, req.HostId)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := h.Capabilities(h.NewCtx(), session)
+	if err != nil {
+		if cerr, ok := err.(server.CmdErr); ok {
+			return &pb.Capabilities{Backend: h.BackendName, Error: cerr.ToPb()}, nil
+		}
+		return nil, err
+	}
+	return &pb.Capabilities{Backend: h.BackendName,
+		Streaming:    caps.Streaming,
+		Become:       caps.Become,
+		Checksum:     caps.Checksum,
+		Symlinks:     caps.Symlinks,
+		AtomicRename: caps.AtomicRename,
+		Ownership:    caps.Ownership,
+		FileTransfer: caps.FileTransfer}, nil
+}
+
+// Checksum returns the SHA256 checksum of a file on a host
+func (s srv) Checksum(ctx context.Context, req *pb.PathRequest) (*pb.ChecksumResponse, error) {
+	session, h, err := server.GetHostAndSession(req.Session, req.HostId)
+	if err != nil {
+		return nil, err
+	}
+	sum, cerr, err := h.Checksum(h.NewCtx(), session, req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if cerr != nil {
+		return &pb.ChecksumResponse{Error: cerr.ToPb()}, nil
+	}
+	return &pb.ChecksumResponse{Sha256: sum, Found: len(sum) > 0}, nil
+}
+
 // New returns a new server
 func New() pb.RemoteServer {
 	return srv{}
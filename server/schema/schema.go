@@ -0,0 +1,275 @@
+// Package schema validates configuration and function arguments
+// using the schemas declared by modules.
+//
+// A schema is a subset of JSON Schema: type, properties, required,
+// additionalProperties (boolean), items, enum, default, minimum,
+// maximum, minLength, maxLength, and pattern are supported. Objects
+// containing a valueFrom reference are not validated, because they
+// are only known when they are expanded.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema describes a value
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// ModuleSchema describes the configuration and the function
+// arguments a module expects
+type ModuleSchema struct {
+	// Config is the schema of the configuration root
+	Config *Schema `json:"config,omitempty"`
+	// Functions are the schemas of the function arguments by function
+	// name
+	Functions map[string]*Schema `json:"functions,omitempty"`
+}
+
+// Parse parses a JSON module schema
+func Parse(data []byte) (*ModuleSchema, error) {
+	var ret ModuleSchema
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// Merge adds the configuration and functions of s to m. The
+// configuration properties of s are added to the root properties of
+// m. Function schemas of m are not replaced
+func (m *ModuleSchema) Merge(s *ModuleSchema) {
+	if s == nil {
+		return
+	}
+	if s.Config != nil {
+		if m.Config == nil {
+			m.Config = &Schema{Type: "object"}
+		}
+		if m.Config.Properties == nil {
+			m.Config.Properties = map[string]*Schema{}
+		}
+		for k, v := range s.Config.Properties {
+			if _, ok := m.Config.Properties[k]; !ok {
+				m.Config.Properties[k] = v
+			}
+		}
+		m.Config.Required = append(m.Config.Required, s.Config.Required...)
+	}
+	for k, v := range s.Functions {
+		if m.Functions == nil {
+			m.Functions = map[string]*Schema{}
+		}
+		if _, ok := m.Functions[k]; !ok {
+			m.Functions[k] = v
+		}
+	}
+}
+
+// Violation is a value that does not conform to its schema
+type Violation struct {
+	// Path is the JSON pointer of the value
+	Path string
+	Msg  string
+}
+
+func (v Violation) Error() string {
+	path := v.Path
+	if len(path) == 0 {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, v.Msg)
+}
+
+// Validate validates value against the schema, and returns all the
+// violations. The paths of the violations start with path
+func (s *Schema) Validate(path string, value interface{}) []Violation {
+	ret := make([]Violation, 0)
+	s.validate(path, value, &ret)
+	return ret
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch k := value.(type) {
+	case int:
+		return float64(k), true
+	case int8:
+		return float64(k), true
+	case int16:
+		return float64(k), true
+	case int32:
+		return float64(k), true
+	case int64:
+		return float64(k), true
+	case uint:
+		return float64(k), true
+	case uint8:
+		return float64(k), true
+	case uint16:
+		return float64(k), true
+	case uint32:
+		return float64(k), true
+	case uint64:
+		return float64(k), true
+	case float32:
+		return float64(k), true
+	case float64:
+		return k, true
+	}
+	return 0, false
+}
+
+func hasType(value interface{}, t string) bool {
+	actual := typeOf(value)
+	switch t {
+	case "":
+		return true
+	case "integer":
+		f, ok := toFloat(value)
+		return ok && f == float64(int64(f))
+	}
+	return actual == t
+}
+
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func (s *Schema) validate(path string, value interface{}, ret *[]Violation) {
+	add := func(format string, args ...interface{}) {
+		*ret = append(*ret, Violation{Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+	if m, ok := value.(map[string]interface{}); ok {
+		if _, ok := m["valueFrom"]; ok {
+			return
+		}
+	}
+	if !hasType(value, s.Type) {
+		add("expecting %s, got %s", s.Type, typeOf(value))
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("%v is not one of %v", value, s.Enum)
+		}
+	}
+	if f, ok := toFloat(value); ok {
+		if s.Minimum != nil && f < *s.Minimum {
+			add("%v is less than %v", value, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			add("%v is greater than %v", value, *s.Maximum)
+		}
+	}
+	if str, ok := value.(string); ok {
+		if s.MinLength != nil && len(str) < *s.MinLength {
+			add("shorter than %d", *s.MinLength)
+		}
+		if s.MaxLength != nil && len(str) > *s.MaxLength {
+			add("longer than %d", *s.MaxLength)
+		}
+		if len(s.Pattern) > 0 {
+			if rx, err := regexp.Compile(s.Pattern); err != nil {
+				add("invalid pattern %s: %v", s.Pattern, err)
+			} else if !rx.MatchString(str) {
+				add("%s does not match %s", str, s.Pattern)
+			}
+		}
+	}
+	if a, ok := value.([]interface{}); ok && s.Items != nil {
+		for i, x := range a {
+			s.Items.validate(fmt.Sprintf("%s/%d", path, i), x, ret)
+		}
+	}
+	if m, ok := value.(map[string]interface{}); ok {
+		for _, r := range s.Required {
+			if _, ok := m[r]; !ok {
+				*ret = append(*ret, Violation{Path: path + "/" + escape(r), Msg: "required"})
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				p.validate(path+"/"+escape(k), m[k], ret)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*ret = append(*ret, Violation{Path: path + "/" + escape(k), Msg: "unknown key"})
+			}
+		}
+	}
+}
+
+func escape(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// Defaults returns a document containing the default values of the
+// schema. For objects, this contains the defaults of the properties.
+// Returns nil if there are no defaults
+func (s *Schema) Defaults() interface{} {
+	if s == nil {
+		return nil
+	}
+	if s.Default != nil {
+		return s.Default
+	}
+	ret := map[string]interface{}{}
+	for k, p := range s.Properties {
+		if d := p.Defaults(); d != nil {
+			ret[k] = d
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(`{"config": {"type": "object",
+  "required": ["db"],
+  "properties": {
+    "db": {"type": "object", "additionalProperties": false,
+      "required": ["host"],
+      "properties": {
+        "host": {"type": "string", "pattern": "^[a-z]+$"},
+        "port": {"type": "integer", "default": 5432, "maximum": 65535},
+        "tags": {"type": "array", "items": {"type": "string"}}}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := map[string]interface{}{"db": map[string]interface{}{
+		"host":  "DB",
+		"port":  70000,
+		"tags":  []interface{}{"a", 1},
+		"hostt": "typo"}}
+	expected := []string{"/db/host: DB does not match ^[a-z]+$", "/db/hostt: unknown key", "/db/port: 70000 is greater than 65535", "/db/tags/1: expecting string, got number"}
+	v := s.Config.Validate("", cfg)
+	if len(v) != len(expected) {
+		t.Fatalf("Wrong violations: %v", v)
+	}
+	for i := range v {
+		if v[i].Error() != expected[i] {
+			t.Errorf("Expecting %s, got %s", expected[i], v[i].Error())
+		}
+	}
+	if v := s.Config.Validate("", map[string]interface{}{}); len(v) != 1 || v[0].Error() != "/db: required" {
+		t.Errorf("Wrong violations: %v", v)
+	}
+	ref := map[string]interface{}{"db": map[string]interface{}{"valueFrom": map[string]interface{}{"type": "env"}}}
+	if v := s.Config.Validate("", ref); len(v) != 0 {
+		t.Errorf("References must not be validated: %v", v)
+	}
+	d := s.Config.Defaults().(map[string]interface{})
+	if d["db"].(map[string]interface{})["port"] != float64(5432) {
+		t.Errorf("Wrong defaults: %v", d)
+	}
+}
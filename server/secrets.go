@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Mask replaces the secrets in logs and outputs
+const Mask = "******"
+
+// MinSecretLength is the length of the shortest secret that is
+// masked. Shorter values are not masked, so common words and numbers
+// are not removed from the logs
+const MinSecretLength = 4
+
+// Redactor masks secret values in text
+type Redactor struct {
+	sync.RWMutex
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// Secrets are the secret values known to watermelon: vault values,
+// configuration values marked as secret, passwords, and the values
+// marked as secret by modules
+var Secrets = &Redactor{}
+
+// Add adds secret values
+func (r *Redactor) Add(values ...string) {
+	r.Lock()
+	defer r.Unlock()
+	for _, v := range values {
+		if len(v) < MinSecretLength {
+			continue
+		}
+		if r.secrets == nil {
+			r.secrets = make(map[string]struct{})
+		}
+		if _, ok := r.secrets[v]; !ok {
+			r.secrets[v] = struct{}{}
+			r.replacer = nil
+		}
+	}
+}
+
+func (r *Redactor) getReplacer() *strings.Replacer {
+	r.RLock()
+	rp, n := r.replacer, len(r.secrets)
+	r.RUnlock()
+	if rp != nil || n == 0 {
+		return rp
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.replacer == nil {
+		// Longer secrets first, so a secret containing another one is
+		// masked completely
+		values := make([]string, 0, len(r.secrets))
+		for v := range r.secrets {
+			values = append(values, v)
+		}
+		sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+		pairs := make([]string, 0, 2*len(values))
+		for _, v := range values {
+			pairs = append(pairs, v, Mask)
+		}
+		r.replacer = strings.NewReplacer(pairs...)
+	}
+	return r.replacer
+}
+
+// Redact returns s with the secrets masked
+func (r *Redactor) Redact(s string) string {
+	if rp := r.getReplacer(); rp != nil {
+		return rp.Replace(s)
+	}
+	return s
+}
+
+// RedactBytes returns b with the secrets masked
+func (r *Redactor) RedactBytes(b []byte) []byte {
+	rp := r.getReplacer()
+	if rp == nil {
+		return b
+	}
+	buf := bytes.Buffer{}
+	rp.WriteString(&buf, string(b))
+	return buf.Bytes()
+}
+
+// AddSecret adds secret values to Secrets
+func AddSecret(values ...string) { Secrets.Add(values...) }
+
+// AddSecretValues adds all the strings and numbers in a configuration
+// value to Secrets. valueFrom references are skipped
+func AddSecretValues(in interface{}) {
+	switch k := in.(type) {
+	case string:
+		Secrets.Add(k)
+	case int:
+		Secrets.Add(strconv.Itoa(k))
+	case float64:
+		Secrets.Add(strconv.FormatFloat(k, 'f', -1, 64))
+	case []interface{}:
+		for _, x := range k {
+			AddSecretValues(x)
+		}
+	case map[string]interface{}:
+		if _, ok := k["valueFrom"]; ok {
+			return
+		}
+		for _, x := range k {
+			AddSecretValues(x)
+		}
+	}
+}
+
+// IsPasswordKey returns if the configuration values under key are
+// passwords
+func IsPasswordKey(key string) bool {
+	k := strings.ToLower(key)
+	return strings.Contains(k, "password") || strings.Contains(k, "passphrase")
+}
+
+// AddPasswords adds the strings under password keys of a
+// configuration value to Secrets. valueFrom references are skipped,
+// they are added when they are expanded
+func AddPasswords(in interface{}) {
+	switch k := in.(type) {
+	case []interface{}:
+		for _, x := range k {
+			AddPasswords(x)
+		}
+	case map[string]interface{}:
+		if _, ok := k["valueFrom"]; ok {
+			return
+		}
+		for key, x := range k {
+			if IsPasswordKey(key) {
+				AddSecretValues(x)
+			} else {
+				AddPasswords(x)
+			}
+		}
+	}
+}
+
+// Redact returns s with the secrets masked
+func Redact(s string) string { return Secrets.Redact(s) }
+
+// RedactFormatter is a log formatter that masks the secrets in log
+// messages
+type RedactFormatter struct {
+	log.Formatter
+}
+
+// Format formats the entry using the underlying formatter, and masks
+// the secrets
+func (f RedactFormatter) Format(entry *log.Entry) ([]byte, error) {
+	out, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return Secrets.RedactBytes(out), nil
+}
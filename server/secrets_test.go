@@ -0,0 +1,39 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := &Redactor{}
+	if s := r.Redact("nothing to hide"); s != "nothing to hide" {
+		t.Errorf("Unexpected: %s", s)
+	}
+	r.Add("abc", "secret", "secretkey", "")
+	if s := r.Redact("abc secret secretkey"); s != "abc "+Mask+" "+Mask {
+		t.Errorf("Unexpected: %s", s)
+	}
+	r.Add("other")
+	if s := string(r.RedactBytes([]byte("other secret"))); s != Mask+" "+Mask {
+		t.Errorf("Unexpected: %s", s)
+	}
+}
+
+func TestAddPasswords(t *testing.T) {
+	AddPasswords(map[string]interface{}{
+		"db": map[string]interface{}{
+			"user":     "dbuser",
+			"password": "pwd-1234",
+		},
+		"users": []interface{}{
+			map[string]interface{}{"name": "admin", "loginPassword": "pwd-5678"},
+		},
+		"token": map[string]interface{}{
+			"password": map[string]interface{}{"valueFrom": map[string]interface{}{"type": "vault"}},
+		},
+	})
+	in := "dbuser pwd-1234 admin pwd-5678 vault"
+	if s := Redact(in); s != "dbuser "+Mask+" admin "+Mask+" vault" {
+		t.Errorf("Unexpected: %s", s)
+	}
+}
@@ -14,12 +14,19 @@ type Session interface {
 	GetID() string
 	// Close a session
 	Close()
+	// Done returns a channel that is closed when the session is
+	// closed
+	Done() <-chan struct{}
 	// GetLogger returns a logger for the host
 	GetLogger(host *Host) Logger
 	// GetHost returns a host from the session
 	GetHost(hostID string) (*Host, error)
-	// GetCfg returns a path from cfg, either seen by host, or global config
-	GetCfg(hostId, path string) interface{}
+	// GetCfg returns a path from cfg, either seen by host, or global
+	// config, with the valueFrom references expanded
+	GetCfg(hostId, path string) (interface{}, error)
+	// GetModuleCfg is GetCfg as seen by module, including the layers
+	// of the module
+	GetModuleCfg(module, hostId, path string) (interface{}, error)
 
 	// GetInv returns inventory
 	GetInv() InternalInventory
@@ -28,10 +35,25 @@ type Session interface {
 	SetModules(ModuleMgr)
 	SetLogStdout(bool)
 	SetLog(Logging)
+	// GetConfig returns the global configuration
 	GetConfig() interface{}
+	// SetConfig sets the configuration as a single layer
 	SetConfig(interface{})
+	// GetConfigLayers returns the configuration layers ordered from
+	// the lowest precedence to the highest
+	GetConfigLayers() []ConfigLayer
+	SetConfigLayers([]ConfigLayer)
+	// AddDefaultConfig adds a configuration layer with the lowest
+	// precedence, unless there is a layer with the same name and
+	// module
+	AddDefaultConfig(ConfigLayer)
+	// ExplainCfg returns the value at path seen by host, and the
+	// configuration layers that have a value at path
+	ExplainCfg(hostId, path string) (interface{}, []CfgSource, error)
 	SetArgs([]string)
 	GetArgs() []string
+	// GetHandlers returns the handlers declared in this session
+	GetHandlers() *Handlers
 }
 
 type sessionRegistry struct {
@@ -0,0 +1,351 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+// DefaultCommandTimeout is the timeout of a cmd reference if it does
+// not specify one
+const DefaultCommandTimeout = time.Minute
+
+func init() {
+	Extensions["env"] = func(server.Session) Extension { return &envExpander{} }
+	Extensions["file"] = func(server.Session) Extension { return &fileExpander{} }
+	PathFields["file"] = []string{"path"}
+	Extensions["cmd"] = func(server.Session) Extension { return &cmdExpander{} }
+	Extensions["config"] = func(s server.Session) Extension {
+		return &configExpander{session: s.(*Session), values: make(map[string]interface{})}
+	}
+}
+
+// refCache keeps the expanded values of references, so each
+// reference is resolved once in a session
+type refCache struct {
+	sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// get returns the value of the reference from the cache, or calls f
+// to compute it. Concurrent calls for the same reference wait for the
+// first one. Errors are not cached
+func (c *refCache) get(ref map[string]interface{}, f func() (interface{}, error)) (interface{}, error) {
+	key, err := json.Marshal(ref)
+	if err != nil {
+		return nil, err
+	}
+	c.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+	if e, ok := c.entries[string(key)]; ok {
+		c.Unlock()
+		<-e.done
+		return e.value, e.err
+	}
+	e := &cacheEntry{done: make(chan struct{})}
+	c.entries[string(key)] = e
+	c.Unlock()
+
+	e.value, e.err = f()
+	if e.err != nil {
+		c.Lock()
+		delete(c.entries, string(key))
+		c.Unlock()
+	}
+	close(e.done)
+	return e.value, e.err
+}
+
+func stringField(ref map[string]interface{}, name string, required bool) (string, error) {
+	v, ok := ref[name]
+	if !ok {
+		if required {
+			return "", fmt.Errorf("%s is required", name)
+		}
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string: %v", name, v)
+	}
+	return s, nil
+}
+
+// parseValue parses data using format, and returns the item at
+// pointer. Format is raw, json, or yaml. Raw data is returned as a
+// string without the trailing newlines, and cannot have a pointer
+func parseValue(data []byte, format, pointer string) (interface{}, error) {
+	var doc interface{}
+	switch format {
+	case "", "raw":
+		if len(pointer) > 0 {
+			return nil, fmt.Errorf("pointer %s requires json or yaml format", pointer)
+		}
+		return string(bytes.TrimRight(data, "\r\n")), nil
+	case "json":
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		doc = server.MapYaml(doc)
+	default:
+		return nil, fmt.Errorf("Unknown format: %s", format)
+	}
+	if len(pointer) == 0 {
+		return doc, nil
+	}
+	v := server.ResolveCfg(pointer, doc)
+	if v == nil {
+		return nil, fmt.Errorf("%s not found", pointer)
+	}
+	return v, nil
+}
+
+// envExpander returns the value of an environment variable:
+//
+//	valueFrom:
+//	  type: env
+//	  name: DB_PASSWORD
+//	  default: secret
+//
+// It is an error if the variable is not set and there is no default
+type envExpander struct {
+	refCache
+}
+
+func (e *envExpander) ExpandConfig(ref Ref) (interface{}, error) {
+	return e.get(ref.Value, func() (interface{}, error) { return envValue(ref) })
+}
+
+// CheckRef checks if the variable is set, or there is a default
+func (e *envExpander) CheckRef(ref Ref) error {
+	_, err := envValue(ref)
+	return err
+}
+
+func envValue(ref Ref) (interface{}, error) {
+	name, err := stringField(ref.Value, "name", true)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := os.LookupEnv(name); ok {
+		return v, nil
+	}
+	if def, ok := ref.Value["default"]; ok {
+		return def, nil
+	}
+	return nil, fmt.Errorf("Environment variable %s is not set", name)
+}
+
+// fileExpander returns the contents of a local file, or an item in
+// it:
+//
+//	valueFrom:
+//	  type: file
+//	  path: secrets/db.json
+//	  pointer: /password
+//
+// format is raw, json, or yaml. If a pointer is given without a
+// format, the format is determined from the file extension. A relative
+// path is relative to the directory of the configuration file
+type fileExpander struct {
+	refCache
+}
+
+func (e *fileExpander) ExpandConfig(ref Ref) (interface{}, error) {
+	return e.get(ref.Value, func() (interface{}, error) { return fileValue(ref) })
+}
+
+// CheckRef checks if the file can be read, and has the item at pointer
+func (e *fileExpander) CheckRef(ref Ref) error {
+	_, err := fileValue(ref)
+	return err
+}
+
+func fileValue(ref Ref) (interface{}, error) {
+	path, err := stringField(ref.Value, "path", true)
+	if err != nil {
+		return nil, err
+	}
+	format, err := stringField(ref.Value, "format", false)
+	if err != nil {
+		return nil, err
+	}
+	pointer, err := stringField(ref.Value, "pointer", false)
+	if err != nil {
+		return nil, err
+	}
+	if len(format) == 0 && len(pointer) > 0 {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			format = "json"
+		case ".yml", ".yaml":
+			format = "yaml"
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseValue(data, format, pointer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return v, nil
+}
+
+// cmdExpander runs a local command using the shell, and returns its
+// output, or an item in it:
+//
+//	valueFrom:
+//	  type: cmd
+//	  command: pass show db/password
+//	  timeout: 10s
+//
+// format and pointer are as in the file reference. The command runs
+// once in a session
+type cmdExpander struct {
+	refCache
+}
+
+// cmdRef is a parsed cmd reference
+type cmdRef struct {
+	command, format, pointer string
+	timeout                  time.Duration
+}
+
+func parseCmdRef(ref Ref) (cmdRef, error) {
+	var ret cmdRef
+	var err error
+	if ret.command, err = stringField(ref.Value, "command", true); err != nil {
+		return ret, err
+	}
+	if ret.format, err = stringField(ref.Value, "format", false); err != nil {
+		return ret, err
+	}
+	switch ret.format {
+	case "", "raw", "json", "yaml":
+	default:
+		return ret, fmt.Errorf("Unknown format: %s", ret.format)
+	}
+	if ret.pointer, err = stringField(ref.Value, "pointer", false); err != nil {
+		return ret, err
+	}
+	t, err := stringField(ref.Value, "timeout", false)
+	if err != nil {
+		return ret, err
+	}
+	ret.timeout = DefaultCommandTimeout
+	if len(t) > 0 {
+		if ret.timeout, err = time.ParseDuration(t); err != nil {
+			return ret, fmt.Errorf("Invalid timeout: %v", err)
+		}
+	}
+	return ret, nil
+}
+
+// CheckRef checks the fields of the reference. The command is not
+// run
+func (e *cmdExpander) CheckRef(ref Ref) error {
+	_, err := parseCmdRef(ref)
+	return err
+}
+
+func (e *cmdExpander) ExpandConfig(ref Ref) (interface{}, error) {
+	return e.get(ref.Value, func() (interface{}, error) {
+		r, err := parseCmdRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		command, format, pointer, timeout := r.command, r.format, r.pointer, r.timeout
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("timed out after %s", timeout)
+			}
+			if msg := strings.TrimSpace(stderr.String()); len(msg) > 0 {
+				return nil, fmt.Errorf("%s: %v: %s", command, err, msg)
+			}
+			return nil, fmt.Errorf("%s: %v", command, err)
+		}
+		v, err := parseValue(stdout.Bytes(), format, pointer)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", command, err)
+		}
+		return v, nil
+	})
+}
+
+// configExpander returns another configuration item, as seen by the
+// same host and module:
+//
+//	valueFrom:
+//	  type: config
+//	  path: /db/password
+type configExpander struct {
+	session *Session
+
+	sync.Mutex
+	values map[string]interface{}
+}
+
+// CheckRef checks that the reference has a path. The path is resolved
+// when the reference is expanded
+func (e *configExpander) CheckRef(ref Ref) error {
+	_, err := stringField(ref.Value, "path", true)
+	return err
+}
+
+func (e *configExpander) ExpandConfig(ref Ref) (interface{}, error) {
+	path, err := stringField(ref.Value, "path", true)
+	if err != nil {
+		return nil, err
+	}
+	// The values are not computed under the lock, because expanding
+	// them may expand other config references
+	key := ref.Module + "\x00" + ref.HostID + "\x00" + path
+	e.Lock()
+	v, ok := e.values[key]
+	e.Unlock()
+	if ok {
+		return v, nil
+	}
+	v, err = e.session.expandPath(ref.Module, ref.HostID, path, ref.paths)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("Configuration %s not found", path)
+	}
+	e.Lock()
+	e.values[key] = v
+	e.Unlock()
+	return v, nil
+}
@@ -0,0 +1,169 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bserdar/watermelon/server"
+)
+
+func ref(fields ...interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	for i := 0; i+1 < len(fields); i += 2 {
+		ret[fields[i].(string)] = fields[i+1]
+	}
+	return map[string]interface{}{"valueFrom": ret}
+}
+
+func TestExpanders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "expanders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "db.json"), []byte(`{"db":{"password":"jsonpwd"}}`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "token"), []byte("rawtoken\n"), 0600)
+	counter := filepath.Join(dir, "counter")
+	os.Setenv("WM_TEST_EXPANDER", "envvalue")
+	defer os.Unsetenv("WM_TEST_EXPANDER")
+
+	s := Factory().(*Session)
+	s.SetConfig(map[string]interface{}{
+		"env":        ref("type", "env", "name", "WM_TEST_EXPANDER"),
+		"envDefault": ref("type", "env", "name", "WM_TEST_UNSET", "default", "def"),
+		"envMissing": ref("type", "env", "name", "WM_TEST_UNSET"),
+		"raw":        ref("type", "file", "path", filepath.Join(dir, "token")),
+		"json":       ref("type", "file", "path", filepath.Join(dir, "db.json"), "pointer", "/db/password"),
+		"cmd":        ref("type", "cmd", "command", "echo x >> "+counter+"; echo '{\"a\": [1, 2]}'", "format", "yaml", "pointer", "/a"),
+		"cmdFail":    ref("type", "cmd", "command", "echo bad >&2; exit 3"),
+		"alias":      ref("type", "config", "path", "/env"),
+		"cycle1":     ref("type", "config", "path", "/cycle2"),
+		"cycle2":     ref("type", "config", "path", "/cycle1"),
+		"unknown":    ref("type", "nope"),
+	})
+
+	expect := map[string]string{
+		"/env":        "envvalue",
+		"/envDefault": "def",
+		"/raw":        "rawtoken",
+		"/json":       "jsonpwd",
+		"/alias":      "envvalue",
+	}
+	for path, value := range expect {
+		v, err := s.GetCfg("", path)
+		if err != nil || v != value {
+			t.Errorf("%s: expecting %s, got %v %v", path, value, v, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		v, err := s.GetCfg("", "/cmd")
+		if a, ok := v.([]interface{}); err != nil || !ok || len(a) != 2 {
+			t.Errorf("Wrong cmd output: %v %v", v, err)
+		}
+	}
+	if data, _ := ioutil.ReadFile(counter); string(data) != "x\n" {
+		t.Errorf("Command not cached: %q", data)
+	}
+
+	errs := map[string]string{
+		"/envMissing": "WM_TEST_UNSET is not set",
+		"/cmdFail":    "bad",
+		"/cycle1":     "cycle",
+		"/unknown":    "Unknown extension",
+	}
+	for path, msg := range errs {
+		_, err := s.GetCfg("", path)
+		if err == nil || !strings.Contains(err.Error(), msg) || !strings.HasPrefix(err.Error(), path) {
+			t.Errorf("%s: expecting error with %s, got %v", path, msg, err)
+		}
+	}
+}
+
+func TestSecretRef(t *testing.T) {
+	os.Setenv("WM_TEST_SECRET", "secretvalue")
+	defer os.Unsetenv("WM_TEST_SECRET")
+	os.Setenv("WM_TEST_PUBLIC", "publicvalue")
+	defer os.Unsetenv("WM_TEST_PUBLIC")
+
+	s := Factory().(*Session)
+	s.SetConfig(map[string]interface{}{
+		"secret": ref("type", "env", "name", "WM_TEST_SECRET", "secret", true),
+		"public": ref("type", "env", "name", "WM_TEST_PUBLIC"),
+	})
+	if _, err := s.GetCfg("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if s := server.Redact("secretvalue publicvalue"); s != server.Mask+" publicvalue" {
+		t.Errorf("Unexpected: %s", s)
+	}
+}
+
+func TestCheckRef(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "db.json"), []byte(`{"db":{"password":"jsonpwd"}}`), 0600)
+	counter := filepath.Join(dir, "counter")
+
+	valid := []map[string]interface{}{
+		ref("type", "env", "name", "WM_TEST_UNSET", "default", "def"),
+		ref("type", "file", "path", filepath.Join(dir, "db.json"), "pointer", "/db/password"),
+		ref("type", "cmd", "command", "echo x >> "+counter),
+		ref("type", "config", "path", "/undefined"),
+	}
+	for _, x := range valid {
+		if err := CheckRef(x["valueFrom"]); err != nil {
+			t.Errorf("%v: %v", x, err)
+		}
+	}
+	if _, err := os.Stat(counter); err == nil {
+		t.Errorf("Command run by check")
+	}
+
+	invalid := map[string]map[string]interface{}{
+		"is not set":         ref("type", "env", "name", "WM_TEST_UNSET"),
+		"no such file":       ref("type", "file", "path", filepath.Join(dir, "missing")),
+		"/db/user not found": ref("type", "file", "path", filepath.Join(dir, "db.json"), "pointer", "/db/user"),
+		"Invalid timeout":    ref("type", "cmd", "command", "true", "timeout", "soon"),
+		"path is required":   ref("type", "config"),
+		"Unknown extension":  ref("type", "nope"),
+	}
+	for msg, x := range invalid {
+		if err := CheckRef(x["valueFrom"]); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%v: expecting error with %s, got %v", x, msg, err)
+		}
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	cfg := map[string]interface{}{
+		"rel":  ref("type", "file", "path", "token"),
+		"abs":  ref("type", "file", "path", "/etc/token"),
+		"env":  ref("type", "env", "name", "path"),
+		"list": []interface{}{ref("type", "file", "path", "x/y")},
+	}
+	out := ResolvePaths(cfg, "/cfg").(map[string]interface{})
+	path := func(v interface{}) interface{} {
+		return v.(map[string]interface{})["valueFrom"].(map[string]interface{})["path"]
+	}
+	if p := path(out["rel"]); p != filepath.Join("/cfg", "token") {
+		t.Errorf("Wrong relative path: %v", p)
+	}
+	if p := path(out["abs"]); p != "/etc/token" {
+		t.Errorf("Wrong absolute path: %v", p)
+	}
+	if n := out["env"].(map[string]interface{})["valueFrom"].(map[string]interface{})["name"]; n != "path" {
+		t.Errorf("Wrong env ref: %v", n)
+	}
+	if p := path(out["list"].([]interface{})[0]); p != filepath.Join("/cfg", "x/y") {
+		t.Errorf("Wrong path in list: %v", p)
+	}
+	if p := path(cfg["rel"]); p != "token" {
+		t.Errorf("Input modified: %v", p)
+	}
+}
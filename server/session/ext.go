@@ -1,19 +1,87 @@
 package session
 
 import (
+	"path/filepath"
+
 	"github.com/bserdar/watermelon/server"
 )
 
 // Registered extensions
 var (
 	Extensions = map[string]func(server.Session) Extension{}
+	// PathFields are the fields of the references of an extension
+	// that are file paths. Relative paths in them are relative to the
+	// directory of the configuration file
+	PathFields = map[string][]string{}
 )
 
 // Extension interface
 type Extension interface {
 }
 
+// Ref is a configuration reference given in a valueFrom object
+type Ref struct {
+	// HostID is the host whose configuration is being expanded. It is
+	// empty for the global configuration
+	HostID string
+	// Module is the module whose configuration is being expanded. It
+	// is empty for the configuration of the session
+	Module string
+	// Value is the valueFrom object
+	Value map[string]interface{}
+
+	// The configuration paths being expanded, to detect cycles
+	paths []string
+}
+
 // ConfigExpander is an extension that can expand config 'ref' objects
 type ConfigExpander interface {
-	ExpandConfig(in interface{}) interface{}
+	ExpandConfig(ref Ref) (interface{}, error)
+}
+
+// RefChecker is an extension that can check if a 'ref' object can be
+// resolved, without the side effects of expanding it
+type RefChecker interface {
+	CheckRef(ref Ref) error
+}
+
+// ResolvePaths returns a copy of the configuration value in which the
+// relative file paths of the valueFrom references are resolved
+// against dir
+func ResolvePaths(in interface{}, dir string) interface{} {
+	switch k := in.(type) {
+	case []interface{}:
+		out := make([]interface{}, 0, len(k))
+		for _, x := range k {
+			out = append(out, ResolvePaths(x, dir))
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(k))
+		for key, x := range k {
+			if ref, ok := x.(map[string]interface{}); ok && key == "valueFrom" {
+				out[key] = ResolveRefPaths(ref, dir)
+			} else {
+				out[key] = ResolvePaths(x, dir)
+			}
+		}
+		return out
+	}
+	return in
+}
+
+// ResolveRefPaths returns a copy of the valueFrom reference in which
+// the relative file paths are resolved against dir
+func ResolveRefPaths(ref map[string]interface{}, dir string) map[string]interface{} {
+	str, _ := ref["type"].(string)
+	out := make(map[string]interface{}, len(ref))
+	for k, v := range ref {
+		out[k] = v
+	}
+	for _, field := range PathFields[str] {
+		if p, ok := out[field].(string); ok && len(p) > 0 && !filepath.IsAbs(p) {
+			out[field] = filepath.Join(dir, p)
+		}
+	}
+	return out
 }
@@ -2,9 +2,9 @@ package session
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
-	jptr "github.com/dustin/go-jsonpointer"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/bserdar/watermelon/server"
@@ -14,14 +14,19 @@ import (
 type Session struct {
 	sync.RWMutex
 
-	ID         string
-	Inv        server.InternalInventory
-	Modules    server.ModuleMgr
-	Log        server.Logging
-	LogStdout  bool
-	Config     interface{}
-	Extensions map[string]Extension
-	Args       []string
+	ID        string
+	Inv       server.InternalInventory
+	Modules   server.ModuleMgr
+	Log       server.Logging
+	LogStdout bool
+	// Configuration layers, ordered from the lowest precedence to
+	// the highest
+	ConfigLayers []server.ConfigLayer
+	Extensions   map[string]Extension
+	Args         []string
+	Handlers     server.Handlers
+
+	done chan struct{}
 }
 
 var sessionCtr = 0
@@ -32,19 +37,19 @@ func Factory() server.Session {
 	return &Session{ID: fmt.Sprintf("s-%d", sessionCtr), Extensions: map[string]Extension{}}
 }
 
-func (s *Session) getExtension(str string) Extension {
+func (s *Session) getExtension(str string) (Extension, error) {
 	s.Lock()
+	defer s.Unlock()
 	extension, ok := s.Extensions[str]
 	if !ok {
 		extensionFactory, ok := Extensions[str]
 		if !ok {
-			panic(fmt.Sprintf("Unknown extension: %s", str))
+			return nil, fmt.Errorf("Unknown extension: %s", str)
 		}
 		extension = extensionFactory(s)
 		s.Extensions[str] = extension
 	}
-	s.Unlock()
-	return extension
+	return extension, nil
 }
 
 // GetID returns session ID
@@ -68,11 +73,53 @@ func (s *Session) SetLogStdout(z bool) { s.LogStdout = z }
 // SetLog sets logger
 func (s *Session) SetLog(l server.Logging) { s.Log = l }
 
-// GetConfig returns the configuration
-func (s *Session) GetConfig() interface{} { return s.Config }
+// GetConfig returns the global configuration merged from all
+// configuration layers that are not specific to a label
+func (s *Session) GetConfig() interface{} {
+	layers, _ := s.configLayers("", "")
+	cfgs := make([]interface{}, 0, len(layers))
+	for _, l := range layers {
+		cfgs = append(cfgs, l.Config)
+	}
+	return server.ResolveCfg("", cfgs...)
+}
+
+// SetConfig sets the configuration as a single layer
+func (s *Session) SetConfig(i interface{}) {
+	s.SetConfigLayers([]server.ConfigLayer{{Name: "configuration", Config: i}})
+}
 
-// SetConfig sets the configuration
-func (s *Session) SetConfig(i interface{}) { s.Config = i }
+// GetConfigLayers returns the configuration layers, ordered from the
+// lowest precedence to the highest
+func (s *Session) GetConfigLayers() []server.ConfigLayer {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ConfigLayers
+}
+
+// SetConfigLayers sets the configuration layers, ordered from the
+// lowest precedence to the highest
+func (s *Session) SetConfigLayers(layers []server.ConfigLayer) {
+	for _, l := range layers {
+		server.AddPasswords(l.Config)
+	}
+	s.Lock()
+	s.ConfigLayers = layers
+	s.Unlock()
+}
+
+// AddDefaultConfig adds a configuration layer with the lowest
+// precedence, unless there is a layer with the same name and module
+func (s *Session) AddDefaultConfig(layer server.ConfigLayer) {
+	s.Lock()
+	defer s.Unlock()
+	for _, l := range s.ConfigLayers {
+		if l.Name == layer.Name && l.Module == layer.Module {
+			return
+		}
+	}
+	s.ConfigLayers = append([]server.ConfigLayer{layer}, s.ConfigLayers...)
+}
 
 // SetArgs sets args
 func (s *Session) SetArgs(args []string) { s.Args = args }
@@ -80,14 +127,40 @@ func (s *Session) SetArgs(args []string) { s.Args = args }
 // GetArgs returns args
 func (s *Session) GetArgs() []string { return s.Args }
 
+// GetHandlers returns the handlers of the session
+func (s *Session) GetHandlers() *server.Handlers { return &s.Handlers }
+
 // Close a session
 func (s *Session) Close() {
+	// Operations waiting for the session are cancelled first
+	s.Lock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.Unlock()
+
 	server.Sessions.Lock()
 	defer server.Sessions.Unlock()
+	s.Modules.CloseSession(s.ID)
 	s.Modules.Close()
 	delete(server.Sessions.Sessions, s.ID)
 }
 
+// Done returns a channel that is closed when the session is closed
+func (s *Session) Done() <-chan struct{} {
+	s.Lock()
+	defer s.Unlock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	return s.done
+}
+
 // GetLogger returns a logger for the host
 func (s *Session) GetLogger(host *server.Host) server.Logger {
 	return s.Log.New(host, s.LogStdout)
@@ -108,102 +181,215 @@ func (s *Session) GetHost(hostID string) (*server.Host, error) {
 	return host[0], nil
 }
 
-// getCfg returns a path from cfg, either seen by host, or global config
-func (s *Session) getCfg(hostId, path string) interface{} {
-	var cfg interface{}
-
-	log.Debugf("GetCfg with host=%s path=%s", hostId, path)
-	usingHostCfg := false
+// configLayers returns the configuration layers seen by host,
+// ordered from the highest precedence to the lowest. These are the
+// override layers, the host configuration, the label layers of the
+// host labels, the configurations of the groups of the host, and
+// then the global layers. The layers of modules other than module are
+// not included. If hostId is empty, only the override and global
+// layers are returned
+func (s *Session) configLayers(module, hostId string) ([]server.ConfigLayer, error) {
+	ret := make([]server.ConfigLayer, 0)
+	layers := s.GetConfigLayers()
+	add := func(f func(server.ConfigLayer) bool) {
+		for i := len(layers) - 1; i >= 0; i-- {
+			if (len(layers[i].Module) == 0 || layers[i].Module == module) && f(layers[i]) {
+				ret = append(ret, layers[i])
+			}
+		}
+	}
+	add(func(l server.ConfigLayer) bool { return l.Override })
 	if len(hostId) > 0 {
-		hosts, err := s.Inv.GetHost([]string{hostId})
+		host, err := s.GetHost(hostId)
 		if err != nil {
-			panic(hostId)
+			return nil, err
 		}
-		if len(hosts) == 1 {
-			cfg = hosts[0].Configuration
-			usingHostCfg = true
-			log.Debugf("Using host config: %v", hosts[0].Configuration)
+		log.Debugf("Using host config: %v groups: %v", host.Configuration, host.Groups)
+		ret = append(ret, server.ConfigLayer{Name: "host " + host.ID, Config: host.Configuration})
+		labels := host.GetInfo().Labels
+		add(func(l server.ConfigLayer) bool {
+			if l.Override || len(l.Label) == 0 {
+				return false
+			}
+			for _, x := range labels {
+				if x == l.Label {
+					return true
+				}
+			}
+			return false
+		})
+		for i, g := range host.GroupConfiguration {
+			name := "group"
+			if i < len(host.Groups) {
+				name = "group " + host.Groups[i]
+			}
+			ret = append(ret, server.ConfigLayer{Name: name, Config: g})
 		}
 	}
+	add(func(l server.ConfigLayer) bool { return !l.Override && len(l.Label) == 0 })
+	return ret, nil
+}
 
-	if cfg == nil {
-		cfg = s.Config
-		log.Debugf("Using global config: %v", cfg)
+// getCfg returns a path from cfg, either seen by host, or global
+// config, as seen by module. The maps in all configuration layers,
+// including the host configuration, are merged
+func (s *Session) getCfg(module, hostId, path string) (interface{}, error) {
+	log.Debugf("GetCfg with host=%s path=%s", hostId, path)
+	layers, err := s.configLayers(module, hostId)
+	if err != nil {
+		return nil, err
+	}
+	cfgs := make([]interface{}, 0, len(layers))
+	for _, l := range layers {
+		cfgs = append(cfgs, l.Config)
 	}
+	return server.ResolveCfg(path, cfgs...), nil
+}
 
-	if cfg == nil {
-		return nil
+// ExplainCfg returns the unexpanded value at path seen by host, and
+// the configuration layers that have a value at path, from the
+// highest precedence to the lowest
+func (s *Session) ExplainCfg(hostId, path string) (interface{}, []server.CfgSource, error) {
+	layers, err := s.configLayers("", hostId)
+	if err != nil {
+		return nil, nil, err
 	}
+	value, sources := server.ExplainCfg(path, layers...)
+	return value, sources, nil
+}
 
-	ret := jptr.Get(cfg.(map[string]interface{}), path)
-	if ret == nil {
+// GetCfg returns a path from cfg, either seen by host, or global
+// config. The valueFrom references in it are expanded
+func (s *Session) GetCfg(hostId, path string) (interface{}, error) {
+	return s.expandPath("", hostId, path, nil)
+}
 
-		if usingHostCfg {
-			cfg = s.Config
-			ret = jptr.Get(cfg.(map[string]interface{}), path)
-		}
-	}
-	if ret == nil {
-		return nil
-	}
-	return ret
+// GetModuleCfg is GetCfg as seen by module, including the layers of
+// the module
+func (s *Session) GetModuleCfg(module, hostId, path string) (interface{}, error) {
+	return s.expandPath(module, hostId, path, nil)
 }
 
-// GetCfg returns a path from cfg, either seen by host, or global config
-func (s *Session) GetCfg(hostId, path string) interface{} {
+// expandPath returns the expanded configuration at path as seen by
+// module. paths are the configuration paths already being expanded
+func (s *Session) expandPath(module, hostId, path string, paths []string) (interface{}, error) {
 	log.Debugf("getCfg %s with host %s", path, hostId)
-	ret := s.getCfg(hostId, path)
+	for _, p := range paths {
+		if p == path {
+			return nil, fmt.Errorf("Configuration reference cycle: %s -> %s", strings.Join(paths, " -> "), path)
+		}
+	}
+	ret, err := s.getCfg(module, hostId, path)
+	if err != nil {
+		return nil, err
+	}
 	if ret == nil {
 		log.Debugf("cfg %s not found in %s", path, hostId)
-		return ret
+		return ret, nil
+	}
+	ret, err = s.expandConfig(module, hostId, ret, append(paths[:len(paths):len(paths)], path))
+	if err != nil {
+		if len(path) == 0 {
+			path = "/"
+		}
+		return nil, fmt.Errorf("%s: %v", path, err)
 	}
-	return s.ExpandConfig(ret)
+	return ret, nil
 }
 
-func (s *Session) ExpandConfig(in interface{}) interface{} {
+// ExpandConfig expands the valueFrom references in the configuration
+// seen by host
+func (s *Session) ExpandConfig(hostId string, in interface{}) (interface{}, error) {
+	return s.expandConfig("", hostId, in, nil)
+}
+
+func (s *Session) expandConfig(module, hostId string, in interface{}, paths []string) (interface{}, error) {
 	// Expand config recursively
 	if m, ok := in.(map[string]interface{}); ok {
 		out := make(map[string]interface{})
 		for k, v := range m {
 			if k == "valueFrom" {
-				return s.ExpandRef(v)
-			} else {
-				out[k] = s.ExpandConfig(v)
+				return s.expandRef(module, hostId, v, paths)
+			}
+			x, err := s.expandConfig(module, hostId, v, paths)
+			if err != nil {
+				return nil, err
 			}
+			if server.IsPasswordKey(k) {
+				server.AddSecretValues(x)
+			}
+			out[k] = x
 		}
-		return out
+		return out, nil
 	}
 	if a, ok := in.([]interface{}); ok {
 		out := make([]interface{}, 0)
 		for _, x := range a {
-			out = append(out, s.ExpandConfig(x))
+			v, err := s.expandConfig(module, hostId, x, paths)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
 		}
-		return out
+		return out, nil
 	}
-	return in
+	return in, nil
 }
 
-// ExpandRef expands the configuration value using an extension if there is a mathching one
-func (s *Session) ExpandRef(in interface{}) interface{} {
+// ExpandRef expands the configuration value using an extension if
+// there is a mathching one
+func (s *Session) ExpandRef(hostId string, in interface{}) (interface{}, error) {
+	return s.expandRef("", hostId, in, nil)
+}
+
+func (s *Session) expandRef(module, hostId string, in interface{}, paths []string) (interface{}, error) {
 	log.Debugf("Expanding cfg reference %v", in)
+	if err := CheckRef(in); err != nil {
+		return nil, err
+	}
+	m := in.(map[string]interface{})
+	str := m["type"].(string)
+	extension, err := s.getExtension(str)
+	if err != nil {
+		return nil, err
+	}
+	expander, ok := extension.(ConfigExpander)
+	if !ok {
+		return nil, fmt.Errorf("Extension %s cannot deal with configuration", str)
+	}
+	ret, err := expander.ExpandConfig(Ref{HostID: hostId, Module: module, Value: m, paths: paths})
+	if err != nil {
+		return nil, fmt.Errorf("valueFrom %s: %v", str, err)
+	}
+	if secret, _ := m["secret"].(bool); secret {
+		server.AddSecretValues(ret)
+	}
+	return ret, nil
+}
+
+// CheckRef returns an error if the configuration reference cannot be
+// expanded because it has no type, there is no extension for it, or
+// the extension cannot resolve it. Config references are resolved
+// when they are expanded, so only their syntax is checked
+func CheckRef(in interface{}) error {
 	m, ok := in.(map[string]interface{})
 	if !ok {
-		return in
+		return fmt.Errorf("valueFrom must be an object: %v", in)
 	}
-	t, ok := m["type"]
+	str, ok := m["type"].(string)
 	if !ok {
-		return in
+		return fmt.Errorf("valueFrom requires a type: %v", in)
 	}
-	str, ok := t.(string)
+	factory, ok := Extensions[str]
 	if !ok {
-		return in
+		return fmt.Errorf("Unknown extension: %s", str)
 	}
-
-	extension := s.getExtension(str)
-
-	expander, ok := extension.(ConfigExpander)
+	checker, ok := factory(&Session{Extensions: map[string]Extension{}}).(RefChecker)
 	if !ok {
-		panic(fmt.Sprintf("Extension %s cannot deal with configuration", str))
+		return nil
+	}
+	if err := checker.CheckRef(Ref{Value: m}); err != nil {
+		return fmt.Errorf("valueFrom %s: %v", str, err)
 	}
-	return expander.ExpandConfig(in)
+	return nil
 }
@@ -91,13 +91,16 @@ func dial(dest Host, cycle map[string]struct{}) (*Client, error) {
 		conn, err := viaCli.SSH.Dial(dest.GetNetwork(), dest.GetHostAndPort())
 		if err != nil {
 			log.Debugf("Dial failed for %s %s: %s", dest.GetNetwork(), dest.GetHostAndPort(), err.Error())
-			panic(err)
+			viaCli.Close()
+			return nil, err
 		}
 		logger.Debugf("Creating new ssh connection to %s", dest.GetHostAndPort())
 		ncc, chans, reqs, err := ssh.NewClientConn(conn, dest.GetHostAndPort(), cfg)
 		if err != nil {
 			log.Debugf("New connection failed %s: %s", dest.GetHostAndPort(), err.Error())
-			panic(err)
+			conn.Close()
+			viaCli.Close()
+			return nil, err
 		}
 		client = &Client{SSH: ssh.NewClient(ncc, chans, reqs)}
 	} else {
@@ -105,7 +108,7 @@ func dial(dest Host, cycle map[string]struct{}) (*Client, error) {
 		c, err := ssh.Dial(dest.GetNetwork(), dest.GetHostAndPort(), cfg)
 		if err != nil {
 			logger.Debugf("Dial failed for %s %s: %s", dest.GetNetwork(), dest.GetHostAndPort(), err.Error())
-			panic(err)
+			return nil, err
 		}
 		client = &Client{SSH: c}
 	}
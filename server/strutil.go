@@ -3,6 +3,7 @@ package server
 import (
 	"bufio"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +17,22 @@ func ArrayContains(arr []string, str string) bool {
 	return false
 }
 
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys(m map[string]string) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// ShellQuote quotes the string so it is passed as a single word to
+// a POSIX shell
+func ShellQuote(in string) string {
+	return "'" + strings.Replace(in, "'", `'\''`, -1) + "'"
+}
+
 // Words breaks the line into words separated by whitespace
 func Words(in string) []string {
 	scn := bufio.NewScanner(strings.NewReader(in))
@@ -27,7 +44,9 @@ func Words(in string) []string {
 	return out
 }
 
-// MapYaml converts map[interface{}]interface{} to map[string]interface{}
+// MapYaml converts map[interface{}]interface{} to
+// map[string]interface{}, including the maps nested in
+// map[string]interface{}
 func MapYaml(in interface{}) interface{} {
 	if arr, ok := in.([]interface{}); ok {
 		out := make([]interface{}, 0, len(arr))
@@ -43,5 +62,12 @@ func MapYaml(in interface{}) interface{} {
 		}
 		return out
 	}
+	if m, ok := in.(map[string]interface{}); ok {
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[k] = MapYaml(v)
+		}
+		return out
+	}
 	return in
 }
@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"errors"
+	"io/ioutil"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/session"
+)
+
+// expander decrypts configuration values when they are used:
+//
+//	password:
+//	  valueFrom:
+//	    type: vault
+//	    value: $WMVAULT;1;...
+//
+// or, to decrypt an encrypted file relative to the configuration
+// file:
+//
+//	valueFrom:
+//	  type: vault
+//	  file: secrets/db.pwd
+type expander struct{}
+
+func init() {
+	session.Extensions["vault"] = func(server.Session) session.Extension { return expander{} }
+	session.PathFields["vault"] = []string{"file"}
+}
+
+// ExpandConfig decrypts the value or the file of the reference. The
+// decrypted value is masked in logs and outputs
+func (expander) ExpandConfig(ref session.Ref) (interface{}, error) {
+	var data []byte
+	if v, ok := ref.Value["value"].(string); ok {
+		data = []byte(v)
+	} else if f, ok := ref.Value["file"].(string); ok {
+		var err error
+		if data, err = ioutil.ReadFile(f); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("value or file is required")
+	}
+	if !IsEncrypted(data) {
+		return nil, errors.New("not encrypted")
+	}
+	out, err := Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	server.AddSecret(string(out))
+	return string(out), nil
+}
+
+// CheckRef checks if the value or the file of the reference can be
+// decrypted
+func (e expander) CheckRef(ref session.Ref) error {
+	_, err := e.ExpandConfig(ref)
+	return err
+}
@@ -0,0 +1,311 @@
+// Package vault encrypts secrets stored in the inventory and the
+// configuration files.
+//
+// A secret is encrypted with AES-256-GCM using a key derived from the
+// vault passphrase with scrypt. Each secret has its own random salt
+// and nonce. An encrypted secret is a string of the form
+//
+//	$WMVAULT;1;<base64 of salt, nonce, and ciphertext>
+//
+// Encrypted files contain the same string, with the base64 data
+// wrapped into multiple lines.
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+	sshdial "github.com/bserdar/watermelon/server/ssh"
+)
+
+// Header is the prefix of encrypted secrets
+const Header = "$WMVAULT;1;"
+
+const (
+	saltSize  = 16
+	nonceSize = 12
+	keySize   = 32
+	lineWidth = 76
+)
+
+// ErrNoPassphrase is returned if a secret has to be decrypted, but
+// there is no vault passphrase
+var ErrNoPassphrase = errors.New("Vault passphrase required")
+
+// Vault encrypts and decrypts secrets using a passphrase
+type Vault struct {
+	sync.Mutex
+	passphrase []byte
+	// Derived keys by salt, so secrets encrypted using the same salt
+	// do not run scrypt again
+	keys map[string][]byte
+}
+
+// New returns a new vault using the passphrase
+func New(passphrase []byte) *Vault {
+	return &Vault{passphrase: passphrase, keys: make(map[string][]byte)}
+}
+
+func (v *Vault) key(salt []byte) ([]byte, error) {
+	v.Lock()
+	defer v.Unlock()
+	if k, ok := v.keys[string(salt)]; ok {
+		return k, nil
+	}
+	k, err := scrypt.Key(v.passphrase, salt, 32768, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+	v.keys[string(salt)] = k
+	return k, nil
+}
+
+func (v *Vault) gcm(salt []byte) (cipher.AEAD, error) {
+	key, err := v.key(salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts plaintext, and returns the encrypted secret
+func (v *Vault) Encrypt(plaintext []byte) (string, error) {
+	buf := make([]byte, saltSize+nonceSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	gcm, err := v.gcm(buf[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	buf = gcm.Seal(buf, buf[saltSize:], plaintext, []byte(Header))
+	return Header + base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// EncryptFile encrypts plaintext, and returns the file contents
+// containing the encrypted data
+func (v *Vault) EncryptFile(plaintext []byte) ([]byte, error) {
+	s, err := v.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	data := s[len(Header):]
+	out := bytes.Buffer{}
+	out.WriteString(Header)
+	for len(data) > 0 {
+		n := lineWidth
+		if n > len(data) {
+			n = len(data)
+		}
+		out.WriteString("\n")
+		out.WriteString(data[:n])
+		data = data[n:]
+	}
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+// Decrypt decrypts an encrypted secret or file
+func (v *Vault) Decrypt(secret []byte) ([]byte, error) {
+	s := strings.TrimSpace(string(secret))
+	if !strings.HasPrefix(s, Header) {
+		return nil, errors.New("Not encrypted")
+	}
+	s = strings.Join(strings.Fields(s[len(Header):]), "")
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid vault data: %v", err)
+	}
+	if len(data) < saltSize+nonceSize {
+		return nil, errors.New("Invalid vault data")
+	}
+	gcm, err := v.gcm(data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	ret, err := gcm.Open(nil, data[saltSize:saltSize+nonceSize], data[saltSize+nonceSize:], []byte(Header))
+	if err != nil {
+		return nil, errors.New("Cannot decrypt, wrong vault passphrase or corrupt data")
+	}
+	return ret, nil
+}
+
+// IsEncrypted returns true if data is an encrypted secret or file
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(Header))
+}
+
+// PassphraseFile is the file containing the vault passphrase. If
+// empty, WM_VAULT_PASSWORD_FILE is used. If that is also empty, the
+// passphrase is asked using the terminal
+var PassphraseFile string
+
+var defaultVault struct {
+	sync.Mutex
+	v *Vault
+}
+
+// ReadPassphrase reads the passphrase from file. Trailing newlines are
+// removed
+func ReadPassphrase(file string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(data, "\r\n"), nil
+}
+
+// Default returns the vault used to decrypt the inventory and the
+// configuration. The passphrase is read the first time it is needed
+func Default() (*Vault, error) {
+	defaultVault.Lock()
+	defer defaultVault.Unlock()
+	if defaultVault.v != nil {
+		return defaultVault.v, nil
+	}
+	file := PassphraseFile
+	if len(file) == 0 {
+		file = os.Getenv("WM_VAULT_PASSWORD_FILE")
+	}
+	var passphrase []byte
+	if len(file) > 0 {
+		var err error
+		if passphrase, err = ReadPassphrase(file); err != nil {
+			return nil, err
+		}
+	} else {
+		passphrase = []byte(sshdial.AskPassword("Vault passphrase: "))
+	}
+	if len(passphrase) == 0 {
+		return nil, ErrNoPassphrase
+	}
+	defaultVault.v = New(passphrase)
+	return defaultVault.v, nil
+}
+
+// SetDefault sets the vault used to decrypt the inventory and the
+// configuration
+func SetDefault(v *Vault) {
+	defaultVault.Lock()
+	defaultVault.v = v
+	defaultVault.Unlock()
+}
+
+// Decrypt decrypts data using the default vault if it is encrypted,
+// and returns it unchanged otherwise
+func Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	v, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return v.Decrypt(data)
+}
+
+// DecryptValues returns a copy of the YAML document in which all the
+// encrypted strings are decrypted using the default vault. valueFrom
+// references are not decrypted, they are expanded when they are used.
+// The decrypted values are masked in logs and outputs
+func DecryptValues(in interface{}) (interface{}, error) {
+	switch k := in.(type) {
+	case string:
+		if !IsEncrypted([]byte(k)) {
+			return k, nil
+		}
+		out, err := Decrypt([]byte(k))
+		if err != nil {
+			return nil, err
+		}
+		server.AddSecret(string(out))
+		return string(out), nil
+	case []interface{}:
+		out := make([]interface{}, 0, len(k))
+		for _, x := range k {
+			v, err := DecryptValues(x)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case map[interface{}]interface{}:
+		out := make(map[interface{}]interface{}, len(k))
+		for key, x := range k {
+			if key == "valueFrom" {
+				out[key] = x
+				continue
+			}
+			v, err := DecryptValues(x)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(k))
+		for key, x := range k {
+			if key == "valueFrom" {
+				out[key] = x
+				continue
+			}
+			v, err := DecryptValues(x)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+		return out, nil
+	}
+	return in, nil
+}
+
+// DecryptDocument decrypts a YAML or JSON document. If the document is
+// an encrypted file, it is decrypted, and all the values in it are
+// masked in logs and outputs. Then, all the encrypted strings in the
+// document are decrypted. Documents without encrypted data are
+// returned unchanged
+func DecryptDocument(data []byte) ([]byte, error) {
+	encrypted := IsEncrypted(data)
+	data, err := Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	hasValues := bytes.Contains(data, []byte(Header))
+	if !encrypted && !hasValues {
+		return data, nil
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if encrypted {
+		server.AddSecretValues(server.MapYaml(doc))
+	}
+	if !hasValues {
+		return data, nil
+	}
+	if doc, err = DecryptValues(doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
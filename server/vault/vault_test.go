@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/bserdar/watermelon/server"
+	"github.com/bserdar/watermelon/server/session"
+)
+
+func TestVault(t *testing.T) {
+	v := New([]byte("secret"))
+	s, err := v.Encrypt([]byte("pwd01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted([]byte(s)) || strings.Contains(s, "pwd01") {
+		t.Errorf("Not encrypted: %s", s)
+	}
+	if out, err := v.Decrypt([]byte(s)); err != nil || string(out) != "pwd01" {
+		t.Errorf("Wrong decryption: %s %v", out, err)
+	}
+	if _, err := New([]byte("wrong")).Decrypt([]byte(s)); err == nil {
+		t.Errorf("Expecting error with wrong passphrase")
+	}
+	tampered := []byte(s)
+	tampered[len(tampered)-3] ^= 1
+	if _, err := v.Decrypt(tampered); err == nil {
+		t.Errorf("Expecting error for tampered data")
+	}
+
+	file, err := v.EncryptFile([]byte(strings.Repeat("data ", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(string(file), "\n"); len(lines) < 3 || len(lines[1]) != lineWidth {
+		t.Errorf("Wrong file format: %s", file)
+	}
+	if out, err := v.Decrypt(file); err != nil || string(out) != strings.Repeat("data ", 100) {
+		t.Errorf("Wrong file decryption: %v", err)
+	}
+}
+
+func TestDecryptDocument(t *testing.T) {
+	v := New([]byte("secret"))
+	SetDefault(v)
+	defer SetDefault(nil)
+
+	pwd, _ := v.Encrypt([]byte("pwd01"))
+	ref, _ := v.Encrypt([]byte("lazy"))
+	doc := "hosts:\n  - id: h1\n    ssh:\n      password: " + pwd + "\nconfiguration:\n  db:\n    valueFrom:\n      type: vault\n      value: " + ref + "\n"
+	data, err := DecryptDocument([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	host := out["hosts"].([]interface{})[0].(map[string]interface{})
+	if p := host["ssh"].(map[string]interface{})["password"]; p != "pwd01" {
+		t.Errorf("Wrong password: %v", p)
+	}
+	// valueFrom references are expanded when used
+	cfg := out["configuration"].(map[string]interface{})["db"].(map[string]interface{})["valueFrom"].(map[string]interface{})
+	if cfg["value"] != ref {
+		t.Errorf("Reference decrypted at load: %v", cfg)
+	}
+	if err := session.CheckRef(map[string]interface{}{"type": "vault", "value": ref}); err != nil {
+		t.Error(err)
+	}
+	if x, err := (expander{}).ExpandConfig(session.Ref{Value: map[string]interface{}{"type": "vault", "value": ref}}); err != nil || x != "lazy" {
+		t.Errorf("Wrong expansion: %v %v", x, err)
+	}
+
+	encrypted, _ := v.EncryptFile([]byte(doc))
+	if data, err := DecryptDocument(encrypted); err != nil || !strings.Contains(string(data), "pwd01") {
+		t.Errorf("Cannot decrypt file: %s %v", data, err)
+	}
+	// All values of an encrypted file are secrets
+	encrypted, _ = v.EncryptFile([]byte("user: dbadmin\nport: 5433\n"))
+	if _, err := DecryptDocument(encrypted); err != nil {
+		t.Fatal(err)
+	}
+	if s := server.Redact("dbadmin:5433"); s != server.Mask+":"+server.Mask {
+		t.Errorf("Values not masked: %s", s)
+	}
+}
+
+func TestFileRef(t *testing.T) {
+	v := New([]byte("secret"))
+	SetDefault(v)
+	defer SetDefault(nil)
+
+	dir, err := ioutil.TempDir("", "vaultref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	encrypted, _ := v.EncryptFile([]byte("filepwd"))
+	ioutil.WriteFile(filepath.Join(dir, "db.pwd"), encrypted, 0600)
+
+	cfg := session.ResolvePaths(map[string]interface{}{
+		"db": map[string]interface{}{"valueFrom": map[string]interface{}{"type": "vault", "file": "db.pwd"}},
+	}, dir)
+	ref := cfg.(map[string]interface{})["db"].(map[string]interface{})["valueFrom"].(map[string]interface{})
+	if x, err := (expander{}).ExpandConfig(session.Ref{Value: ref}); err != nil || x != "filepwd" {
+		t.Errorf("Wrong expansion: %v %v", x, err)
+	}
+	// Values of wrong types are errors
+	if _, err := (expander{}).ExpandConfig(session.Ref{Value: map[string]interface{}{"type": "vault", "value": 5}}); err == nil {
+		t.Errorf("Expecting error for invalid value")
+	}
+}
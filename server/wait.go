@@ -0,0 +1,217 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Default wait parameters
+const (
+	DefaultPollInterval = 2 * time.Second
+	DefaultMaxInterval  = 30 * time.Second
+	DefaultProbeTimeout = 5 * time.Second
+)
+
+// WaitOptions control how a host is polled while waiting for it. The
+// poll interval starts at PollInterval, and doubles after every
+// failed attempt until it reaches MaxInterval.
+type WaitOptions struct {
+	PollInterval time.Duration
+	MaxInterval  time.Duration
+	ProbeTimeout time.Duration
+}
+
+// withDefaults returns a copy of the options with the unset values
+// initialized
+func (w WaitOptions) withDefaults() WaitOptions {
+	if w.PollInterval <= 0 {
+		w.PollInterval = DefaultPollInterval
+	}
+	if w.MaxInterval <= 0 {
+		w.MaxInterval = DefaultMaxInterval
+	}
+	if w.MaxInterval < w.PollInterval {
+		w.MaxInterval = w.PollInterval
+	}
+	if w.ProbeTimeout <= 0 {
+		w.ProbeTimeout = DefaultProbeTimeout
+	}
+	return w
+}
+
+// nextInterval doubles the interval, up to max
+func nextInterval(current, max time.Duration) time.Duration {
+	current *= 2
+	if current > max {
+		return max
+	}
+	return current
+}
+
+// CanProbe returns true if the host can be probed at TCP level. Hosts
+// behind a bastion and hosts without a hostname cannot be probed
+func (h *Host) CanProbe() bool {
+	return h.Bastion == nil && len(h.Hostname) > 0
+}
+
+// Probe checks if the host accepts TCP connections at its SSH
+// port. This is much cheaper than an SSH handshake. If the host
+// cannot be probed, returns nil
+func (h *Host) Probe(timeout time.Duration) error {
+	if !h.CanProbe() {
+		return nil
+	}
+	conn, err := net.DialTimeout(h.GetNetwork(), h.GetHostAndPort(), timeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// reachable returns nil if the host accepts TCP connections, and a
+// new session can be opened
+func (h *Host) reachable(s Session, opts WaitOptions) error {
+	if err := h.Probe(opts.ProbeTimeout); err != nil {
+		return err
+	}
+	ctx := h.NewCtx()
+	_, err := ctx.New(s)
+	if err != nil {
+		return err
+	}
+	ctx.Close()
+	return nil
+}
+
+// WaitUp waits until the host becomes reachable, or until timeout
+func (h *Host) WaitUp(s Session, timeout time.Duration, opts WaitOptions) error {
+	opts = opts.withDefaults()
+	end := time.Now().Add(timeout)
+	interval := opts.PollInterval
+	for {
+		log.Debugf("Waiting for host %s", h.ID)
+		err := h.reachable(s, opts)
+		if err == nil {
+			return nil
+		}
+		log.Debugf("Host %s is not reachable: %v", h.ID, err)
+		remaining := time.Until(end)
+		if remaining <= 0 {
+			return fmt.Errorf("Timeout while waiting for %s to become available: %s", h.ID, err)
+		}
+		// Do not sleep past the deadline, so the host is probed one
+		// last time at the deadline
+		if remaining < interval {
+			time.Sleep(remaining)
+		} else {
+			time.Sleep(interval)
+		}
+		interval = nextInterval(interval, opts.MaxInterval)
+	}
+}
+
+// WaitDown waits until the host stops accepting connections, or
+// until timeout. If bootID is nonempty, a host whose boot ID is
+// different went down and came back up between two polls, so it is
+// also considered to have gone down
+func (h *Host) WaitDown(s Session, timeout time.Duration, opts WaitOptions, bootID string) error {
+	opts = opts.withDefaults()
+	end := time.Now().Add(timeout)
+	for {
+		var err error
+		if h.CanProbe() {
+			err = h.Probe(opts.ProbeTimeout)
+		} else {
+			err = h.reachable(s, opts)
+		}
+		if err != nil {
+			log.Debugf("Host %s is down: %v", h.ID, err)
+			return nil
+		}
+		if len(bootID) > 0 {
+			id, _, err := h.BootID(h.NewCtx(), s)
+			if err != nil {
+				log.Debugf("Host %s is down: %v", h.ID, err)
+				return nil
+			}
+			if len(id) > 0 && id != bootID {
+				log.Debugf("Host %s rebooted, boot id: %s", h.ID, id)
+				return nil
+			}
+		}
+		if time.Now().Add(opts.PollInterval).After(end) {
+			return fmt.Errorf("Timeout while waiting for %s to go down", h.ID)
+		}
+		// Poll at a fixed interval, the host is going down soon
+		time.Sleep(opts.PollInterval)
+	}
+}
+
+// BootID returns the boot ID of the host. The boot ID changes every
+// time the host boots
+func (h *Host) BootID(ctx Ctx, s Session) (string, CmdErr, error) {
+	rsp, err := h.RunCmd(ctx, s, "cat /proc/sys/kernel/random/boot_id", nil)
+	if err != nil {
+		return "", nil, err
+	}
+	id := strings.TrimSpace(string(rsp.Out))
+	if rsp.ExitCode != 0 || len(id) == 0 {
+		return "", NewCmdErr(h, "Cannot read boot id: %s", string(rsp.Err)), nil
+	}
+	return id, nil, nil
+}
+
+// rebootCmd returns the command that reboots the host in the
+// background, so the command can return before the connection
+// drops. Commands run using the become method of the host. If the
+// host has none, sudo is used unless the user is root
+func rebootCmd(h *Host) string {
+	cmd := "nohup sh -c 'sleep 2; reboot' >/dev/null 2>&1 &"
+	if len(h.Become) > 0 {
+		return cmd
+	}
+	return fmt.Sprintf(`if [ "$(id -u)" = 0 ]; then %s else sudo -n sh -c %s; fi`, cmd, ShellQuote(cmd))
+}
+
+// Reboot reboots the host, and waits until it comes back. The boot ID
+// is recorded before the reboot, and it is compared to the boot ID
+// after the host is up to make sure the host actually rebooted.
+// Returns the old and new boot IDs.
+func (h *Host) Reboot(s Session, timeout time.Duration, opts WaitOptions) (string, string, CmdErr, error) {
+	if h.ID == LocalhostID {
+		return "", "", NewCmdErr(h, "Cannot reboot localhost"), nil
+	}
+	end := time.Now().Add(timeout)
+	oldID, cerr, err := h.BootID(h.NewCtx(), s)
+	if err != nil || cerr != nil {
+		return "", "", cerr, err
+	}
+	s.GetLogger(h).Printf("Rebooting, boot id: %s", oldID)
+	rsp, err := h.RunCmd(h.NewCtx(), s, rebootCmd(h), nil)
+	if err != nil {
+		return oldID, "", nil, err
+	}
+	if rsp.ExitCode != 0 {
+		return oldID, "", NewCmdErr(h, "Cannot reboot: %s", string(rsp.Err)), nil
+	}
+	if err := h.WaitDown(s, time.Until(end), opts, oldID); err != nil {
+		return oldID, "", nil, err
+	}
+	if err := h.WaitUp(s, time.Until(end), opts); err != nil {
+		return oldID, "", nil, err
+	}
+	newID, cerr, err := h.BootID(h.NewCtx(), s)
+	if err != nil || cerr != nil {
+		return oldID, "", cerr, err
+	}
+	s.GetLogger(h).Printf("Host is up, boot id: %s", newID)
+	if newID == oldID {
+		return oldID, newID, NewCmdErr(h, "Boot id did not change, host did not reboot"), nil
+	}
+	return oldID, newID, nil, nil
+}
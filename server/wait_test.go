@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNextInterval(t *testing.T) {
+	opts := WaitOptions{PollInterval: time.Second, MaxInterval: 5 * time.Second}.withDefaults()
+	i := opts.PollInterval
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for _, x := range expected {
+		i = nextInterval(i, opts.MaxInterval)
+		if i != x {
+			t.Errorf("Expected %s, got %s", x, i)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Host{Hostname: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port}
+	if err := h.Probe(time.Second); err != nil {
+		t.Errorf("Probe failed: %s", err)
+	}
+	l.Close()
+	if err := h.Probe(time.Second); err == nil {
+		t.Errorf("Expecting probe to fail")
+	}
+	h.Bastion = &Host{}
+	if h.CanProbe() {
+		t.Errorf("Cannot probe via bastion")
+	}
+}